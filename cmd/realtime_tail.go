@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"blockscout-vc/internal/client"
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/heartbeat"
+	"blockscout-vc/internal/subscription"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// RealtimeTailCmd creates and returns the realtime-tail command.
+// It prints decoded realtime messages without touching env files or docker.
+func RealtimeTailCmd() *cobra.Command {
+	realtimeTail := &cobra.Command{
+		Use:   "realtime-tail",
+		Short: "Print realtime messages for debugging",
+		Long:  `Subscribes to the configured tables and pretty-prints every realtime message until interrupted. No handlers run, so env files and containers are left untouched`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			configPath, err := cmd.Flags().GetString("config")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			config.InitConfig(configPath)
+
+			overrides, err := cmd.Flags().GetStringArray("set")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err := config.ApplyOverrides(overrides); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			supabaseRealtimeUrl := viper.GetString("supabaseRealtimeUrl")
+			supabaseAnonKey := viper.GetString("supabaseAnonKey")
+			if supabaseRealtimeUrl == "" || supabaseAnonKey == "" {
+				return fmt.Errorf("supabaseRealtimeUrl and supabaseAnonKey must be configured")
+			}
+
+			realtimeClient := client.New(supabaseRealtimeUrl, supabaseAnonKey)
+			if err := realtimeClient.Connect(); err != nil {
+				return fmt.Errorf("failed to connect to Supabase realtime: %w", err)
+			}
+			defer func() {
+				if closeErr := realtimeClient.Close(); closeErr != nil {
+					fmt.Fprintf(os.Stderr, "Error closing realtime client: %v\n", closeErr)
+				}
+			}()
+
+			// Keep the connection alive the same way the sidecar does
+			hb := heartbeat.New(realtimeClient, 30*time.Second)
+			hb.Start()
+			defer hb.Stop()
+
+			tableNames := []string{}
+			for _, table := range config.GetTables() {
+				tableNames = append(tableNames, table.Name)
+			}
+			if err := realtimeClient.Conn.WriteJSON(subscription.NewJoinPayload(tableNames)); err != nil {
+				return fmt.Errorf("failed to subscribe: %w", err)
+			}
+			fmt.Printf("Subscribed to %v, waiting for messages (Ctrl+C to stop)...\n", tableNames)
+
+			readErrChan := make(chan error, 1)
+			go func() {
+				for {
+					_, message, err := realtimeClient.Conn.ReadMessage()
+					if err != nil {
+						readErrChan <- err
+						return
+					}
+					if err := printRealtimeMessage(os.Stdout, message); err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to decode message: %v\n%s\n", err, message)
+					}
+				}
+			}()
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+			select {
+			case <-interrupt:
+				fmt.Println("\nReceived interrupt signal, stopping...")
+				return nil
+			case err := <-readErrChan:
+				return fmt.Errorf("read error: %w", err)
+			}
+		},
+	}
+	realtimeTail.PersistentFlags().StringP("config", "c", "", "Path of the configuration file")
+	realtimeTail.PersistentFlags().StringArray("set", nil, "Override a config key, e.g. --set chainId=1313161556 (repeatable)")
+	return realtimeTail
+}
+
+// printRealtimeMessage decodes a raw realtime message and writes it to w.
+// Database changes include the type, table and record fields; other events
+// (replies, heartbeats) are printed by name only
+func printRealtimeMessage(w io.Writer, message []byte) error {
+	changes, err := subscription.NewPostgresChanges(message, nil)
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Format(time.RFC3339)
+	if changes.Event != "postgres_changes" {
+		_, err := fmt.Fprintf(w, "%s event=%s\n", timestamp, changes.Event)
+		return err
+	}
+
+	record, err := json.MarshalIndent(changes.Payload.Data.Record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s event=%s type=%s table=%s\n%s\n",
+		timestamp, changes.Event, changes.Payload.Data.Type, changes.Payload.Data.Table, record)
+	return err
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintRealtimeMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{
+			name: "database change",
+			message: `{"event":"postgres_changes","topic":"realtime:public:silos","payload":{"data":{"table":"silos","type":"UPDATE",
+				"record":{"id":7,"name":"Aurora","base_token_symbol":"ETH","chain_id":1313161555,"created_at":"2024-05-01T12:30:45Z"}}}}`,
+			want: []string{"event=postgres_changes type=UPDATE table=silos", `"name": "Aurora"`, `"chain_id": 1313161555`, `"base_token_symbol": "ETH"`},
+		},
+		{
+			name:    "heartbeat reply",
+			message: `{"event":"phx_reply","topic":"phoenix","payload":{"status":"ok"}}`,
+			want:    []string{"event=phx_reply"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := printRealtimeMessage(&out, []byte(tt.message)); err != nil {
+				t.Fatalf("printRealtimeMessage: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintRealtimeMessageInvalid(t *testing.T) {
+	var out bytes.Buffer
+	if err := printRealtimeMessage(&out, []byte(`not json`)); err == nil {
+		t.Error("printRealtimeMessage accepted an invalid message")
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"blockscout-vc/internal/config"
+	"fmt"
+)
+
+// sidecarRoles are the parts of the sidecar enabled with enable.httpServer,
+// enable.realtime and enable.worker
+type sidecarRoles struct {
+	httpServer bool
+	realtime   bool
+	worker     bool
+}
+
+// configuredRoles reads the enabled roles from the config
+func configuredRoles() sidecarRoles {
+	return sidecarRoles{
+		httpServer: config.GetEnableHTTPServer(),
+		realtime:   config.GetEnableRealtime(),
+		worker:     config.GetEnableWorker(),
+	}
+}
+
+// validate rejects role combinations with nothing to do. The worker only runs jobs queued
+// by the HTTP server or realtime handlers in the same process, so a worker-only process
+// is not valid
+func (r sidecarRoles) validate() error {
+	if !r.httpServer && !r.realtime {
+		return fmt.Errorf("enable.httpServer and enable.realtime are both disabled; the worker alone has no jobs to run")
+	}
+	return nil
+}
+
+// needsComposeFile reports whether this process recreates containers and so needs pathToDockerCompose
+func (r sidecarRoles) needsComposeFile() bool {
+	return r.worker && config.GetManageContainers()
+}
+
+// needsEnvFile reports whether this process applies records or recreates containers and so
+// needs the chain's env file; an API-only process leaves it to the host running those roles
+func (r sidecarRoles) needsEnvFile() bool {
+	return r.realtime || r.worker
+}
+
+// roleStarters start each sidecar role. An error from a starter stops the sidecar
+type roleStarters struct {
+	worker     func()
+	httpServer func() error
+	realtime   func() error
+}
+
+// start runs the starters of the enabled roles and returns the roles it started. The worker
+// starts first since the other roles queue jobs on it, and the HTTP server before realtime
+// so the subscription can be handed to it. Roles after a failed starter are not started
+func (r sidecarRoles) start(starters roleStarters) (sidecarRoles, error) {
+	var started sidecarRoles
+	if r.worker {
+		starters.worker()
+		started.worker = true
+	} else {
+		fmt.Println("Worker disabled (enable.worker), containers will not be recreated")
+	}
+
+	if r.httpServer {
+		if err := starters.httpServer(); err != nil {
+			return started, err
+		}
+		started.httpServer = true
+	} else {
+		fmt.Println("HTTP server disabled (enable.httpServer)")
+	}
+
+	if r.realtime {
+		if err := starters.realtime(); err != nil {
+			return started, err
+		}
+		started.realtime = true
+	} else {
+		fmt.Println("Database change monitoring disabled (enable.realtime)")
+	}
+	return started, nil
+}
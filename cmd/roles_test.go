@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfiguredRolesDefaultToEnabled(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	roles := configuredRoles()
+	if !roles.httpServer || !roles.realtime || !roles.worker {
+		t.Fatalf("roles = %+v, want every role enabled by default", roles)
+	}
+}
+
+func TestSidecarRoles(t *testing.T) {
+	tests := []struct {
+		name            string
+		settings        map[string]any
+		wantErr         bool
+		wantComposeFile bool
+		wantEnvFile     bool
+		wantHTTPServer  bool
+		wantRealtime    bool
+		wantWorker      bool
+	}{
+		{
+			name:            "all roles",
+			settings:        map[string]any{},
+			wantComposeFile: true,
+			wantEnvFile:     true,
+			wantHTTPServer:  true,
+			wantRealtime:    true,
+			wantWorker:      true,
+		},
+		{
+			name:           "API only",
+			settings:       map[string]any{"enable.realtime": false, "enable.worker": false},
+			wantHTTPServer: true,
+		},
+		{
+			name:            "reconciler only",
+			settings:        map[string]any{"enable.httpServer": false},
+			wantComposeFile: true,
+			wantEnvFile:     true,
+			wantRealtime:    true,
+			wantWorker:      true,
+		},
+		{
+			name:           "worker without container management",
+			settings:       map[string]any{"manageContainers": false},
+			wantEnvFile:    true,
+			wantHTTPServer: true,
+			wantRealtime:   true,
+			wantWorker:     true,
+		},
+		{
+			name:            "worker only",
+			settings:        map[string]any{"enable.httpServer": false, "enable.realtime": false},
+			wantErr:         true,
+			wantComposeFile: true,
+			wantEnvFile:     true,
+			wantWorker:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("manageContainers", true)
+			for key, value := range tt.settings {
+				viper.Set(key, value)
+			}
+
+			roles := configuredRoles()
+			if err := roles.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if roles.httpServer != tt.wantHTTPServer || roles.realtime != tt.wantRealtime || roles.worker != tt.wantWorker {
+				t.Fatalf("roles = %+v", roles)
+			}
+			if got := roles.needsComposeFile(); got != tt.wantComposeFile {
+				t.Errorf("needsComposeFile() = %v, want %v", got, tt.wantComposeFile)
+			}
+			if got := roles.needsEnvFile(); got != tt.wantEnvFile {
+				t.Errorf("needsEnvFile() = %v, want %v", got, tt.wantEnvFile)
+			}
+		})
+	}
+}
+
+// recordingStarters returns starters that record the roles they start, failing the named one
+func recordingStarters(calls *[]string, failing string) roleStarters {
+	starter := func(role string) func() error {
+		return func() error {
+			*calls = append(*calls, role)
+			if role == failing {
+				return errors.New(role + " failed")
+			}
+			return nil
+		}
+	}
+	return roleStarters{
+		worker:     func() { _ = starter("worker")() },
+		httpServer: starter("httpServer"),
+		realtime:   starter("realtime"),
+	}
+}
+
+func TestStartRolesStartsEnabledCombinations(t *testing.T) {
+	for _, httpServer := range []bool{false, true} {
+		for _, realtime := range []bool{false, true} {
+			for _, worker := range []bool{false, true} {
+				t.Run(fmt.Sprintf("httpServer=%t realtime=%t worker=%t", httpServer, realtime, worker), func(t *testing.T) {
+					t.Cleanup(viper.Reset)
+					viper.Set("enable.httpServer", httpServer)
+					viper.Set("enable.realtime", realtime)
+					viper.Set("enable.worker", worker)
+
+					roles := configuredRoles()
+					if err := roles.validate(); err != nil {
+						if httpServer || realtime {
+							t.Fatalf("validate() = %v", err)
+						}
+						return
+					}
+
+					var calls []string
+					started, err := roles.start(recordingStarters(&calls, ""))
+					if err != nil {
+						t.Fatalf("start() = %v", err)
+					}
+					want := sidecarRoles{httpServer: httpServer, realtime: realtime, worker: worker}
+					if started != want {
+						t.Errorf("started = %+v, want %+v", started, want)
+					}
+					var wantCalls []string
+					for _, role := range []struct {
+						name    string
+						enabled bool
+					}{{"worker", worker}, {"httpServer", httpServer}, {"realtime", realtime}} {
+						if role.enabled {
+							wantCalls = append(wantCalls, role.name)
+						}
+					}
+					if !reflect.DeepEqual(calls, wantCalls) {
+						t.Errorf("started in order %v, want %v", calls, wantCalls)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestStartRolesStopsAtFailedStarter(t *testing.T) {
+	roles := sidecarRoles{httpServer: true, realtime: true, worker: true}
+	var calls []string
+	started, err := roles.start(recordingStarters(&calls, "httpServer"))
+	if err == nil || err.Error() != "httpServer failed" {
+		t.Fatalf("start() = %v, want the HTTP server failure", err)
+	}
+	if want := (sidecarRoles{worker: true}); started != want {
+		t.Errorf("started = %+v, want %+v", started, want)
+	}
+	if want := []string{"worker", "httpServer"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("starters called = %v, want %v", calls, want)
+	}
+}
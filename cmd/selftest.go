@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/env"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// selfTestKey is the harmless env key written and reverted by the env check
+const selfTestKey = "SIDECAR_SELFTEST"
+
+// selfTestCheck is a single named diagnostic run by the selftest command
+type selfTestCheck struct {
+	name string
+	run  func() error
+}
+
+// SelfTestCmd creates and returns the selftest command.
+// It exercises the env, docker and database paths the sidecar depends on.
+func SelfTestCmd() *cobra.Command {
+	selfTest := &cobra.Command{
+		Use:   "selftest",
+		Short: "Run deployment diagnostics",
+		Long:  `Checks that the env file is writable, docker is reachable, the compose file defines the configured services and both databases respond`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			configPath, err := cmd.Flags().GetString("config")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			config.InitConfig(configPath)
+
+			overrides, err := cmd.Flags().GetStringArray("set")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err := config.ApplyOverrides(overrides); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := []selfTestCheck{
+				{name: "Env file is writable", run: checkEnvWritable},
+				{name: "Docker is reachable", run: checkDocker},
+				{name: "Compose file defines configured services", run: checkComposeServices},
+				{name: "Sidecar database responds", run: func() error { return pingDatabase("sidecarDatabaseUrl") }},
+				{name: "Blockscout database responds", run: func() error { return pingDatabase("blockscoutDatabaseUrl") }},
+			}
+
+			failed := 0
+			for _, check := range checks {
+				if err := check.run(); err != nil {
+					failed++
+					fmt.Printf("[FAIL] %s: %v\n", check.name, err)
+					continue
+				}
+				fmt.Printf("[PASS] %s\n", check.name)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d self-test checks failed", failed, len(checks))
+			}
+			fmt.Println("All self-test checks passed.")
+			return nil
+		},
+	}
+	selfTest.PersistentFlags().StringP("config", "c", "", "Path of the configuration file")
+	selfTest.PersistentFlags().StringArray("set", nil, "Override a config key, e.g. --set chainId=1313161556 (repeatable)")
+	return selfTest
+}
+
+// checkEnvWritable writes a harmless key to the env file and restores the original content
+func checkEnvWritable() error {
+	e := env.NewEnv()
+	if e.PathToEnvFile == "" {
+		return fmt.Errorf("pathToEnvFile not configured")
+	}
+
+	original, err := os.ReadFile(e.PathToEnvFile)
+	if err != nil {
+		return fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	_, updateErr := e.UpdateEnvVars(map[string]string{
+		selfTestKey: time.Now().UTC().Format(time.RFC3339),
+	})
+
+	// Always revert, even if the update failed part way through
+	if err := os.WriteFile(e.PathToEnvFile, original, 0644); err != nil {
+		return fmt.Errorf("failed to revert env file: %w", err)
+	}
+	if updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
+
+// checkDocker verifies the docker binary exists and the daemon answers
+func checkDocker() error {
+	dockerPath, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker executable not found: %w", err)
+	}
+
+	output, err := exec.Command(dockerPath, "version", "--format", "{{.Server.Version}}").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker daemon not reachable: %w: %s", err, output)
+	}
+	return nil
+}
+
+// checkComposeServices parses the compose file and verifies the configured services exist
+func checkComposeServices() error {
+	if err := config.CheckComposeFile(); err != nil {
+		return err
+	}
+	d := docker.NewDocker()
+	if err := d.LoadComposeFile(); err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, key := range []string{"frontendServiceName", "backendServiceName", "statsServiceName", "proxyServiceName"} {
+		serviceName := viper.GetString(key)
+		if serviceName == "" {
+			continue
+		}
+		if !d.HasService(serviceName) {
+			missing = append(missing, serviceName)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("services not found in compose file: %v", missing)
+	}
+	return nil
+}
+
+// pingDatabase opens a connection using the given config key and pings it
+func pingDatabase(urlKey string) error {
+	databaseURL := viper.GetString(urlKey)
+	if databaseURL == "" {
+		return fmt.Errorf("%s not configured", urlKey)
+	}
+
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close database connection: %v\n", closeErr)
+		}
+	}()
+
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// writeTempFile writes content to name in a temporary directory and returns its path
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckEnvWritableRevertsFile(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	original := "# managed by the sidecar\nNEXT_PUBLIC_NETWORK_NAME=Aurora\n"
+	envFile := writeTempFile(t, "sidecar-injected.env", original)
+	viper.Set("pathToEnvFile", envFile)
+
+	if err := checkEnvWritable(); err != nil {
+		t.Fatalf("checkEnvWritable: %v", err)
+	}
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Errorf("env file = %q, want it reverted to %q", content, original)
+	}
+}
+
+func TestCheckEnvWritableMissingFile(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("pathToEnvFile", filepath.Join(t.TempDir(), "missing.env"))
+
+	if err := checkEnvWritable(); err == nil {
+		t.Fatal("checkEnvWritable succeeded for a missing env file")
+	}
+}
+
+func TestCheckComposeServices(t *testing.T) {
+	compose := "services:\n  frontend:\n    image: blockscout/frontend\n  backend:\n    image: blockscout/backend\n"
+	tests := []struct {
+		name        string
+		compose     string
+		services    map[string]string
+		wantErrText string
+	}{
+		{"all services present", compose, map[string]string{"frontendServiceName": "frontend", "backendServiceName": "backend"}, ""},
+		{"missing service", compose, map[string]string{"frontendServiceName": "frontend", "statsServiceName": "stats"}, "[stats]"},
+		{"invalid yaml", "services: [", map[string]string{"frontendServiceName": "frontend"}, "failed to parse compose file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("pathToDockerCompose", writeTempFile(t, "docker-compose.yaml", tt.compose))
+			for key, value := range tt.services {
+				viper.Set(key, value)
+			}
+
+			err := checkComposeServices()
+			if tt.wantErrText == "" {
+				if err != nil {
+					t.Fatalf("checkComposeServices: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+				t.Fatalf("checkComposeServices() = %v, want an error containing %q", err, tt.wantErrText)
+			}
+		})
+	}
+}
@@ -3,12 +3,15 @@ package cmd
 import (
 	"blockscout-vc/internal/client"
 	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/env"
+	"blockscout-vc/internal/events"
 	"blockscout-vc/internal/heartbeat"
 	"blockscout-vc/internal/server"
 	"blockscout-vc/internal/subscription"
 	"blockscout-vc/internal/worker"
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"os/signal"
 	"syscall"
@@ -32,83 +35,114 @@ func StartSidecarCmd() *cobra.Command {
 				os.Exit(1)
 			}
 			config.InitConfig(configPath)
+
+			overrides, err := cmd.Flags().GetStringArray("set")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err := config.ApplyOverrides(overrides); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create a cancellable context
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
-			// Create the sidecar-injected.env file if it doesn't exist
-			sidecarInjectedEnv := viper.GetString("pathToEnvFile")
-			if sidecarInjectedEnv != "" {
-				if _, err := os.Stat(sidecarInjectedEnv); os.IsNotExist(err) {
-					file, err := os.Create(sidecarInjectedEnv)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "Error creating env file: %v\n", err)
-					} else {
-						if closeErr := file.Close(); closeErr != nil {
-							fmt.Fprintf(os.Stderr, "Error closing env file: %v\n", closeErr)
-						}
-					}
+			// The enable.* keys let one binary run only some roles, e.g. the API on one host
+			// and the reconciler on another
+			roles := configuredRoles()
+			if err := roles.validate(); err != nil {
+				return err
+			}
+
+			// tls.minVersion does not reach the pq database connections
+			for _, warning := range config.DatabaseTLSWarnings() {
+				log.Printf("Warning: %s", warning)
+			}
+
+			// The compose file is only needed when this process recreates containers
+			if roles.needsComposeFile() {
+				if err := config.CheckComposeFile(); err != nil {
+					return err
 				}
 			}
 
-			// Initialize and start HTTP server
-			httpServer, err := server.NewServer()
+			// Create the configured chain's env file if it doesn't exist
+			sidecarInjectedEnv := env.NewEnv()
+			if roles.needsEnvFile() && sidecarInjectedEnv.PathToEnvFile != "" {
+				if err := sidecarInjectedEnv.EnsureEnvFile(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error creating env file: %v\n", err)
+				}
+			}
+
+			// Publish handled records to the configured message queue, if any
+			eventSink, err := events.NewSink()
 			if err != nil {
-				return fmt.Errorf("failed to initialize HTTP server: %w", err)
+				return fmt.Errorf("failed to initialize event sink: %w", err)
 			}
+			events.SetSink(eventSink)
+			defer func() {
+				if closeErr := eventSink.Close(); closeErr != nil {
+					fmt.Fprintf(os.Stderr, "Error closing event sink: %v\n", closeErr)
+				}
+			}()
+
+			// The worker is shared by realtime handlers and maintenance endpoints. When disabled,
+			// jobs are refused and containers are never recreated by this process
+			containerWorker := worker.New()
 
 			// Create error channel for HTTP server
 			serverErrChan := make(chan error, 1)
 
-			go func() {
-				port := viper.GetString("httpPort")
-				fmt.Printf("Starting HTTP server on port %s\n", port)
-				fmt.Printf("Token management web interface available at: http://localhost:%s/\n", port)
-				fmt.Printf("API endpoints available at: http://localhost:%s/api/v1/\n", port)
-				if err := httpServer.Start(port); err != nil {
-					fmt.Fprintf(os.Stderr, "HTTP server error: %v\n", err)
-					serverErrChan <- err
+			// Realtime resources are stopped in reverse order once the sidecar shuts down
+			var stops []func()
+			defer func() {
+				for i := len(stops) - 1; i >= 0; i-- {
+					stops[i]()
 				}
 			}()
 
-			// Initialize WebSocket client
-			supabaseUrl := viper.GetString("supabaseUrl")
-			supabaseRealtimeUrl := viper.GetString("supabaseRealtimeUrl")
-			supabaseAnonKey := viper.GetString("supabaseAnonKey")
-			if supabaseUrl != "" && supabaseRealtimeUrl != "" && supabaseAnonKey != "" {
-				realtimeClient := client.New(supabaseRealtimeUrl, supabaseAnonKey)
-				if err := realtimeClient.Connect(); err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to connect to Supabase realtime: %v\n", err)
-					// Continue without realtime functionality rather than exiting
-					fmt.Println("Continuing without realtime database monitoring...")
-				} else {
-					// Only defer Close if client was successfully created and connected
-					defer func() {
-						if closeErr := realtimeClient.Close(); closeErr != nil {
-							fmt.Fprintf(os.Stderr, "Error closing realtime client: %v\n", closeErr)
+			var httpServer *server.Server
+			_, err = roles.start(roleStarters{
+				worker: func() {
+					containerWorker.Start(ctx)
+				},
+				httpServer: func() error {
+					httpServer, err = server.NewServer(ctx, containerWorker)
+					if err != nil {
+						return fmt.Errorf("failed to initialize HTTP server: %w", err)
+					}
+					httpServer.SetBuildInfo(server.BuildInfo{Version: Version, Commit: Commit, BuildTime: BuildTime})
+
+					go func() {
+						port := viper.GetString("httpPort")
+						fmt.Printf("Starting HTTP server on port %s\n", port)
+						fmt.Printf("Token management web interface available at: http://localhost:%s/\n", port)
+						fmt.Printf("API endpoints available at: http://localhost:%s/api/v1/\n", port)
+						if err := httpServer.Start(port); err != nil {
+							fmt.Fprintf(os.Stderr, "HTTP server error: %v\n", err)
+							serverErrChan <- err
 						}
 					}()
-
-					// Initialize and start the worker
-					worker := worker.New()
-					worker.Start(ctx)
-
-					// Initialize and start heartbeat service
-					hb := heartbeat.New(realtimeClient, 30*time.Second)
-					hb.Start()
-					defer hb.Stop()
-
-					// Initialize and start subscription service
-					sub := subscription.New(realtimeClient)
-					if err := sub.Subscribe(worker); err != nil {
-						fmt.Fprintf(os.Stderr, "Failed to subscribe to database changes: %v\n", err)
-						fmt.Println("Continuing without database change monitoring...")
-					} else {
-						defer sub.Stop()
+					return nil
+				},
+				realtime: func() error {
+					return monitoringFailure(startMonitoring(ctx, containerWorker, httpServer, &stops))
+				},
+			})
+			if err != nil {
+				// Exit non-zero so the orchestrator restarts the sidecar instead of it running unmonitored
+				if httpServer != nil {
+					shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+					defer shutdownCancel()
+					if err := httpServer.Shutdown(shutdownCtx); err != nil {
+						fmt.Fprintf(os.Stderr, "Error shutting down HTTP server: %v\n", err)
 					}
 				}
+				return err
 			}
 
 			// Wait for interrupt signal or server error
@@ -126,14 +160,19 @@ func StartSidecarCmd() *cobra.Command {
 				fmt.Println("Shutting down due to server error...")
 			}
 
-			// Create shutdown context with timeout
-			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer shutdownCancel()
+			// Stop in-flight handlers and worker jobs before tearing down the server
+			cancel()
+
+			if httpServer != nil {
+				// Create shutdown context with timeout
+				shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer shutdownCancel()
 
-			// Shutdown HTTP server
-			fmt.Println("Shutting down HTTP server...")
-			if err := httpServer.Shutdown(shutdownCtx); err != nil {
-				fmt.Fprintf(os.Stderr, "Error shutting down HTTP server: %v\n", err)
+				// Shutdown HTTP server
+				fmt.Println("Shutting down HTTP server...")
+				if err := httpServer.Shutdown(shutdownCtx); err != nil {
+					fmt.Fprintf(os.Stderr, "Error shutting down HTTP server: %v\n", err)
+				}
 			}
 
 			fmt.Println("Shutdown complete.")
@@ -141,5 +180,79 @@ func StartSidecarCmd() *cobra.Command {
 		},
 	}
 	startServer.PersistentFlags().StringP("config", "c", "", "Path of the configuration file")
+	startServer.PersistentFlags().StringArray("set", nil, "Override a config key, e.g. --set chainId=1313161556 (repeatable)")
 	return startServer
 }
+
+// monitoringFailure decides what a failure to start database change monitoring means:
+// with realtime.required it is returned so the sidecar exits non-zero, otherwise the
+// sidecar continues without monitoring
+func monitoringFailure(err error) error {
+	if err == nil {
+		return nil
+	}
+	if config.GetRealtimeRequired() {
+		return fmt.Errorf("realtime.required is set: %w", err)
+	}
+	fmt.Println("Continuing without database change monitoring...")
+	return nil
+}
+
+// startMonitoring starts database change monitoring with the configured change source and
+// hands the subscription to httpServer, when it runs. Resources to release at shutdown are
+// appended to stops; the returned error is the failure to start monitoring, if any
+func startMonitoring(ctx context.Context, containerWorker *worker.Worker, httpServer *server.Server, stops *[]func()) error {
+	// setSubscription hands the subscription to the HTTP server for reconcile and status endpoints
+	setSubscription := func(sub *subscription.Subscription) {
+		if httpServer != nil {
+			httpServer.SetSubscription(sub)
+		}
+	}
+
+	supabaseUrl := viper.GetString("supabaseUrl")
+	supabaseRealtimeUrl := viper.GetString("supabaseRealtimeUrl")
+	supabaseAnonKey := viper.GetString("supabaseAnonKey")
+	if config.GetChangeSource() == config.ChangeSourcePostgres {
+		// Plain Postgres: receive changes through LISTEN/NOTIFY on the supabaseUrl database
+		sub := subscription.New(nil)
+		setSubscription(sub)
+		if err := sub.Listen(ctx, containerWorker); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to listen for database changes: %v\n", err)
+			return fmt.Errorf("failed to listen for database changes: %w", err)
+		}
+		*stops = append(*stops, sub.Stop)
+		return nil
+	}
+	if supabaseUrl == "" || supabaseRealtimeUrl == "" || supabaseAnonKey == "" {
+		return fmt.Errorf("supabaseUrl, supabaseRealtimeUrl and supabaseAnonKey are not all configured")
+	}
+
+	realtimeClient := client.New(supabaseRealtimeUrl, supabaseAnonKey)
+	if err := realtimeClient.Connect(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to Supabase realtime: %v\n", err)
+		return fmt.Errorf("failed to connect to Supabase realtime: %w", err)
+	}
+	// Only close the client once it was successfully connected
+	*stops = append(*stops, func() {
+		if closeErr := realtimeClient.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Error closing realtime client: %v\n", closeErr)
+		}
+	})
+
+	sub := subscription.New(realtimeClient)
+	setSubscription(sub)
+
+	// Initialize and start heartbeat service; repeated failures make the subscription reconnect
+	hb := heartbeat.New(realtimeClient, 30*time.Second)
+	hb.OnDeadConnection(config.GetHeartbeatMaxFailures(), sub.RequestReconnect)
+	hb.Start()
+	*stops = append(*stops, hb.Stop)
+
+	// Start subscription service
+	if err := sub.Subscribe(ctx, containerWorker); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to subscribe to database changes: %v\n", err)
+		return fmt.Errorf("failed to subscribe to database changes: %w", err)
+	}
+	*stops = append(*stops, sub.Stop)
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/client"
+
+	"github.com/spf13/viper"
+)
+
+// closedRealtimeURL returns a websocket URL nothing listens on, so connecting fails
+func closedRealtimeURL(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return "ws://" + address + "/realtime/v1/websocket"
+}
+
+func TestMonitoringFailure(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	connectErr := client.New(closedRealtimeURL(t), "key").Connect()
+	if connectErr == nil {
+		t.Fatal("Connect succeeded without a server")
+	}
+
+	for _, required := range []bool{false, true} {
+		viper.Set("realtime.required", required)
+
+		err := monitoringFailure(connectErr)
+		if required && !errors.Is(err, connectErr) {
+			t.Errorf("realtime.required: monitoringFailure() = %v, want the connect failure", err)
+		}
+		if !required && err != nil {
+			t.Errorf("realtime not required: monitoringFailure() = %v, want the sidecar to continue", err)
+		}
+	}
+	if err := monitoringFailure(nil); err != nil {
+		t.Errorf("monitoringFailure(nil) = %v", err)
+	}
+}
+
+func TestSidecarChecksComposeFileAtStartup(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(dir string) string
+		wantError string
+	}{
+		{"missing path", func(dir string) string { return filepath.Join(dir, "docker-compose.yaml") }, "compose file not found at %s"},
+		{"directory", func(dir string) string { return dir }, "compose file at %s is a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			path := tt.setup(t.TempDir())
+			viper.Set("pathToDockerCompose", path)
+			viper.Set("enable.realtime", false)
+
+			cmd := StartSidecarCmd()
+			err := cmd.RunE(cmd, nil)
+			if want := strings.ReplaceAll(tt.wantError, "%s", path); err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("RunE() = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestSidecarExitsWhenRealtimeRequired(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("realtime.required", true)
+	viper.Set("enable.httpServer", false)
+	viper.Set("enable.worker", false)
+	viper.Set("pathToEnvFile", filepath.Join(t.TempDir(), "sidecar-injected.env"))
+	viper.Set("supabaseUrl", "postgres://localhost/unused")
+	viper.Set("supabaseRealtimeUrl", closedRealtimeURL(t))
+	viper.Set("supabaseAnonKey", "key")
+
+	cmd := StartSidecarCmd()
+	err := cmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to connect to Supabase realtime") {
+		t.Errorf("RunE() = %v, want the realtime connect failure", err)
+	}
+}
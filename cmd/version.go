@@ -0,0 +1,9 @@
+package cmd
+
+// Build information, injected at build time with
+// -ldflags "-X blockscout-vc/cmd.Version=... -X blockscout-vc/cmd.Commit=... -X blockscout-vc/cmd.BuildTime=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
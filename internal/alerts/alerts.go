@@ -0,0 +1,112 @@
+// Package alerts posts handler errors to a Slack or Discord webhook so operators
+// learn about persistent failures without watching the logs
+package alerts
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/netguard"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported values of alerts.format
+const (
+	FormatSlack   = "slack"
+	FormatDiscord = "discord"
+)
+
+var (
+	// clients are shared by every alert so connections to the webhook host are reused,
+	// one per alerts.allowPrivate value
+	clients    = map[bool]*http.Client{}
+	clientsMux sync.Mutex
+
+	// lastSent holds when each handler last alerted, for the cooldown
+	lastSent    = map[string]time.Time{}
+	lastSentMux sync.Mutex
+)
+
+// httpClient returns the shared outbound client, created on first use so the configured
+// TLS minimum version is honoured. Like image fetches, it refuses to connect to private
+// addresses unless alerts.allowPrivate is set
+func httpClient() *http.Client {
+	allowPrivate := config.GetAlertsAllowPrivate()
+	clientsMux.Lock()
+	defer clientsMux.Unlock()
+	if client, ok := clients[allowPrivate]; ok {
+		return client
+	}
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: netguard.NewTransport(allowPrivate, &tls.Config{MinVersion: config.GetTLSMinVersion()}),
+	}
+	clients[allowPrivate] = client
+	return client
+}
+
+// HandlerError sends an alert for a failed handler in the background. At most one alert
+// per handler is sent per alerts.cooldown; nothing is sent when alerts.webhookUrl is unset
+func HandlerError(handler, table string, recordID, chainID int, handlerErr error) {
+	webhookURL := config.GetAlertsWebhookURL()
+	if webhookURL == "" || !allow(handler, time.Now()) {
+		return
+	}
+
+	text := fmt.Sprintf("blockscout-vc: handler %s failed for record %d (chain %d, table %s): %v",
+		handler, recordID, chainID, table, handlerErr)
+	go func() {
+		if err := post(context.Background(), webhookURL, config.GetAlertsFormat(), text); err != nil {
+			log.Printf("Warning: failed to send alert for handler %s: %v", handler, err)
+		}
+	}()
+}
+
+// allow reports whether handler may alert at now and, if so, starts its cooldown
+func allow(handler string, now time.Time) bool {
+	lastSentMux.Lock()
+	defer lastSentMux.Unlock()
+	if last, ok := lastSent[handler]; ok && now.Sub(last) < config.GetAlertsCooldown() {
+		return false
+	}
+	lastSent[handler] = now
+	return true
+}
+
+// post delivers text to the webhook in the payload shape of format
+func post(ctx context.Context, webhookURL, format, text string) error {
+	payload := map[string]string{"text": text}
+	if format == FormatDiscord {
+		payload = map[string]string{"content": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/netguard"
+
+	"github.com/spf13/viper"
+)
+
+// resetCooldowns forgets every handler's last alert, so earlier tests do not throttle this one
+func resetCooldowns(t *testing.T) {
+	t.Helper()
+	lastSentMux.Lock()
+	defer lastSentMux.Unlock()
+	lastSent = map[string]time.Time{}
+}
+
+// webhookServer records the JSON payloads posted to it. It listens on loopback, so
+// alerts.allowPrivate is set for the test
+func webhookServer(t *testing.T, status int) (string, <-chan map[string]string) {
+	t.Helper()
+	t.Cleanup(viper.Reset)
+	viper.Set("alerts.allowPrivate", true)
+	payloads := make(chan map[string]string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("alert is not JSON: %v", err)
+		}
+		payloads <- payload
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, payloads
+}
+
+// receive returns the next posted payload
+func receive(t *testing.T, payloads <-chan map[string]string) map[string]string {
+	t.Helper()
+	select {
+	case payload := <-payloads:
+		return payload
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert was posted")
+		return nil
+	}
+}
+
+func TestHandlerErrorPostsThrottledAlerts(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	resetCooldowns(t)
+	url, payloads := webhookServer(t, http.StatusOK)
+	viper.Set("alerts.webhookUrl", url)
+
+	HandlerError("image", "silos", 7, 1, errors.New("image not found"))
+	payload := receive(t, payloads)
+	if text := payload["text"]; !strings.Contains(text, "handler image failed for record 7") || !strings.Contains(text, "image not found") {
+		t.Errorf("alert text = %q, want the handler error", text)
+	}
+
+	// A repeat within the cooldown is dropped, while another handler still alerts
+	HandlerError("image", "silos", 8, 1, errors.New("image not found"))
+	HandlerError("explorer", "silos", 8, 1, errors.New("invalid URL"))
+	if text := receive(t, payloads)["text"]; !strings.Contains(text, "handler explorer failed") {
+		t.Errorf("alert text = %q, want the explorer handler error", text)
+	}
+	select {
+	case payload := <-payloads:
+		t.Errorf("a throttled alert was posted: %v", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAllowStartsCooldown(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("alerts.cooldown", time.Minute)
+	resetCooldowns(t)
+	now := time.Now()
+
+	if !allow("cooldown-test", now) {
+		t.Fatal("the first alert was throttled")
+	}
+	if allow("cooldown-test", now.Add(30*time.Second)) {
+		t.Error("an alert within the cooldown was allowed")
+	}
+	if !allow("cooldown-test", now.Add(time.Minute)) {
+		t.Error("an alert after the cooldown was throttled")
+	}
+}
+
+func TestPostUsesFormatPayload(t *testing.T) {
+	tests := []struct {
+		format string
+		field  string
+	}{
+		{FormatSlack, "text"},
+		{FormatDiscord, "content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			url, payloads := webhookServer(t, http.StatusNoContent)
+			if err := post(context.Background(), url, tt.format, "handler failed"); err != nil {
+				t.Fatalf("post: %v", err)
+			}
+			if payload := receive(t, payloads); payload[tt.field] != "handler failed" || len(payload) != 1 {
+				t.Errorf("payload = %v, want only %s", payload, tt.field)
+			}
+		})
+	}
+}
+
+func TestPostReportsWebhookError(t *testing.T) {
+	url, _ := webhookServer(t, http.StatusForbidden)
+	if err := post(context.Background(), url, FormatSlack, "handler failed"); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("post() = %v, want the webhook status", err)
+	}
+}
+
+func TestPostRefusesPrivateWebhookByDefault(t *testing.T) {
+	url, payloads := webhookServer(t, http.StatusOK)
+	viper.Set("alerts.allowPrivate", false)
+
+	if err := post(context.Background(), url, FormatSlack, "handler failed"); !errors.Is(err, netguard.ErrPrivateAddress) {
+		t.Errorf("post() = %v, want ErrPrivateAddress", err)
+	}
+	select {
+	case payload := <-payloads:
+		t.Errorf("the private webhook received %v", payload)
+	default:
+	}
+}
@@ -1,10 +1,21 @@
 package client
 
 import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/models"
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"syscall"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/spf13/viper"
 )
 
@@ -12,7 +23,51 @@ import (
 // Note: COALESCE is used for symbol and name fields as they can be NULL in the Blockscout database schema.
 // Contract address matching uses case-insensitive comparison for better user experience.
 type BlockscoutClient struct {
-	db *sql.DB
+	db      *sql.DB
+	queries tokenQueries
+}
+
+// tokenQueries holds the token statements built for the configured Blockscout schema
+type tokenQueries struct {
+	selectAll         string
+	selectByAddress   string
+	selectByAddresses string
+	updateIconURL     string
+}
+
+// buildTokenQueries renders the token statements for schema, whose identifiers
+// must already be validated (see config.GetBlockscoutTokensSchema)
+// The bytea address is converted from \x to 0x hex in every statement
+func buildTokenQueries(schema config.BlockscoutTokensSchema) tokenQueries {
+	address := fmt.Sprintf(`regexp_replace(%s::varchar, '^\\x', '0x')`, schema.AddressColumn)
+	// Use COALESCE to handle NULL values for symbol, name, and icon_url
+	selectColumns := fmt.Sprintf(`
+		SELECT %s, 
+		       COALESCE(%s, '') as symbol, 
+		       COALESCE(%s, '') as name,
+		       COALESCE(%s, '') as icon_url
+		FROM %s`, address, schema.SymbolColumn, schema.NameColumn, schema.IconURLColumn, schema.Table)
+
+	return tokenQueries{
+		selectAll: selectColumns + fmt.Sprintf(`
+		ORDER BY COALESCE(%s, '') ASC
+	`, schema.NameColumn),
+		// Use case-insensitive comparison for contract address matching
+		selectByAddress: selectColumns + fmt.Sprintf(`
+		WHERE lower(%s) = lower($1)
+	`, address),
+		// $1 holds lowercase addresses
+		selectByAddresses: selectColumns + fmt.Sprintf(`
+		WHERE lower(%s) = ANY($1)
+		ORDER BY COALESCE(%s, '') ASC
+	`, address, schema.NameColumn),
+		updateIconURL: fmt.Sprintf(`
+		UPDATE %s 
+		SET %s = $2, %s = CURRENT_TIMESTAMP
+		WHERE lower(%s) = lower($1)
+		  AND %s IS DISTINCT FROM $2
+	`, schema.Table, schema.IconURLColumn, schema.UpdatedAtColumn, address, schema.IconURLColumn),
+	}
 }
 
 // BlockscoutToken represents a token from Blockscout database
@@ -31,6 +86,11 @@ func NewBlockscoutClient() (*BlockscoutClient, error) {
 		return nil, fmt.Errorf("blockscoutDatabaseUrl not configured")
 	}
 
+	schema, err := config.GetBlockscoutTokensSchema()
+	if err != nil {
+		return nil, err
+	}
+
 	// Open database connection
 	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
@@ -42,7 +102,7 @@ func NewBlockscoutClient() (*BlockscoutClient, error) {
 		return nil, fmt.Errorf("failed to ping blockscout database: %w", err)
 	}
 
-	return &BlockscoutClient{db: db}, nil
+	return &BlockscoutClient{db: db, queries: buildTokenQueries(schema)}, nil
 }
 
 // Close closes the database connection
@@ -54,18 +114,20 @@ func (c *BlockscoutClient) Close() error {
 }
 
 // GetTokens fetches all tokens from Blockscout database
+// Returns an error wrapping models.ErrTooManyTokens when there are more than maxTokensInMemory
 func (c *BlockscoutClient) GetTokens() ([]BlockscoutToken, error) {
-	// Get all tokens - use COALESCE to handle NULL values for symbol, name, and icon_url
-	query := `
-		SELECT regexp_replace(contract_address_hash::varchar, '^\\x', '0x'), 
-		       COALESCE(symbol, '') as symbol, 
-		       COALESCE(name, '') as name,
-		       COALESCE(icon_url, '') as icon_url
-		FROM tokens
-		ORDER BY COALESCE(name, '') ASC
-	`
-
-	rows, err := c.db.Query(query)
+	var tokens []BlockscoutToken
+	err := c.retryStaleConn(func() error {
+		var err error
+		tokens, err = c.getTokens()
+		return err
+	})
+	return tokens, err
+}
+
+// getTokens runs a single token listing query
+func (c *BlockscoutClient) getTokens() ([]BlockscoutToken, error) {
+	rows, err := c.db.Query(c.queries.selectAll)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tokens: %w", err)
 	}
@@ -75,8 +137,12 @@ func (c *BlockscoutClient) GetTokens() ([]BlockscoutToken, error) {
 		}
 	}()
 
+	maxTokens := config.GetMaxTokensInMemory()
 	var tokens []BlockscoutToken
 	for rows.Next() {
+		if maxTokens > 0 && len(tokens) >= maxTokens {
+			return nil, fmt.Errorf("%w: more than %d Blockscout tokens", models.ErrTooManyTokens, maxTokens)
+		}
 		var token BlockscoutToken
 		err := rows.Scan(
 			&token.Address,
@@ -100,21 +166,67 @@ func (c *BlockscoutClient) GetTokens() ([]BlockscoutToken, error) {
 	return tokens, nil
 }
 
+// GetTokensByAddresses fetches the tokens with the given addresses in a single query,
+// matching case-insensitively. Addresses without a Blockscout token are left out
+func (c *BlockscoutClient) GetTokensByAddresses(addresses []string) ([]BlockscoutToken, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	lowered := make([]string, len(addresses))
+	for i, address := range addresses {
+		lowered[i] = strings.ToLower(address)
+	}
+
+	var tokens []BlockscoutToken
+	err := c.retryStaleConn(func() error {
+		var err error
+		tokens, err = c.getTokensByAddresses(lowered)
+		return err
+	})
+	return tokens, err
+}
+
+// getTokensByAddresses runs a single batched token lookup query
+func (c *BlockscoutClient) getTokensByAddresses(addresses []string) ([]BlockscoutToken, error) {
+	rows, err := c.db.Query(c.queries.selectByAddresses, pq.Array(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tokens by address: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", closeErr)
+		}
+	}()
+
+	var tokens []BlockscoutToken
+	for rows.Next() {
+		var token BlockscoutToken
+		if err := rows.Scan(&token.Address, &token.Symbol, &token.Name, &token.IconURL); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return tokens, nil
+}
+
 // GetTokenByAddress fetches a specific token from Blockscout database by address
 func (c *BlockscoutClient) GetTokenByAddress(address string) (*BlockscoutToken, error) {
-	// Use COALESCE to handle NULL values for symbol, name, and icon_url
-	// Use case-insensitive comparison for contract address matching
-	query := `
-		SELECT regexp_replace(contract_address_hash::varchar, '^\\x', '0x'), 
-		       COALESCE(symbol, '') as symbol, 
-		       COALESCE(name, '') as name,
-		       COALESCE(icon_url, '') as icon_url
-		FROM tokens
-		WHERE lower(regexp_replace(contract_address_hash::varchar, '^\\x', '0x')) = lower($1)
-	`
+	var token *BlockscoutToken
+	err := c.retryStaleConn(func() error {
+		var err error
+		token, err = c.getTokenByAddress(address)
+		return err
+	})
+	return token, err
+}
 
+// getTokenByAddress runs a single token lookup query
+func (c *BlockscoutClient) getTokenByAddress(address string) (*BlockscoutToken, error) {
 	var token BlockscoutToken
-	err := c.db.QueryRow(query, address).Scan(
+	err := c.db.QueryRow(c.queries.selectByAddress, address).Scan(
 		&token.Address,
 		&token.Symbol,
 		&token.Name,
@@ -131,16 +243,86 @@ func (c *BlockscoutClient) GetTokenByAddress(address string) (*BlockscoutToken,
 	return &token, nil
 }
 
+// retryStaleConn runs query and, if it failed because a pooled connection went stale
+// (e.g. the Blockscout database restarted), pings the database and runs it once more
+func (c *BlockscoutClient) retryStaleConn(query func() error) error {
+	err := query()
+	if err == nil || !isConnectionError(err) {
+		return err
+	}
+
+	log.Printf("Blockscout query failed on a stale connection, retrying: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if pingErr := c.db.PingContext(ctx); pingErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to ping blockscout database: %w", pingErr))
+	}
+	return query()
+}
+
+// isConnectionError reports whether err means the connection was lost rather than
+// the query being invalid: closed sockets, resets, and Postgres connection
+// exception (class 08) or shutdown (57P01-57P03) errors
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+	}
+	return false
+}
+
+// ErrTokenNotFound is returned when a token does not exist in the Blockscout database
+var ErrTokenNotFound = errors.New("token not found")
+
 // UpdateTokenIconURL updates the icon_url field for a specific token in Blockscout database
-func (c *BlockscoutClient) UpdateTokenIconURL(address, iconURL string) error {
-	// Use case-insensitive comparison for contract address matching
-	query := `
-		UPDATE tokens 
-		SET icon_url = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE lower(regexp_replace(contract_address_hash::varchar, '^\\x', '0x')) = lower($1)
-	`
-
-	result, err := c.db.Exec(query, address, iconURL)
+// Lost connections are retried with backoff (iconSync.retryAttempts / iconSync.retryBackoff);
+// any other error, or ctx ending, stops the retries.
+// Returns an error wrapping ErrTokenNotFound when the token does not exist
+func (c *BlockscoutClient) UpdateTokenIconURL(ctx context.Context, address, iconURL string) error {
+	return retryConnectionErrors(ctx, config.GetIconSyncRetryAttempts(), config.GetIconSyncRetryBackoff(), func() error {
+		return c.updateTokenIconURL(ctx, address, iconURL)
+	}, func(attempt, attempts int, backoff time.Duration, err error) {
+		log.Printf("Icon update for %s failed (attempt %d/%d), retrying in %s: %v", address, attempt, attempts, backoff, err)
+	})
+}
+
+// retryConnectionErrors runs update up to attempts times, doubling backoff between tries.
+// Only connection errors are retried, and never once ctx is done; onRetry is called
+// before each wait
+func retryConnectionErrors(ctx context.Context, attempts int, backoff time.Duration, update func() error,
+	onRetry func(attempt, attempts int, backoff time.Duration, err error)) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = update()
+		if err == nil || !isConnectionError(err) || ctx.Err() != nil || attempt == attempts {
+			return err
+		}
+
+		onRetry(attempt, attempts, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// updateTokenIconURL runs a single check-then-set update. A token whose icon already
+// equals iconURL is left untouched so updated_at does not change
+func (c *BlockscoutClient) updateTokenIconURL(ctx context.Context, address, iconURL string) error {
+	result, err := c.db.ExecContext(ctx, c.queries.updateIconURL, address, iconURL)
 	if err != nil {
 		return fmt.Errorf("failed to update token icon_url: %w", err)
 	}
@@ -151,7 +333,14 @@ func (c *BlockscoutClient) UpdateTokenIconURL(address, iconURL string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no token found with address: %s", address)
+		// Either the icon is already up to date or the token does not exist
+		token, err := c.GetTokenByAddress(address)
+		if err != nil {
+			return err
+		}
+		if token == nil {
+			return fmt.Errorf("%w: %s", ErrTokenNotFound, address)
+		}
 	}
 
 	return nil
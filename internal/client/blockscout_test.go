@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func noRetryLog(int, int, time.Duration, error) {}
+
+func TestRetryConnectionErrors(t *testing.T) {
+	errInvalid := errors.New("invalid input syntax")
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"connection error then success", []error{driver.ErrBadConn, nil}, 2, nil},
+		{"connection error every attempt", []error{driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn}, 3, driver.ErrBadConn},
+		{"other error is not retried", []error{errInvalid}, 1, errInvalid},
+		{"token not found is not retried", []error{fmt.Errorf("%w: 0xabc", ErrTokenNotFound)}, 1, ErrTokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryConnectionErrors(context.Background(), 3, time.Millisecond, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			}, noRetryLog)
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryConnectionErrorsStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := retryConnectionErrors(ctx, 5, time.Hour, func() error {
+		calls++
+		return driver.ErrBadConn
+	}, func(int, int, time.Duration, error) { cancel() })
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("err = %v, want the connection error joined with context.Canceled", err)
+	}
+
+	calls = 0
+	_ = retryConnectionErrors(ctx, 5, time.Millisecond, func() error {
+		calls++
+		return driver.ErrBadConn
+	}, noRetryLog)
+	if calls != 1 {
+		t.Errorf("calls with a done context = %d, want 1", calls)
+	}
+}
+
+func TestGetTokenByAddressRetriesStaleConnection(t *testing.T) {
+	const address = "0xabc"
+	tests := []struct {
+		name        string
+		failures    int
+		wantErr     bool
+		wantQueries int
+	}{
+		{"healthy connection", 0, false, 1},
+		{"stale connection is retried", 1, false, 2},
+		{"connection lost twice", 2, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &iconTable{icons: map[string]string{address: "https://icon.png"}, queryFailures: tt.failures}
+			c := newIconClient(t, table)
+
+			token, err := c.GetTokenByAddress(address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if table.queries != tt.wantQueries {
+				t.Errorf("queries = %d, want %d", table.queries, tt.wantQueries)
+			}
+			if !tt.wantErr && (token == nil || token.IconURL != "https://icon.png") {
+				t.Errorf("token = %+v, want the stored token", token)
+			}
+		})
+	}
+}
@@ -2,9 +2,13 @@
 package client
 
 import (
+	"blockscout-vc/internal/config"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -13,15 +17,22 @@ import (
 type Client struct {
 	apiKey   string
 	endpoint string
+	authMode string // Where the API key is sent, see config.GetRealtimeAuthMode
 	handlers map[string]func([]byte)
 	Conn     *websocket.Conn // Public connection instance for external use
+	connMux  sync.RWMutex    // Guards Conn across Reconnect
+	writeMux sync.Mutex      // Serializes WriteJSON; websocket connections allow one writer at a time
 }
 
+// writeTimeout bounds a single write, so a dead connection fails instead of blocking
+const writeTimeout = 10 * time.Second
+
 // New creates a new WebSocket client with the specified endpoint and API key
 func New(endpoint, apiKey string) *Client {
 	return &Client{
 		endpoint: endpoint,
 		apiKey:   apiKey,
+		authMode: config.GetRealtimeAuthMode(),
 		handlers: make(map[string]func([]byte)),
 		Conn:     nil,
 	}
@@ -29,29 +40,104 @@ func New(endpoint, apiKey string) *Client {
 
 // Connect establishes a WebSocket connection to the Supabase Realtime server
 // It configures the connection with the necessary headers and authentication
+// A failed dial is returned, so the caller decides whether to continue without realtime
 func (c *Client) Connect() error {
-	header := http.Header{}
-	header.Add("Authorization", "Bearer "+c.apiKey)
+	url, header := c.authRequest()
+	fmt.Printf("Connecting to Supabase Realtime (auth mode: %s)\n", c.authMode)
 
 	dialer := websocket.Dialer{
 		EnableCompression: true,
+		TLSClientConfig:   &tls.Config{MinVersion: config.GetTLSMinVersion()},
 	}
 
-	conn, resp, err := dialer.Dial(c.endpoint+"?apikey="+c.apiKey, header)
+	conn, resp, err := dialer.Dial(url, header)
 	if err != nil {
 		if resp != nil {
 			log.Printf("HTTP Response Status: %s", resp.Status)
 			log.Printf("HTTP Response Headers: %v", resp.Header)
 		}
-		log.Fatalf("Failed to connect to Realtime server: %v", err)
+		return fmt.Errorf("failed to connect to Realtime server: %w", err)
 	}
+	c.connMux.Lock()
 	c.Conn = conn
+	c.connMux.Unlock()
 
 	fmt.Println("Connected to Supabase Realtime!")
 	return nil
 }
 
+// Reconnect closes the current connection and dials a new one
+// Unlike Connect, a failed dial is returned so the caller can retry
+func (c *Client) Reconnect() error {
+	url, header := c.authRequest()
+	dialer := websocket.Dialer{
+		EnableCompression: true,
+		TLSClientConfig:   &tls.Config{MinVersion: config.GetTLSMinVersion()},
+	}
+
+	conn, resp, err := dialer.Dial(url, header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("failed to reconnect to Realtime server (HTTP %s): %w", resp.Status, err)
+		}
+		return fmt.Errorf("failed to reconnect to Realtime server: %w", err)
+	}
+
+	c.connMux.Lock()
+	old := c.Conn
+	c.Conn = conn
+	c.connMux.Unlock()
+	if old != nil {
+		old.Close()
+	}
+
+	fmt.Println("Reconnected to Supabase Realtime!")
+	return nil
+}
+
+// CurrentConn returns the connection in use, which changes on Reconnect
+func (c *Client) CurrentConn() *websocket.Conn {
+	c.connMux.RLock()
+	defer c.connMux.RUnlock()
+	return c.Conn
+}
+
+// WriteJSON sends v on the current connection, one writer at a time
+func (c *Client) WriteJSON(v interface{}) error {
+	conn := c.CurrentConn()
+	if conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+	if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(v)
+}
+
+// authRequest returns the URL and headers used to dial, placing the API key
+// according to the auth mode
+func (c *Client) authRequest() (string, http.Header) {
+	url := c.endpoint
+	header := http.Header{}
+	switch c.authMode {
+	case config.RealtimeAuthModeBearer:
+		header.Add("Authorization", "Bearer "+c.apiKey)
+	case config.RealtimeAuthModeAPIKeyQuery:
+		url += "?apikey=" + c.apiKey
+	case config.RealtimeAuthModeBoth:
+		header.Add("Authorization", "Bearer "+c.apiKey)
+		url += "?apikey=" + c.apiKey
+	default:
+		// Custom gateways expect the key in a header of their choosing
+		header.Add(c.authMode, c.apiKey)
+	}
+	return url, header
+}
+
 // Close terminates the WebSocket connection
 func (c *Client) Close() error {
-	return c.Conn.Close()
+	return c.CurrentConn().Close()
 }
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+)
+
+func TestConnectSendsAPIKeyForAuthMode(t *testing.T) {
+	tests := []struct {
+		mode       string
+		wantHeader string // header carrying the key, empty for none
+		wantValue  string
+		wantQuery  bool
+	}{
+		{"", "Authorization", "Bearer secret", true},
+		{"both", "Authorization", "Bearer secret", true},
+		{"bearer", "Authorization", "Bearer secret", false},
+		{"apikey-query", "", "", true},
+		{"X-Gateway-Key", "X-Gateway-Key", "secret", false},
+	}
+	for _, tt := range tests {
+		t.Run("mode "+tt.mode, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("realtimeAuthMode", tt.mode)
+
+			requests := make(chan *http.Request, 1)
+			upgrader := websocket.Upgrader{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests <- r
+				if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+					conn.Close()
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			c := New("ws"+strings.TrimPrefix(server.URL, "http"), "secret")
+			if err := c.Connect(); err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer c.Close()
+			r := <-requests
+
+			if got := r.URL.Query().Get("apikey"); (got == "secret") != tt.wantQuery {
+				t.Errorf("apikey query = %q, want present: %t", got, tt.wantQuery)
+			}
+			if tt.wantHeader != "" && r.Header.Get(tt.wantHeader) != tt.wantValue {
+				t.Errorf("%s header = %q, want %q", tt.wantHeader, r.Header.Get(tt.wantHeader), tt.wantValue)
+			}
+			if tt.wantHeader != "Authorization" && r.Header.Get("Authorization") != "" {
+				t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/config"
+
+	"github.com/spf13/viper"
+)
+
+// normalizeSQL collapses whitespace so statements compare independently of formatting
+func normalizeSQL(query string) string {
+	return strings.TrimSpace(regexp.MustCompile(`\s+`).ReplaceAllString(query, " "))
+}
+
+func TestBuildTokenQueriesCustomSchema(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("blockscout.tokensTable", "explorer.token_meta")
+	viper.Set("blockscout.columns.address", "address_hash")
+	viper.Set("blockscout.columns.symbol", "ticker")
+	viper.Set("blockscout.columns.name", "title")
+	viper.Set("blockscout.columns.iconUrl", "logo")
+	viper.Set("blockscout.columns.updatedAt", "modified_at")
+
+	schema, err := config.GetBlockscoutTokensSchema()
+	if err != nil {
+		t.Fatalf("GetBlockscoutTokensSchema: %v", err)
+	}
+	queries := buildTokenQueries(schema)
+
+	address := `regexp_replace(address_hash::varchar, '^\\x', '0x')`
+	selectColumns := `SELECT ` + address + `, COALESCE(ticker, '') as symbol, COALESCE(title, '') as name, COALESCE(logo, '') as icon_url FROM explorer.token_meta`
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"selectAll", queries.selectAll, selectColumns + ` ORDER BY COALESCE(title, '') ASC`},
+		{"selectByAddress", queries.selectByAddress, selectColumns + ` WHERE lower(` + address + `) = lower($1)`},
+		{"selectByAddresses", queries.selectByAddresses, selectColumns + ` WHERE lower(` + address + `) = ANY($1) ORDER BY COALESCE(title, '') ASC`},
+		{"updateIconURL", queries.updateIconURL, `UPDATE explorer.token_meta SET logo = $2, modified_at = CURRENT_TIMESTAMP WHERE lower(` + address + `) = lower($1) AND logo IS DISTINCT FROM $2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSQL(tt.query); got != tt.want {
+				t.Errorf("query =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockscoutTokensSchemaRejectsUnsafeIdentifiers(t *testing.T) {
+	for key, value := range map[string]string{
+		"blockscout.tokensTable":       "tokens; DROP TABLE tokens",
+		"blockscout.columns.address":   "contract_address_hash--",
+		"blockscout.columns.iconUrl":   "icon url",
+		"blockscout.columns.updatedAt": "a.b.c",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set(key, value)
+			if _, err := config.GetBlockscoutTokensSchema(); err == nil {
+				t.Errorf("%s = %q was accepted", key, value)
+			}
+		})
+	}
+}
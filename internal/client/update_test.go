@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/config"
+
+	"github.com/spf13/viper"
+)
+
+// iconTable is an in-memory Blockscout tokens table serving the client's icon statements
+type iconTable struct {
+	mu       sync.Mutex
+	icons    map[string]string // address -> icon_url
+	failures int               // updates failing with a lost connection before one succeeds
+	attempts int
+	writes   int
+
+	queryFailures int // lookups failing with a lost connection before one succeeds
+	queries       int
+}
+
+func (t *iconTable) Connect(context.Context) (driver.Conn, error) { return iconConn{t}, nil }
+func (t *iconTable) Driver() driver.Driver                        { return nil }
+
+type iconConn struct{ table *iconTable }
+
+func (c iconConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c iconConn) Close() error                        { return nil }
+func (c iconConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c iconConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	t := c.table
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attempts++
+	if t.failures > 0 {
+		t.failures--
+		return nil, io.ErrUnexpectedEOF
+	}
+	address, icon := args[0].Value.(string), args[1].Value.(string)
+	current, ok := t.icons[address]
+	if !ok || current == icon { // WHERE ... AND icon_url IS DISTINCT FROM $2
+		return driver.RowsAffected(0), nil
+	}
+	t.icons[address] = icon
+	t.writes++
+	return driver.RowsAffected(1), nil
+}
+
+func (c iconConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	t := c.table
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queries++
+	if t.queryFailures > 0 {
+		t.queryFailures--
+		return nil, io.ErrUnexpectedEOF
+	}
+	address := args[0].Value.(string)
+	rows := &iconRows{}
+	if icon, ok := t.icons[address]; ok {
+		rows.values = [][]driver.Value{{address, "TKN", "Token", icon}}
+	}
+	return rows, nil
+}
+
+type iconRows struct{ values [][]driver.Value }
+
+func (r *iconRows) Columns() []string { return []string{"address", "symbol", "name", "icon_url"} }
+func (r *iconRows) Close() error      { return nil }
+func (r *iconRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newIconClient returns a client backed by table
+func newIconClient(t *testing.T, table *iconTable) *BlockscoutClient {
+	t.Helper()
+	t.Cleanup(viper.Reset)
+	viper.Set("iconSync.retryBackoff", time.Millisecond)
+	schema, err := config.GetBlockscoutTokensSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(table)
+	t.Cleanup(func() { db.Close() })
+	return &BlockscoutClient{db: db, queries: buildTokenQueries(schema)}
+}
+
+func TestUpdateTokenIconURL(t *testing.T) {
+	const address = "0xabc"
+	tests := []struct {
+		name         string
+		icons        map[string]string
+		failures     int
+		wantErr      error
+		wantAttempts int
+		wantWrites   int
+	}{
+		{"changed icon is written", map[string]string{address: "https://old.png"}, 0, nil, 1, 1},
+		{"same icon is a no-op", map[string]string{address: "https://new.png"}, 0, nil, 1, 0},
+		{"lost connection is retried", map[string]string{address: "https://old.png"}, 2, nil, 3, 1},
+		{"missing token", map[string]string{}, 0, ErrTokenNotFound, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &iconTable{icons: tt.icons, failures: tt.failures}
+			c := newIconClient(t, table)
+
+			err := c.UpdateTokenIconURL(context.Background(), address, "https://new.png")
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if table.attempts != tt.wantAttempts || table.writes != tt.wantWrites {
+				t.Errorf("attempts = %d, writes = %d, want %d and %d", table.attempts, table.writes, tt.wantAttempts, tt.wantWrites)
+			}
+			if tt.wantErr == nil && table.icons[address] != "https://new.png" {
+				t.Errorf("icon = %q, want the new icon", table.icons[address])
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// composeFileCase is a pathToDockerCompose setup and the error CheckComposeFile reports for it
+type composeFileCase struct {
+	name      string
+	setup     func(t *testing.T, dir string) string
+	wantError string
+}
+
+// composeFileCases covers the compose paths CheckComposeFile rejects, plus a valid file.
+// The wantError "%s" is replaced with the configured path
+var composeFileCases = []composeFileCase{
+	{
+		name:  "valid file",
+		setup: func(t *testing.T, dir string) string { return writeComposeFile(t, dir, 0644) },
+	},
+	{
+		name:      "missing path",
+		setup:     func(t *testing.T, dir string) string { return filepath.Join(dir, "docker-compose.yaml") },
+		wantError: "compose file not found at %s",
+	},
+	{
+		name:      "directory",
+		setup:     func(t *testing.T, dir string) string { return dir },
+		wantError: "compose file at %s is a directory",
+	},
+	{
+		name: "unreadable file",
+		setup: func(t *testing.T, dir string) string {
+			if os.Geteuid() == 0 {
+				t.Skip("root can read files without read permission")
+			}
+			return writeComposeFile(t, dir, 0)
+		},
+		wantError: "compose file at %s is not readable",
+	},
+	{
+		// Unlike a permission error this fails to open as root too
+		name: "path below a file",
+		setup: func(t *testing.T, dir string) string {
+			return filepath.Join(writeComposeFile(t, dir, 0644), "docker-compose.yaml")
+		},
+		wantError: "compose file at %s is not readable",
+	},
+}
+
+// writeComposeFile writes a compose file with the given permissions to dir
+func writeComposeFile(t *testing.T, dir string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, "docker-compose.yaml")
+	if err := os.WriteFile(path, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckComposeFile(t *testing.T) {
+	for _, tt := range composeFileCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			path := tt.setup(t, t.TempDir())
+			viper.Set("pathToDockerCompose", path)
+
+			err := CheckComposeFile()
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("CheckComposeFile() = %v, want nil", err)
+				}
+				return
+			}
+			if want := strings.ReplaceAll(tt.wantError, "%s", path); err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("CheckComposeFile() = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestCheckComposeFileRequiresPath(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	if err := CheckComposeFile(); err == nil || err.Error() != "pathToDockerCompose not configured" {
+		t.Errorf("CheckComposeFile() = %v, want the unset path reported", err)
+	}
+}
@@ -1,18 +1,77 @@
 package config
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+// EnvironmentStaging marks a staging deployment in the environment config key
+const EnvironmentStaging = "staging"
+
+// EnvironmentDevelopment marks a local development setup in the environment config key
+const EnvironmentDevelopment = "development"
+
+// Output modes for environment changes made by handlers
+const (
+	OutputModeEnv             = "env"
+	OutputModeComposeOverride = "composeOverride"
+)
+
+// Key casing of public token responses
+const (
+	ResponseCaseCamel = "camel"
+	ResponseCaseSnake = "snake"
+)
+
+// Address formats of public token responses
+const (
+	AddressFormatLowercase = "lowercase"
+	AddressFormatChecksum  = "checksum"
+)
+
+// Social link modes set per token form field under socialLinks.<field>
+const (
+	SocialLinkModeURL = "url" // Convert handles to canonical URLs and require a valid URL (default)
+	SocialLinkModeRaw = "raw" // Store the value as entered
+)
+
+// Sources of record changes
+const (
+	ChangeSourceSupabase = "supabase"
+	ChangeSourcePostgres = "postgres"
+)
+
+// Realtime auth modes; any other value is used as a custom header name
+const (
+	RealtimeAuthModeBearer      = "bearer"
+	RealtimeAuthModeAPIKeyQuery = "apikey-query"
+	RealtimeAuthModeBoth        = "both"
+)
+
 // Config holds the application configuration
 type Config struct {
 	CORS CORSConfig
 	Auth AuthConfig
 }
 
+// TableConfig describes a monitored table and the handlers applied to its changes
+type TableConfig struct {
+	Name     string   `mapstructure:"name"`
+	Handlers []string `mapstructure:"handlers"` // Handler names; all handlers when empty
+}
+
 // CORSConfig holds CORS-related configuration
 type CORSConfig struct {
 	AllowedOrigins []string
@@ -41,6 +100,190 @@ func GetCORSAllowedOrigins() []string {
 	return origins
 }
 
+// GetImageAllowedTypes returns the list of exact image content types accepted
+// for logos and favicons. An empty list means any image/* type is accepted
+func GetImageAllowedTypes() []string {
+	typesStr := viper.GetString("imageValidation.allowedTypes")
+	if typesStr == "" {
+		return []string{}
+	}
+
+	// Split comma-separated types and normalize for comparison
+	types := strings.Split(typesStr, ",")
+	for i, t := range types {
+		types[i] = strings.ToLower(strings.TrimSpace(t))
+	}
+
+	return types
+}
+
+// GetImageAllowPrivate reports whether image URLs may point at private, loopback or
+// link-local addresses; they are blocked by default to prevent SSRF
+func GetImageAllowPrivate() bool {
+	return viper.GetBool("imageValidation.allowPrivate")
+}
+
+// GetImageAllowedHosts returns the host suffixes image URLs must match
+// (imageValidation.allowedHosts, comma-separated). An empty list allows any host
+func GetImageAllowedHosts() []string {
+	hostsStr := viper.GetString("imageValidation.allowedHosts")
+	if hostsStr == "" {
+		return []string{}
+	}
+
+	hosts := []string{}
+	for _, host := range strings.Split(hostsStr, ",") {
+		host = strings.Trim(strings.ToLower(strings.TrimSpace(host)), ".")
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// GetChainIDFrontendKeys returns the frontend env keys receiving the record's chain ID
+// (chainIdEnv.frontendKeys, comma-separated, e.g. NEXT_PUBLIC_NETWORK_ID); none by default
+func GetChainIDFrontendKeys() []string {
+	return splitList("chainIdEnv.frontendKeys", "")
+}
+
+// GetChainIDBackendKeys returns the backend env keys receiving the record's chain ID
+// (chainIdEnv.backendKeys, comma-separated, e.g. CHAIN_ID); none by default
+func GetChainIDBackendKeys() []string {
+	return splitList("chainIdEnv.backendKeys", "")
+}
+
+// splitList returns the trimmed, non-empty entries of the comma-separated value at key,
+// or of def when key is unset; an explicitly empty value yields an empty list
+func splitList(key, def string) []string {
+	value := def
+	if viper.IsSet(key) {
+		value = viper.GetString(key)
+	}
+	entries := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// GetSocialLinkMode returns how a token link field (e.g. "twitter") is normalized, "url" unless "raw" is configured
+func GetSocialLinkMode(field string) string {
+	if viper.GetString("socialLinks."+field) == SocialLinkModeRaw {
+		return SocialLinkModeRaw
+	}
+	return SocialLinkModeURL
+}
+
+// DefaultTokenFieldLimits are the token form length limits used when limits.<name> is not set
+var DefaultTokenFieldLimits = map[string]int{
+	"projectNameMax": 100,
+	"descriptionMax": 2000,
+	"sectorMax":      100,
+	"emailMax":       254,
+	"urlMax":         2048,
+	"tickerMax":      50,
+	"tokenNameMax":   100,
+	"tokenSymbolMax": 20,
+}
+
+// GetTokenFieldLimit returns the maximum length in characters of a token form
+// limit (e.g. "descriptionMax"); 0 disables the limit
+func GetTokenFieldLimit(name string) int {
+	key := "limits." + name
+	if viper.IsSet(key) {
+		return viper.GetInt(key)
+	}
+	return DefaultTokenFieldLimits[name]
+}
+
+// Token delete modes
+const (
+	DeleteModeSoft = "soft"
+	DeleteModeHard = "hard"
+)
+
+// GetDeleteMode returns how the token delete endpoint removes tokens: "soft" (default,
+// restorable) or "hard"
+func GetDeleteMode() string {
+	if viper.GetString("delete.mode") == DeleteModeHard {
+		return DeleteModeHard
+	}
+	return DeleteModeSoft
+}
+
+// GetRestartOrder returns the services in the order they are passed to docker compose
+// when recreated together (restartOrder, comma-separated); unlisted services follow alphabetically
+func GetRestartOrder() []string {
+	services := []string{}
+	for _, service := range strings.Split(viper.GetString("restartOrder"), ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// GetExplorerAdditionalHosts returns extra explorer hosts (e.g. an old domain
+// during a migration) that should keep working alongside the primary host
+func GetExplorerAdditionalHosts() []string {
+	hostsStr := viper.GetString("explorer.additionalHosts")
+	if hostsStr == "" {
+		return []string{}
+	}
+
+	// Split comma-separated hosts, trimming whitespace and skipping empty entries
+	hosts := []string{}
+	for _, host := range strings.Split(hostsStr, ",") {
+		host = strings.TrimSpace(host)
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// GetExplorerRequireURL reports whether an empty explorer URL is an error; by default the
+// explorer handler is skipped until the URL is set
+func GetExplorerRequireURL() bool {
+	return viper.GetBool("explorer.requireURL")
+}
+
+// GetExplorerDefaultProtocol returns the protocol assumed for explorer URLs without a
+// usable scheme: "http" when explorer.defaultProtocol is "http", otherwise "https"
+func GetExplorerDefaultProtocol() string {
+	if strings.EqualFold(viper.GetString("explorer.defaultProtocol"), "http") {
+		return "http"
+	}
+	return "https"
+}
+
+// GetDBQueryTimeoutPublic returns how long the database queries of a public API request
+// may take (dbQueryTimeout.public, default 2s); 0 disables the timeout
+func GetDBQueryTimeoutPublic() time.Duration {
+	if viper.IsSet("dbQueryTimeout.public") {
+		return viper.GetDuration("dbQueryTimeout.public")
+	}
+	return 2 * time.Second
+}
+
+// GetDBQueryTimeoutAdmin returns how long the database queries of a protected API request
+// may take (dbQueryTimeout.admin, default 30s); 0 disables the timeout
+func GetDBQueryTimeoutAdmin() time.Duration {
+	if viper.IsSet("dbQueryTimeout.admin") {
+		return viper.GetDuration("dbQueryTimeout.admin")
+	}
+	return 30 * time.Second
+}
+
+// GetDashboardRequireAuth reports whether the dashboard page itself requires authentication
+func GetDashboardRequireAuth() bool {
+	return viper.GetBool("dashboard.requireAuth")
+}
+
 // GetAuthUsername returns the authentication username
 func GetAuthUsername() string {
 	return viper.GetString("auth.username")
@@ -51,11 +294,739 @@ func GetAuthPassword() string {
 	return viper.GetString("auth.password")
 }
 
+// GetIconSyncAllowClear reports whether a token update may clear a non-empty Blockscout icon
+func GetIconSyncAllowClear() bool {
+	return viper.GetBool("iconSync.allowClear")
+}
+
+// GetIconSyncRetryAttempts returns how many times a Blockscout icon update is attempted (default 3)
+func GetIconSyncRetryAttempts() int {
+	if attempts := viper.GetInt("iconSync.retryAttempts"); attempts > 0 {
+		return attempts
+	}
+	return 3
+}
+
+// GetIconSyncRetryBackoff returns the delay before the first icon update retry,
+// doubled after every failed attempt (default 500ms)
+func GetIconSyncRetryBackoff() time.Duration {
+	if backoff := viper.GetDuration("iconSync.retryBackoff"); backoff > 0 {
+		return backoff
+	}
+	return 500 * time.Millisecond
+}
+
+// IsStaging reports whether the sidecar runs a staging deployment
+// Any value other than "staging" (including "development") is treated as production
+func IsStaging() bool {
+	return viper.GetString("environment") == EnvironmentStaging
+}
+
+// GetRealtimeRequired reports whether the sidecar exits with an error when it cannot monitor
+// database changes, instead of continuing without (realtime.required, default false)
+func GetRealtimeRequired() bool {
+	return viper.GetBool("realtime.required")
+}
+
+// GetHeartbeatMaxFailures returns how many consecutive Realtime heartbeats may fail before the
+// connection is considered dead and re-established (heartbeat.maxFailures, default 3, 0 never reconnects)
+func GetHeartbeatMaxFailures() int {
+	if viper.IsSet("heartbeat.maxFailures") {
+		return viper.GetInt("heartbeat.maxFailures")
+	}
+	return 3
+}
+
+// GetEnvVerifyAfterWrite reports whether handlers re-read the env file after writing it to
+// confirm the written values landed (env.verifyAfterWrite, off by default to save the IO)
+func GetEnvVerifyAfterWrite() bool {
+	return viper.GetBool("env.verifyAfterWrite")
+}
+
+// GetEnvClearPrefixOnUpdate reports whether env file keys with a prefix from env.clearPrefixes
+// that no handler wrote during a full pass over a record are removed (env.clearPrefixOnUpdate)
+func GetEnvClearPrefixOnUpdate() bool {
+	return viper.GetBool("env.clearPrefixOnUpdate")
+}
+
+// GetEnvClearPrefixes returns the key prefixes cleared when env.clearPrefixOnUpdate is enabled
+// (env.clearPrefixes, comma-separated, default NEXT_PUBLIC_)
+func GetEnvClearPrefixes() []string {
+	return splitList("env.clearPrefixes", "NEXT_PUBLIC_")
+}
+
+// GetEnvClearPrefixExclude returns keys that are never cleared even when they match a prefix,
+// e.g. keys written by other processes (env.clearPrefixExclude, comma-separated). The
+// maintenance key is always excluded since the maintenance endpoint, not a handler, owns it
+func GetEnvClearPrefixExclude() []string {
+	return append(splitList("env.clearPrefixExclude", ""), GetMaintenanceEnvKey())
+}
+
+// GetEnvChangeLogPath returns the file every env file change is appended to as a unified diff
+// (envChangeLog); empty disables the change log
+func GetEnvChangeLogPath() string {
+	return viper.GetString("envChangeLog")
+}
+
+// GetTokensCacheTTL returns how long the local token list is cached in memory (tokens.cacheTTL)
+// 0, the default, disables the cache
+func GetTokensCacheTTL() time.Duration {
+	return viper.GetDuration("tokens.cacheTTL")
+}
+
+// IsDevelopment reports whether the sidecar runs against a local development setup
+func IsDevelopment() bool {
+	return viper.GetString("environment") == EnvironmentDevelopment
+}
+
+// GetCreateMonitoredTableIfMissing reports whether missing monitored tables are created
+// at startup; only honored with environment: development
+func GetCreateMonitoredTableIfMissing() bool {
+	return viper.GetBool("createMonitoredTableIfMissing")
+}
+
+// GetCompressionEnabled reports whether HTTP responses are compressed (enabled by default)
+func GetCompressionEnabled() bool {
+	if !viper.IsSet("http.compressionEnabled") {
+		return true
+	}
+	return viper.GetBool("http.compressionEnabled")
+}
+
+// GetManageContainers reports whether the sidecar recreates containers after env changes (enabled by default)
+// When disabled, env files are still maintained and restarts are left to external tooling
+func GetManageContainers() bool {
+	if !viper.IsSet("manageContainers") {
+		return true
+	}
+	return viper.GetBool("manageContainers")
+}
+
+// GetCompressionLevel returns the HTTP compression level:
+// 0 default, 1 best speed, 2 best compression
+func GetCompressionLevel() int {
+	return viper.GetInt("http.compressionLevel")
+}
+
+// DefaultHTTPMaxBodyBytes is the request body limit used when http.maxBodyBytes is not set
+const DefaultHTTPMaxBodyBytes = 1 << 20
+
+// GetHTTPMaxBodyBytes returns the largest request body accepted, larger ones get 413 (default 1MB)
+func GetHTTPMaxBodyBytes() int {
+	if limit := viper.GetInt("http.maxBodyBytes"); limit > 0 {
+		return limit
+	}
+	return DefaultHTTPMaxBodyBytes
+}
+
+// GetStrictBody reports whether request bodies with unknown JSON fields are rejected
+func GetStrictBody() bool {
+	return viper.GetBool("strictBody")
+}
+
+// GetBodyFieldAliases returns the alternate JSON field names accepted by the token upsert
+// endpoint, mapped to the canonical field they fill (bodyFieldAliases, e.g. website: projectWebsite)
+// Aliases are lowercase since config keys are case-insensitive
+func GetBodyFieldAliases() map[string]string {
+	return viper.GetStringMapString("bodyFieldAliases")
+}
+
 // GetChainID returns the configured chain ID
 func GetChainID() string {
 	return viper.GetString("chainId")
 }
 
+// IsChainAllowed reports whether records for chainID may be applied
+// allowedChainIds is a comma-separated list of chain IDs; when unset every chain is allowed
+func IsChainAllowed(chainID int) bool {
+	allowedStr := viper.GetString("allowedChainIds")
+	if strings.TrimSpace(allowedStr) == "" {
+		return true
+	}
+
+	for _, allowed := range strings.Split(allowedStr, ",") {
+		if strings.TrimSpace(allowed) == strconv.Itoa(chainID) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAllowedChainIDs returns the chains listed in allowedChainIds, skipping invalid entries
+func GetAllowedChainIDs() []int {
+	chainIDs := []int{}
+	for _, allowed := range strings.Split(viper.GetString("allowedChainIds"), ",") {
+		if chainID, err := strconv.Atoi(strings.TrimSpace(allowed)); err == nil {
+			chainIDs = append(chainIDs, chainID)
+		}
+	}
+	return chainIDs
+}
+
+// GetTables returns the tables to monitor. When "tables" is not set, the single
+// "table" key is monitored with all handlers
+func GetTables() []TableConfig {
+	var tables []TableConfig
+	if viper.IsSet("tables") {
+		if err := viper.UnmarshalKey("tables", &tables); err != nil {
+			log.Printf("Can't parse tables config: %s\n", err)
+		}
+	}
+	if len(tables) == 0 {
+		tables = []TableConfig{{Name: viper.GetString("table")}}
+	}
+	return tables
+}
+
+// GetMonitoredActiveColumn returns the boolean column marking the active row among several
+// config rows of a chain (monitoredActiveColumn); empty when every row counts
+func GetMonitoredActiveColumn() (string, error) {
+	column := viper.GetString("monitoredActiveColumn")
+	if column == "" {
+		return "", nil
+	}
+	if err := SafeIdentifier(column); err != nil {
+		return "", fmt.Errorf("monitoredActiveColumn: %w", err)
+	}
+	return column, nil
+}
+
+// GetChainString returns the configuration value for key, preferring a
+// per-chain override from the "chains.<chainId>" map when one is set.
+// Falls back to the global key when no override exists
+func GetChainString(chainID int, key string) string {
+	overrideKey := fmt.Sprintf("chains.%d.%s", chainID, key)
+	if viper.IsSet(overrideKey) {
+		return viper.GetString(overrideKey)
+	}
+	return viper.GetString(key)
+}
+
+// GetEnvFilePath returns the env file for a chain. When pathToEnvFileTemplate is set,
+// "{chainId}" in it is replaced with the chain ID; otherwise pathToEnvFile is used
+func GetEnvFilePath(chainID int) string {
+	if template := viper.GetString("pathToEnvFileTemplate"); template != "" {
+		return strings.ReplaceAll(template, "{chainId}", fmt.Sprint(chainID))
+	}
+	return viper.GetString("pathToEnvFile")
+}
+
+// GetFieldDefault returns the value substituted for an empty record field, set under
+// defaults.<field> (e.g. defaults.name); empty when no default is configured
+func GetFieldDefault(field string) string {
+	return viper.GetString("defaults." + field)
+}
+
+// SafeIdentifier validates that a table or column name is safe for SQL queries
+// Only allows alphanumeric characters and underscores, starting with a letter or underscore
+func SafeIdentifier(identifier string) error {
+	if !safeIdentifierPattern.MatchString(identifier) {
+		return fmt.Errorf("unsafe SQL identifier: %s - only alphanumeric characters and underscores allowed, must start with letter or underscore", identifier)
+	}
+	return nil
+}
+
+var safeIdentifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// BlockscoutTokensSchema names the Blockscout table and columns holding tokens,
+// for Blockscout versions or forks whose schema differs from the default
+type BlockscoutTokensSchema struct {
+	Table           string // May be schema-qualified, e.g. "explorer.tokens"
+	AddressColumn   string // bytea contract address, rendered as 0x-prefixed hex
+	SymbolColumn    string
+	NameColumn      string
+	IconURLColumn   string
+	UpdatedAtColumn string
+}
+
+// GetBlockscoutTokensSchema returns the Blockscout tokens table mapping from
+// blockscout.tokensTable and blockscout.columns.*, defaulting to the standard schema
+// Every identifier is checked with SafeIdentifier
+func GetBlockscoutTokensSchema() (BlockscoutTokensSchema, error) {
+	get := func(key, fallback string) string {
+		if value := viper.GetString(key); value != "" {
+			return value
+		}
+		return fallback
+	}
+	schema := BlockscoutTokensSchema{
+		Table:           get("blockscout.tokensTable", "tokens"),
+		AddressColumn:   get("blockscout.columns.address", "contract_address_hash"),
+		SymbolColumn:    get("blockscout.columns.symbol", "symbol"),
+		NameColumn:      get("blockscout.columns.name", "name"),
+		IconURLColumn:   get("blockscout.columns.iconUrl", "icon_url"),
+		UpdatedAtColumn: get("blockscout.columns.updatedAt", "updated_at"),
+	}
+
+	identifiers := strings.Split(schema.Table, ".")
+	if len(identifiers) > 2 {
+		return schema, fmt.Errorf("invalid blockscout.tokensTable %q: expected table or schema.table", schema.Table)
+	}
+	identifiers = append(identifiers, schema.AddressColumn, schema.SymbolColumn, schema.NameColumn, schema.IconURLColumn, schema.UpdatedAtColumn)
+	for _, identifier := range identifiers {
+		if err := SafeIdentifier(identifier); err != nil {
+			return schema, fmt.Errorf("invalid Blockscout tokens schema: %w", err)
+		}
+	}
+	return schema, nil
+}
+
+// GetChangeSource returns where record changes come from: "supabase" realtime (default)
+// or "postgres" LISTEN/NOTIFY
+func GetChangeSource() string {
+	if viper.GetString("changeSource") == ChangeSourcePostgres {
+		return ChangeSourcePostgres
+	}
+	return ChangeSourceSupabase
+}
+
+// GetRealtimeAuthMode returns how the realtime API key is sent: "bearer", "apikey-query",
+// "both" (default) or the name of a custom header carrying the key
+func GetRealtimeAuthMode() string {
+	if mode := viper.GetString("realtimeAuthMode"); mode != "" {
+		return mode
+	}
+	return RealtimeAuthModeBoth
+}
+
+// GetResponseCase returns the key casing of public token responses, "camel" unless "snake" is configured
+func GetResponseCase() string {
+	if viper.GetString("responseCase") == ResponseCaseSnake {
+		return ResponseCaseSnake
+	}
+	return ResponseCaseCamel
+}
+
+// GetResponseAddressFormat returns how token addresses are rendered in the public token
+// info response, "lowercase" as stored unless response.addressFormat is "checksum" (EIP-55)
+func GetResponseAddressFormat() string {
+	if viper.GetString("response.addressFormat") == AddressFormatChecksum {
+		return AddressFormatChecksum
+	}
+	return AddressFormatLowercase
+}
+
+// GetMaxTokensInMemory returns the maximum number of tokens a listing may load
+// into memory from a single database (0 means no limit)
+func GetMaxTokensInMemory() int {
+	return viper.GetInt("maxTokensInMemory")
+}
+
+// GetMaintenanceEnvKey returns the frontend env key toggled by the maintenance endpoint
+func GetMaintenanceEnvKey() string {
+	if key := viper.GetString("maintenance.envKey"); key != "" {
+		return key
+	}
+	return "NEXT_PUBLIC_MAINTENANCE"
+}
+
+// GetSubscribeRetryAttempts returns how many times the Realtime join is attempted (default 3)
+func GetSubscribeRetryAttempts() int {
+	if attempts := viper.GetInt("realtimeSubscribe.retryAttempts"); attempts > 0 {
+		return attempts
+	}
+	return 3
+}
+
+// GetSubscribeRetryBackoff returns the delay before the first Realtime join retry,
+// doubled after each failure (default 1s)
+func GetSubscribeRetryBackoff() time.Duration {
+	if backoff := viper.GetDuration("realtimeSubscribe.retryBackoff"); backoff > 0 {
+		return backoff
+	}
+	return time.Second
+}
+
+// GetSubscribeJoinTimeout returns how long to wait for Realtime to acknowledge a join (default 10s)
+func GetSubscribeJoinTimeout() time.Duration {
+	if timeout := viper.GetDuration("realtimeSubscribe.joinTimeout"); timeout > 0 {
+		return timeout
+	}
+	return 10 * time.Second
+}
+
+// GetStartupWaitDelay returns how long to wait before the initial check (startupWait.delay, 0 disables)
+func GetStartupWaitDelay() time.Duration {
+	return viper.GetDuration("startupWait.delay")
+}
+
+// GetStartupWaitForHealthy reports whether the initial check waits for the configured
+// containers to be running and healthy
+func GetStartupWaitForHealthy() bool {
+	return viper.GetBool("startupWait.waitForHealthy")
+}
+
+// GetStartupWaitTimeout returns how long to wait for healthy containers before running
+// the initial check anyway (default 5m)
+func GetStartupWaitTimeout() time.Duration {
+	if timeout := viper.GetDuration("startupWait.timeout"); timeout > 0 {
+		return timeout
+	}
+	return 5 * time.Minute
+}
+
+// GetForceAssertKeys returns the env keys the initial check always writes and restarts
+// for, even when the computed value matches the one on disk (forceAssertKeys, comma-separated)
+func GetForceAssertKeys() []string {
+	keys := []string{}
+	for _, key := range strings.Split(viper.GetString("forceAssertKeys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GetInitialCheckTimeout returns how long the initial check, including its handlers,
+// may run before startup continues without it (default 5m)
+func GetInitialCheckTimeout() time.Duration {
+	if timeout := viper.GetDuration("initialCheck.timeout"); timeout > 0 {
+		return timeout
+	}
+	return 5 * time.Minute
+}
+
+// GetAlertsWebhookURL returns the Slack or Discord webhook handler errors are posted to
+// (alerts.webhookUrl); empty disables alerts
+func GetAlertsWebhookURL() string {
+	return viper.GetString("alerts.webhookUrl")
+}
+
+// GetAlertsFormat returns the alert payload format, "discord" when alerts.format is
+// "discord", otherwise "slack"
+func GetAlertsFormat() string {
+	if strings.EqualFold(viper.GetString("alerts.format"), "discord") {
+		return "discord"
+	}
+	return "slack"
+}
+
+// GetAlertsCooldown returns how long a handler stays silent after alerting (alerts.cooldown, default 15m)
+func GetAlertsCooldown() time.Duration {
+	if viper.IsSet("alerts.cooldown") {
+		return viper.GetDuration("alerts.cooldown")
+	}
+	return 15 * time.Minute
+}
+
+// GetAlertsAllowPrivate reports whether the alerts webhook may be on a private, loopback or
+// link-local address, e.g. a self-hosted chat server (alerts.allowPrivate, default false)
+func GetAlertsAllowPrivate() bool {
+	return viper.GetBool("alerts.allowPrivate")
+}
+
+// GetEnableHTTPServer reports whether the sidecar serves the HTTP API and web interface (enable.httpServer, default true)
+func GetEnableHTTPServer() bool {
+	return enabled("enable.httpServer")
+}
+
+// GetEnableRealtime reports whether the sidecar monitors database changes (enable.realtime, default true)
+func GetEnableRealtime() bool {
+	return enabled("enable.realtime")
+}
+
+// GetEnableWorker reports whether the sidecar recreates containers (enable.worker, default true)
+func GetEnableWorker() bool {
+	return enabled("enable.worker")
+}
+
+// enabled reads a boolean key that defaults to true when unset
+func enabled(key string) bool {
+	if viper.IsSet(key) {
+		return viper.GetBool(key)
+	}
+	return true
+}
+
+// GetHandlerTimeout returns how long a single handler may run for a record before its
+// network calls are cancelled and it fails (handlerTimeout); 0, the default, disables the limit
+func GetHandlerTimeout() time.Duration {
+	return viper.GetDuration("handlerTimeout")
+}
+
+// GetMaxRecordAge returns how old a record's updated_at may be for the initial check to apply it
+// (maxRecordAge); 0, the default, applies records regardless of age
+func GetMaxRecordAge() time.Duration {
+	return viper.GetDuration("maxRecordAge")
+}
+
+// GetRecreationVerifyHealth reports whether recreated containers are checked to be
+// running and healthy before the recreation is reported as successful
+func GetRecreationVerifyHealth() bool {
+	return viper.GetBool("recreation.verifyHealth")
+}
+
+// GetRecreationVerifyDuration returns how long recreated containers are watched when
+// recreation.verifyHealth is set (default 30s)
+func GetRecreationVerifyDuration() time.Duration {
+	if duration := viper.GetDuration("recreation.verifyDuration"); duration > 0 {
+		return duration
+	}
+	return 30 * time.Second
+}
+
+// GetRecreationFailureBackoff returns how long a job waits before it is re-attempted after
+// failing once; each further consecutive failure doubles it (recreation.failureBackoff,
+// default 30s, an explicit 0 disables the backoff)
+func GetRecreationFailureBackoff() time.Duration {
+	if viper.IsSet("recreation.failureBackoff") {
+		return viper.GetDuration("recreation.failureBackoff")
+	}
+	return 30 * time.Second
+}
+
+// GetRecreationFailureBackoffMax caps the doubled failure backoff (default 10m)
+func GetRecreationFailureBackoffMax() time.Duration {
+	if max := viper.GetDuration("recreation.failureBackoffMax"); max > 0 {
+		return max
+	}
+	return 10 * time.Minute
+}
+
+// GetRecreationMaxFailures returns after how many consecutive failures a job is no longer
+// retried automatically (recreation.maxFailures, default 5, an explicit 0 never stops retrying)
+func GetRecreationMaxFailures() int {
+	if viper.IsSet("recreation.maxFailures") {
+		return viper.GetInt("recreation.maxFailures")
+	}
+	return 5
+}
+
+// DefaultRecreationDelay is the recreationDelay used when the key is not set. It is 0, so
+// deployments that never set the key keep processing jobs without waiting
+const DefaultRecreationDelay = 0 * time.Second
+
+// GetRecreationDelay returns how long the worker waits before processing its first job
+// and after each recreation. An explicit 0 disables the wait; unset uses DefaultRecreationDelay
+func GetRecreationDelay() time.Duration {
+	if viper.IsSet("recreationDelay") {
+		return viper.GetDuration("recreationDelay")
+	}
+	return DefaultRecreationDelay
+}
+
+// GetDockerCommandTimeout returns how long a single docker command may run before it is killed (default 5m)
+func GetDockerCommandTimeout() time.Duration {
+	if timeout := viper.GetDuration("dockerCommandTimeout"); timeout > 0 {
+		return timeout
+	}
+	return 5 * time.Minute
+}
+
+// GetProjectName returns the compose project name for a chain. When projectNameTemplate is set,
+// "{chainId}" in it is replaced with the chain ID; otherwise projectName (with per-chain overrides) is used
+func GetProjectName(chainID int) string {
+	if template := viper.GetString("projectNameTemplate"); template != "" {
+		return strings.ReplaceAll(template, "{chainId}", fmt.Sprint(chainID))
+	}
+	return GetChainString(chainID, "projectName")
+}
+
+// tlsVersions maps tls.minVersion values to crypto/tls constants
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// GetTLSMinVersion returns the minimum TLS version for outbound connections (default TLS 1.2)
+// Unknown values fall back to the default with a warning
+func GetTLSMinVersion() uint16 {
+	value := viper.GetString("tls.minVersion")
+	if value == "" {
+		return tls.VersionTLS12
+	}
+	if version, ok := tlsVersions[value]; ok {
+		return version
+	}
+	log.Printf("Warning: unsupported tls.minVersion %q, using 1.2", value)
+	return tls.VersionTLS12
+}
+
+// databaseURLKeys are the config keys of the database connections made with the pq driver
+var databaseURLKeys = []string{"sidecarDatabaseUrl", "sidecarDatabaseReplicaUrl", "blockscoutDatabaseUrl", "supabaseUrl"}
+
+// DatabaseTLSWarnings reports the database URLs whose sslmode allows plaintext connections
+// while tls.minVersion is set. pq cannot pin a minimum TLS version, so tls.minVersion does
+// not apply to them; an unset sslmode is pq's default, require
+func DatabaseTLSWarnings() []string {
+	if viper.GetString("tls.minVersion") == "" {
+		return nil
+	}
+	var warnings []string
+	for _, key := range databaseURLKeys {
+		switch mode := databaseSSLMode(viper.GetString(key)); mode {
+		case "disable", "allow", "prefer":
+			warnings = append(warnings, fmt.Sprintf("%s uses sslmode=%s, so tls.minVersion does not protect it; use sslmode=verify-full", key, mode))
+		}
+	}
+	return warnings
+}
+
+// databaseSSLMode returns the sslmode of a postgres:// URL or key=value connection string
+func databaseSSLMode(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			return ""
+		}
+		return parsed.Query().Get("sslmode")
+	}
+	for _, field := range strings.Fields(dsn) {
+		if value, ok := strings.CutPrefix(field, "sslmode="); ok {
+			return strings.Trim(value, "'")
+		}
+	}
+	return ""
+}
+
+// GetOutputMode returns how handlers emit environment changes:
+// "env" (default) edits the shared env file, "composeOverride" writes a compose override file
+func GetOutputMode() string {
+	if viper.GetString("outputMode") == OutputModeComposeOverride {
+		return OutputModeComposeOverride
+	}
+	return OutputModeEnv
+}
+
+// GetComposeOverridePath returns the compose override file path, defaulting to
+// docker-compose.override.yml next to the compose file
+func GetComposeOverridePath() string {
+	if path := viper.GetString("pathToComposeOverride"); path != "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(viper.GetString("pathToDockerCompose")), "docker-compose.override.yml")
+}
+
+// CheckComposeFile verifies that pathToDockerCompose points to a readable file
+func CheckComposeFile() error {
+	path := viper.GetString("pathToDockerCompose")
+	if path == "" {
+		return fmt.Errorf("pathToDockerCompose not configured")
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("compose file not found at %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("compose file at %s is not readable: %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("compose file at %s is not readable: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("compose file at %s is a directory", path)
+	}
+	return nil
+}
+
+// ApplyOverrides sets config keys from key=value pairs (the --set flag), taking precedence
+// over the config file and environment. Dotted keys address nested options. Values are parsed
+// as the type of the key's current value, or as an int or bool when it looks like one
+func ApplyOverrides(overrides []string) error {
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid override %q, expected key=value", override)
+		}
+		parsed, err := parseOverride(key, value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		viper.Set(key, parsed)
+	}
+	return nil
+}
+
+// parseOverride converts an override value to the type the key currently has
+func parseOverride(key, value string) (interface{}, error) {
+	switch current := viper.Get(key).(type) {
+	case int, int64:
+		return strconv.Atoi(value)
+	case float64:
+		return strconv.ParseFloat(value, 64)
+	case bool:
+		return strconv.ParseBool(value)
+	case time.Duration:
+		return time.ParseDuration(value)
+	case string:
+		// Durations are read from YAML as strings such as "5s"
+		if _, err := time.ParseDuration(current); err == nil && current != "0" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+	case nil:
+		if number, err := strconv.Atoi(value); err == nil {
+			return number, nil
+		}
+		if flag, err := strconv.ParseBool(value); err == nil {
+			return flag, nil
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+// RedactedValue replaces secret values in EffectiveSettings
+const RedactedValue = "[redacted]"
+
+// secretKeys are the lowercase config keys whose values EffectiveSettings redacts. Any key
+// named password or ending in databaseurl is redacted too, wherever it is nested
+var secretKeys = map[string]bool{
+	"auth.password":             true,
+	"supabaseurl":               true,
+	"supabaseanonkey":           true,
+	"sidecardatabaseurl":        true,
+	"sidecardatabasereplicaurl": true,
+	"blockscoutdatabaseurl":     true,
+	"events.nats.url":           true, // May carry user:pass
+	"alerts.webhookurl":         true, // Slack and Discord webhook URLs embed their token
+}
+
+// EffectiveSettings returns the merged configuration (file, environment and --set overrides)
+// as the running process sees it, with secrets replaced by RedactedValue
+func EffectiveSettings() map[string]interface{} {
+	return redactSettings("", viper.AllSettings())
+}
+
+// redactSettings copies settings, redacting secret values; prefix is the dotted path of settings
+func redactSettings(prefix string, settings map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		path := strings.ToLower(key)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			redacted[key] = redactSettings(path, nested)
+			continue
+		}
+		if isSecretKey(path) {
+			redacted[key] = RedactedValue
+			continue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// isSecretKey reports whether the value at the dotted lowercase path is a secret
+func isSecretKey(path string) bool {
+	if secretKeys[path] {
+		return true
+	}
+	name := path[strings.LastIndex(path, ".")+1:]
+	return name == "password" || strings.HasSuffix(name, "databaseurl")
+}
+
 // InitConfig initializes the application configuration using viper.
 // If configPath is provided, it will use that specific file,
 // otherwise it will look for 'local.yaml' in the config directory
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{} // nil leaves the key unset
+		want  []string
+	}{
+		{"unset uses default", nil, []string{"A_", "B_"}},
+		{"explicitly empty disables", "", []string{}},
+		{"trims and drops blanks", " X_ , ,Y_ ", []string{"X_", "Y_"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			if tt.value != nil {
+				viper.Set("list", tt.value)
+			}
+			if got := splitList("list", "A_,B_"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvClearPrefixExcludeKeepsMaintenanceKey(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("env.clearPrefixExclude", "NEXT_PUBLIC_KEEP_")
+
+	want := []string{"NEXT_PUBLIC_KEEP_", GetMaintenanceEnvKey()}
+	if got := GetEnvClearPrefixExclude(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEnvClearPrefixExclude() = %q, want %q", got, want)
+	}
+}
+
+func TestGetChainStringPerChainOverride(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("frontendContainerName", "frontend")
+	viper.Set("chains.1.frontendContainerName", "frontend-chain-a")
+	viper.Set("chains.2.frontendContainerName", "frontend-chain-b")
+
+	tests := []struct {
+		chainID int
+		want    string
+	}{
+		{1, "frontend-chain-a"},
+		{2, "frontend-chain-b"},
+		{3, "frontend"}, // no override falls back to the global key
+	}
+	for _, tt := range tests {
+		if got := GetChainString(tt.chainID, "frontendContainerName"); got != tt.want {
+			t.Errorf("GetChainString(%d) = %q, want %q", tt.chainID, got, tt.want)
+		}
+	}
+}
+
+func TestGetRecreationDelayDefaultsOnlyWhenUnset(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{} // nil leaves the key unset
+		want  time.Duration
+	}{
+		{"unset uses default", nil, DefaultRecreationDelay},
+		{"unset does not wait", nil, 0},
+		{"explicit zero disables the wait", 0, 0},
+		{"explicit zero string disables the wait", "0s", 0},
+		{"configured delay", "5s", 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			if tt.value != nil {
+				viper.Set("recreationDelay", tt.value)
+			}
+			if got := GetRecreationDelay(); got != tt.want {
+				t.Errorf("GetRecreationDelay() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabaseTLSWarnings(t *testing.T) {
+	tests := []struct {
+		name       string
+		minVersion string
+		urls       map[string]string
+		want       []string
+	}{
+		{
+			name: "minVersion unset",
+			urls: map[string]string{"supabaseUrl": "postgres://localhost/supabase?sslmode=disable"},
+		},
+		{
+			name:       "plaintext sslmodes",
+			minVersion: "1.3",
+			urls: map[string]string{
+				"sidecarDatabaseUrl":    "postgresql://localhost/blockscout_vc?sslmode=prefer",
+				"blockscoutDatabaseUrl": "host=localhost dbname=blockscout sslmode=allow",
+				"supabaseUrl":           "postgres://localhost/supabase?sslmode=disable",
+			},
+			want: []string{
+				"sidecarDatabaseUrl uses sslmode=prefer, so tls.minVersion does not protect it; use sslmode=verify-full",
+				"blockscoutDatabaseUrl uses sslmode=allow, so tls.minVersion does not protect it; use sslmode=verify-full",
+				"supabaseUrl uses sslmode=disable, so tls.minVersion does not protect it; use sslmode=verify-full",
+			},
+		},
+		{
+			name:       "TLS sslmodes",
+			minVersion: "1.2",
+			urls: map[string]string{
+				"sidecarDatabaseUrl":    "postgres://localhost/blockscout_vc?sslmode=verify-full",
+				"blockscoutDatabaseUrl": "host=localhost sslmode=require",
+				"supabaseUrl":           "postgres://localhost/supabase",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("tls.minVersion", tt.minVersion)
+			for key, value := range tt.urls {
+				viper.Set(key, value)
+			}
+			if got := DatabaseTLSWarnings(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DatabaseTLSWarnings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
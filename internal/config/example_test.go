@@ -0,0 +1,20 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// TestExampleConfigLoads guards config/example.yaml against mistakes such as duplicate keys,
+// which make viper refuse the whole file
+func TestExampleConfigLoads(t *testing.T) {
+	v := viper.New()
+	v.SetConfigFile("../../config/example.yaml")
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("example config does not load: %v", err)
+	}
+	if got := v.GetString("projectName"); got != "blockscout" {
+		t.Fatalf("projectName = %q, want blockscout", got)
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestApplyOverridesTakePrecedenceOverConfigFile(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	configFile := filepath.Join(t.TempDir(), "local.yaml")
+	content := "chainId: 1313161554\nrecreationDelay: 30s\nimageValidation:\n  allowPrivate: false\n"
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	InitConfig(configFile)
+
+	err := ApplyOverrides([]string{"chainId=1313161556", "recreationDelay=5s", "imageValidation.allowPrivate=true", "chains.1.frontendServiceName=frontend-a"})
+	if err != nil {
+		t.Fatalf("ApplyOverrides: %v", err)
+	}
+
+	if got := GetChainID(); got != "1313161556" {
+		t.Errorf("GetChainID() = %q, want the override", got)
+	}
+	if got := GetRecreationDelay(); got != 5*time.Second {
+		t.Errorf("GetRecreationDelay() = %s, want 5s", got)
+	}
+	if !GetImageAllowPrivate() {
+		t.Error("GetImageAllowPrivate() = false, want the nested override")
+	}
+	if got := GetChainString(1, "frontendServiceName"); got != "frontend-a" {
+		t.Errorf("GetChainString() = %q, want the dotted override", got)
+	}
+}
+
+func TestApplyOverridesRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  interface{}
+		override string
+	}{
+		{"missing value", nil, "chainId"},
+		{"missing key", nil, "=1"},
+		{"int key", 1313161554, "chainId=aurora"},
+		{"bool key", false, "chainId=maybe"},
+		{"duration key", "30s", "chainId=soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			if tt.current != nil {
+				viper.Set("chainId", tt.current)
+			}
+			if err := ApplyOverrides([]string{tt.override}); err == nil {
+				t.Errorf("ApplyOverrides(%q) accepted an invalid override", tt.override)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"blockscout-vc/internal/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+// tokenCache keeps the result of GetAllTokens for a short TTL (tokens.cacheTTL), so the
+// dashboard polling the token list does not query the database every time
+// Writes invalidate it; a generation counter keeps a query that raced with a write from
+// storing its stale result
+type tokenCache struct {
+	mu         sync.Mutex
+	generation uint64
+	entries    map[bool]tokenCacheEntry // Keyed by includeDeleted
+}
+
+type tokenCacheEntry struct {
+	tokens    []models.TokenInfo
+	expiresAt time.Time
+}
+
+// get returns a copy of the cached tokens and the current generation, ok is false on a miss
+func (c *tokenCache) get(includeDeleted bool) (tokens []models.TokenInfo, generation uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[includeDeleted]
+	if !found || time.Now().After(entry.expiresAt) {
+		return nil, c.generation, false
+	}
+	return slices.Clone(entry.tokens), c.generation, true
+}
+
+// put stores tokens queried at generation, unless a write invalidated the cache since
+func (c *tokenCache) put(includeDeleted bool, tokens []models.TokenInfo, generation uint64, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[bool]tokenCacheEntry)
+	}
+	c.entries[includeDeleted] = tokenCacheEntry{tokens: slices.Clone(tokens), expiresAt: time.Now().Add(ttl)}
+}
+
+// invalidate drops every cached list
+func (c *tokenCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.entries = nil
+}
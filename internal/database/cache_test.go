@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+func TestGetAllTokensServesCacheWithoutDatabase(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("tokens.cacheTTL", time.Minute)
+	ctx := context.Background()
+	d := newTokenDatabase(t, "0xaaa")
+
+	if _, err := d.GetAllTokens(ctx, false); err != nil {
+		t.Fatalf("GetAllTokens: %v", err)
+	}
+	// A closed database fails every query, so only a cache hit can succeed
+	d.db.Close()
+	tokens, err := d.GetAllTokens(ctx, false)
+	if err != nil {
+		t.Fatalf("cached GetAllTokens queried the database: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].TokenAddress != "0xaaa" {
+		t.Errorf("GetAllTokens() = %+v, want the cached 0xaaa", tokens)
+	}
+	if _, err := d.GetAllTokens(ctx, true); err == nil {
+		t.Error("GetAllTokens(includeDeleted) was served from the other list's cache")
+	}
+}
+
+func TestUpsertInvalidatesTokenCache(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("tokens.cacheTTL", time.Minute)
+	ctx := context.Background()
+	d := newTokenDatabase(t, "0xaaa")
+
+	if _, err := d.GetAllTokens(ctx, false); err != nil {
+		t.Fatalf("GetAllTokens: %v", err)
+	}
+	form := &models.TokenInfoForm{TokenAddress: "0xbbb", ChainID: "1", ProjectName: "Aurora"}
+	if err := d.UpsertTokenInfo(ctx, form, nil); err != nil {
+		t.Fatalf("UpsertTokenInfo: %v", err)
+	}
+
+	tokens, err := d.GetAllTokens(ctx, false)
+	if err != nil {
+		t.Fatalf("GetAllTokens: %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Errorf("GetAllTokens() after upsert = %+v, want both tokens", tokens)
+	}
+}
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/models"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
@@ -10,14 +13,17 @@ import (
 
 	"blockscout-vc/internal/client"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/spf13/viper"
 )
 
 // Database provides database operations for token information
 // Note: All string fields use TEXT type which can handle NULL values without scan errors
+// Writes always go to the primary; read-only queries use the replica when one is configured
 type Database struct {
-	db *sql.DB
+	db     *sql.DB
+	readDB *sql.DB
+	tokens tokenCache
 }
 
 func NewDatabase() (*Database, error) {
@@ -54,27 +60,66 @@ func NewDatabase() (*Database, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return &Database{db: db}, nil
+	// Open the optional read replica; reads fall back to the primary when it is not configured
+	readDB := db
+	if replicaURL := viper.GetString("sidecarDatabaseReplicaUrl"); replicaURL != "" {
+		readDB, err = openReplica(replicaURL)
+		if err != nil {
+			if closeErr := db.Close(); closeErr != nil {
+				return nil, fmt.Errorf("%w, and failed to close connection: %w", err, closeErr)
+			}
+			return nil, err
+		}
+	}
+
+	return &Database{db: db, readDB: readDB}, nil
+}
+
+// openReplica opens and pings the read replica connection pool
+func openReplica(replicaURL string) (*sql.DB, error) {
+	replica, err := sql.Open("postgres", replicaURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica database: %w", err)
+	}
+
+	if err := replica.Ping(); err != nil {
+		if closeErr := replica.Close(); closeErr != nil {
+			return nil, fmt.Errorf("failed to ping replica database: %w, and failed to close connection: %w", err, closeErr)
+		}
+		return nil, fmt.Errorf("failed to ping replica database: %w", err)
+	}
+
+	return replica, nil
 }
 
 func (d *Database) Close() error {
-	return d.db.Close()
+	var closeErrors []error
+	if d.readDB != nil && d.readDB != d.db {
+		if err := d.readDB.Close(); err != nil {
+			closeErrors = append(closeErrors, fmt.Errorf("failed to close replica database: %w", err))
+		}
+	}
+	if err := d.db.Close(); err != nil {
+		closeErrors = append(closeErrors, err)
+	}
+	return errors.Join(closeErrors...)
 }
 
 // GetTokenInfo retrieves token information by token address and chain ID
-func (d *Database) GetTokenInfo(tokenAddress, chainID string) (*models.TokenInfo, error) {
+// Soft-deleted tokens are only returned when includeDeleted is set
+func (d *Database) GetTokenInfo(ctx context.Context, tokenAddress, chainID string, includeDeleted bool) (*models.TokenInfo, error) {
 	query := `
 		SELECT token_address, chain_id, project_name, project_website, project_email,
 		       icon_url, project_description, project_sector, docs, github, telegram,
 		       linkedin, discord, slack, twitter, opensea, facebook, medium, reddit,
 		       support, coin_market_cap_ticker, coin_gecko_ticker, defi_llama_ticker,
-		       token_name, token_symbol
+		       token_name, token_symbol, deleted_at
 		FROM token_infos
-		WHERE token_address = $1 AND chain_id = $2
+		WHERE token_address = $1 AND chain_id = $2 AND ($3 OR deleted_at IS NULL)
 	`
 
 	var token models.TokenInfo
-	err := d.db.QueryRow(query, tokenAddress, chainID).Scan(
+	err := d.readDB.QueryRowContext(ctx, query, tokenAddress, chainID, includeDeleted).Scan(
 		&token.TokenAddress, &token.ChainID, &token.ProjectName,
 		&token.ProjectWebsite, &token.ProjectEmail, &token.IconURL,
 		&token.ProjectDescription, &token.ProjectSector, &token.Docs,
@@ -82,7 +127,7 @@ func (d *Database) GetTokenInfo(tokenAddress, chainID string) (*models.TokenInfo
 		&token.Slack, &token.Twitter, &token.OpenSea, &token.Facebook,
 		&token.Medium, &token.Reddit, &token.Support, &token.CoinMarketCapTicker,
 		&token.CoinGeckoTicker, &token.DefiLlamaTicker, &token.TokenName,
-		&token.TokenSymbol,
+		&token.TokenSymbol, &token.DeletedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -95,22 +140,76 @@ func (d *Database) GetTokenInfo(tokenAddress, chainID string) (*models.TokenInfo
 	return &token, nil
 }
 
-// GetAllTokens retrieves all tokens
-func (d *Database) GetAllTokens() ([]models.TokenInfo, error) {
+// GetAllTokens retrieves all tokens, including soft-deleted ones only when includeDeleted is set
+// Returns an error wrapping models.ErrTooManyTokens when there are more than maxTokensInMemory
+// With tokens.cacheTTL set, the list is served from memory until it expires or a write invalidates it
+func (d *Database) GetAllTokens(ctx context.Context, includeDeleted bool) ([]models.TokenInfo, error) {
+	ttl := config.GetTokensCacheTTL()
+	if ttl <= 0 {
+		return d.queryAllTokens(ctx, includeDeleted)
+	}
+
+	tokens, generation, ok := d.tokens.get(includeDeleted)
+	if ok {
+		return tokens, nil
+	}
+	tokens, err := d.queryAllTokens(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	d.tokens.put(includeDeleted, tokens, generation, ttl)
+	return tokens, nil
+}
+
+// queryAllTokens reads the token list from the database
+func (d *Database) queryAllTokens(ctx context.Context, includeDeleted bool) ([]models.TokenInfo, error) {
 	query := `
 		SELECT token_address, chain_id, project_name, project_website, project_email,
 		       icon_url, project_description, project_sector, docs, github, telegram,
 		       linkedin, discord, slack, twitter, opensea, facebook, medium, reddit,
 		       support, coin_market_cap_ticker, coin_gecko_ticker, defi_llama_ticker,
-		       token_name, token_symbol
+		       token_name, token_symbol, deleted_at
 		FROM token_infos
+		WHERE $1 OR deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
-	rows, err := d.db.Query(query)
+	rows, err := d.readDB.QueryContext(ctx, query, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tokens: %w", err)
 	}
+	return scanTokens(rows, config.GetMaxTokensInMemory())
+}
+
+// getTokensByAddresses reads the tokens with the given addresses, matching case-insensitively
+func (d *Database) getTokensByAddresses(ctx context.Context, addresses []string, includeDeleted bool) ([]models.TokenInfo, error) {
+	query := `
+		SELECT token_address, chain_id, project_name, project_website, project_email,
+		       icon_url, project_description, project_sector, docs, github, telegram,
+		       linkedin, discord, slack, twitter, opensea, facebook, medium, reddit,
+		       support, coin_market_cap_ticker, coin_gecko_ticker, defi_llama_ticker,
+		       token_name, token_symbol, deleted_at
+		FROM token_infos
+		WHERE lower(token_address) = ANY($1) AND ($2 OR deleted_at IS NULL)
+		ORDER BY created_at DESC
+	`
+
+	lowered := make([]string, len(addresses))
+	for i, address := range addresses {
+		lowered[i] = strings.ToLower(address)
+	}
+
+	rows, err := d.readDB.QueryContext(ctx, query, pq.Array(lowered), includeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tokens by address: %w", err)
+	}
+	// The result is bounded by the number of addresses, so maxTokensInMemory does not apply
+	return scanTokens(rows, 0)
+}
+
+// scanTokens reads and closes token rows, failing with models.ErrTooManyTokens once
+// there are more than maxTokens of them (0 means unlimited)
+func scanTokens(rows *sql.Rows, maxTokens int) ([]models.TokenInfo, error) {
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
 			// Log the error but don't return it since we're in a defer
@@ -120,6 +219,9 @@ func (d *Database) GetAllTokens() ([]models.TokenInfo, error) {
 
 	var tokens []models.TokenInfo
 	for rows.Next() {
+		if maxTokens > 0 && len(tokens) >= maxTokens {
+			return nil, fmt.Errorf("%w: more than %d local tokens", models.ErrTooManyTokens, maxTokens)
+		}
 		var token models.TokenInfo
 		err := rows.Scan(
 			&token.TokenAddress, &token.ChainID, &token.ProjectName,
@@ -129,7 +231,7 @@ func (d *Database) GetAllTokens() ([]models.TokenInfo, error) {
 			&token.Slack, &token.Twitter, &token.OpenSea, &token.Facebook,
 			&token.Medium, &token.Reddit, &token.Support, &token.CoinMarketCapTicker,
 			&token.CoinGeckoTicker, &token.DefiLlamaTicker, &token.TokenName,
-			&token.TokenSymbol,
+			&token.TokenSymbol, &token.DeletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan token: %w", err)
@@ -150,13 +252,16 @@ func (d *Database) GetAllTokens() ([]models.TokenInfo, error) {
 // UpsertTokenInfo creates or updates token information using PostgreSQL upsert
 // Manually sets updated_at timestamp instead of relying on database triggers
 // If onIconURLUpdate callback is provided, it will be called when icon_url is updated
-func (d *Database) UpsertTokenInfo(form *models.TokenInfoForm, onIconURLUpdate func(tokenAddress, iconURL string) error) error {
+func (d *Database) UpsertTokenInfo(ctx context.Context, form *models.TokenInfoForm, onIconURLUpdate func(tokenAddress, iconURL string) error) error {
+	// Addresses are stored lowercase; the database enforces it too
+	form.TokenAddress = strings.ToLower(form.TokenAddress)
+
 	// Get current icon_url before update to check if it changed
 	var currentIconURL sql.NullString
 	query := `
 		SELECT icon_url FROM token_infos WHERE token_address = $1 AND chain_id = $2
 	`
-	err := d.db.QueryRow(query, form.TokenAddress, form.ChainID).Scan(&currentIconURL)
+	err := d.db.QueryRowContext(ctx, query, form.TokenAddress, form.ChainID).Scan(&currentIconURL)
 	if err != nil && err != sql.ErrNoRows {
 		return fmt.Errorf("failed to get current icon_url: %w", err)
 	}
@@ -198,10 +303,11 @@ func (d *Database) UpsertTokenInfo(form *models.TokenInfoForm, onIconURLUpdate f
 			defi_llama_ticker = EXCLUDED.defi_llama_ticker,
 			token_name = EXCLUDED.token_name,
 			token_symbol = EXCLUDED.token_symbol,
-			updated_at = CURRENT_TIMESTAMP
+			updated_at = CURRENT_TIMESTAMP,
+			deleted_at = NULL
 	`
 
-	_, err = d.db.Exec(upsertQuery,
+	_, err = d.db.ExecContext(ctx, upsertQuery,
 		form.TokenAddress, form.ChainID, form.ProjectName, form.ProjectWebsite,
 		form.ProjectEmail, form.IconURL, form.ProjectDescription, form.ProjectSector,
 		form.Docs, form.Github, form.Telegram, form.Linkedin, form.Discord,
@@ -209,6 +315,7 @@ func (d *Database) UpsertTokenInfo(form *models.TokenInfoForm, onIconURLUpdate f
 		form.Reddit, form.Support, form.CoinMarketCapTicker, form.CoinGeckoTicker,
 		form.DefiLlamaTicker, form.TokenName, form.TokenSymbol,
 	)
+	d.tokens.invalidate()
 
 	if err != nil {
 		return fmt.Errorf("failed to upsert token info: %w", err)
@@ -232,11 +339,72 @@ func (d *Database) UpsertTokenInfo(form *models.TokenInfoForm, onIconURLUpdate f
 	return nil
 }
 
+// DeleteTokenInfo removes the local token information. A soft delete only sets deleted_at so
+// the token can be restored; a hard delete removes the row
+// Returns false when there is no (not yet deleted) token to delete
+func (d *Database) DeleteTokenInfo(ctx context.Context, tokenAddress, chainID string, soft bool) (bool, error) {
+	query := `DELETE FROM token_infos WHERE token_address = $1 AND chain_id = $2`
+	if soft {
+		query = `
+			UPDATE token_infos SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE token_address = $1 AND chain_id = $2 AND deleted_at IS NULL
+		`
+	}
+
+	result, err := d.db.ExecContext(ctx, query, tokenAddress, chainID)
+	d.tokens.invalidate()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete token info: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		log.Printf("Deleted token: %s on chain %s (soft: %t)", tokenAddress, chainID, soft)
+	}
+	return rowsAffected > 0, nil
+}
+
+// RestoreTokenInfo clears deleted_at on a soft-deleted token
+// Returns false when there is no soft-deleted token to restore
+func (d *Database) RestoreTokenInfo(ctx context.Context, tokenAddress, chainID string) (bool, error) {
+	query := `
+		UPDATE token_infos SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE token_address = $1 AND chain_id = $2 AND deleted_at IS NOT NULL
+	`
+
+	result, err := d.db.ExecContext(ctx, query, tokenAddress, chainID)
+	d.tokens.invalidate()
+	if err != nil {
+		return false, fmt.Errorf("failed to restore token info: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		log.Printf("Restored token: %s on chain %s", tokenAddress, chainID)
+	}
+	return rowsAffected > 0, nil
+}
+
 // GetUnifiedTokens retrieves all tokens with merged data from both local and Blockscout databases
 // This method requires a callback to fetch Blockscout data since the database package shouldn't directly access Blockscout
-func (d *Database) GetUnifiedTokens(chainID string, getBlockscoutTokens func() ([]client.BlockscoutToken, error)) ([]models.UnifiedTokenInfo, error) {
-	// Get all local tokens
-	localTokens, err := d.GetAllTokens()
+// Soft-deleted local data is left out unless includeDeleted is set. A non-empty addresses
+// limits the result to those tokens, and getBlockscoutTokens is then expected to fetch only them
+func (d *Database) GetUnifiedTokens(ctx context.Context, chainID string, includeDeleted bool, addresses []string, getBlockscoutTokens func() ([]client.BlockscoutToken, error)) ([]models.UnifiedTokenInfo, error) {
+	// Get the local tokens, only the requested ones when filtering by address so the
+	// full list (and its maxTokensInMemory limit) is never loaded
+	var localTokens []models.TokenInfo
+	var err error
+	if len(addresses) > 0 {
+		localTokens, err = d.getTokensByAddresses(ctx, addresses, includeDeleted)
+	} else {
+		localTokens, err = d.GetAllTokens(ctx, includeDeleted)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get local tokens: %w", err)
 	}
@@ -289,6 +457,7 @@ func (d *Database) GetUnifiedTokens(chainID string, getBlockscoutTokens func() (
 			DefiLlamaTicker:     localToken.DefiLlamaTicker,
 			TokenName:           localToken.TokenName,
 			TokenSymbol:         localToken.TokenSymbol,
+			DeletedAt:           localToken.DeletedAt,
 			HasLocalData:        true,
 			HasBlockscoutData:   false,
 		}
@@ -346,9 +515,10 @@ func (d *Database) GetUnifiedTokens(chainID string, getBlockscoutTokens func() (
 }
 
 // GetUnifiedTokenByAddress retrieves a single token with merged data from both local and Blockscout databases
-func (d *Database) GetUnifiedTokenByAddress(tokenAddress, chainID string, getBlockscoutToken func(address string) (*client.BlockscoutToken, error)) (*models.UnifiedTokenInfo, error) {
+// Soft-deleted local data is left out unless includeDeleted is set
+func (d *Database) GetUnifiedTokenByAddress(ctx context.Context, tokenAddress, chainID string, includeDeleted bool, getBlockscoutToken func(address string) (*client.BlockscoutToken, error)) (*models.UnifiedTokenInfo, error) {
 	// Get local token
-	localToken, err := d.GetTokenInfo(tokenAddress, chainID)
+	localToken, err := d.GetTokenInfo(ctx, tokenAddress, chainID, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get local token: %w", err)
 	}
@@ -392,6 +562,7 @@ func (d *Database) GetUnifiedTokenByAddress(tokenAddress, chainID string, getBlo
 		unified.DefiLlamaTicker = localToken.DefiLlamaTicker
 		unified.TokenName = localToken.TokenName
 		unified.TokenSymbol = localToken.TokenSymbol
+		unified.DeletedAt = localToken.DeletedAt
 	}
 
 	// Fill in Blockscout data if available (merge into main fields)
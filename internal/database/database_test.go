@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"blockscout-vc/internal/client"
+
+	"github.com/spf13/viper"
+)
+
+// recordingDriver records every statement it receives, prefixed with the data source
+// name of the pool that ran it, and answers each with no rows
+type recordingDriver struct {
+	mux     sync.Mutex
+	queries []string
+	args    [][]driver.NamedValue
+}
+
+func (d *recordingDriver) Open(dsn string) (driver.Conn, error) { return &recordingConn{d, dsn}, nil }
+
+func (d *recordingDriver) record(dsn, query string, args []driver.NamedValue) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.queries = append(d.queries, dsn+":"+query)
+	d.args = append(d.args, args)
+}
+
+type recordingConn struct {
+	d   *recordingDriver
+	dsn string
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                        { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.record(c.dsn, query, args)
+	return emptyRows{}, nil
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.record(c.dsn, query, args)
+	return driver.RowsAffected(0), nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+var registerOnce sync.Once
+var recorder = &recordingDriver{}
+
+// openRecording opens a pool of the recording driver whose statements are prefixed with dsn
+func openRecording(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register("recording", recorder) })
+	db, err := sql.Open("recording", dsn)
+	if err != nil {
+		t.Fatalf("failed to open recording database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// resetRecorder forgets the statements recorded by earlier tests
+func resetRecorder() {
+	recorder.mux.Lock()
+	recorder.queries, recorder.args = nil, nil
+	recorder.mux.Unlock()
+}
+
+// newRecordingDatabase returns a Database without a replica, as NewDatabase sets it up
+func newRecordingDatabase(t *testing.T) *Database {
+	t.Helper()
+	resetRecorder()
+	db := openRecording(t, "primary")
+	return &Database{db: db, readDB: db}
+}
+
+func TestGetUnifiedTokensFiltersAddressesInSQL(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	// A limit the full listing would trip over must not apply to an address lookup
+	viper.Set("maxTokensInMemory", 1)
+	d := newRecordingDatabase(t)
+
+	noBlockscoutTokens := func() ([]client.BlockscoutToken, error) { return nil, nil }
+	if _, err := d.GetUnifiedTokens(context.Background(), "1", false, []string{"0xABC", "0xdef"}, noBlockscoutTokens); err != nil {
+		t.Fatalf("GetUnifiedTokens: %v", err)
+	}
+
+	if len(recorder.queries) != 1 {
+		t.Fatalf("ran %d queries, want 1", len(recorder.queries))
+	}
+	if !strings.Contains(recorder.queries[0], "lower(token_address) = ANY($1)") {
+		t.Errorf("query does not filter by address:\n%s", recorder.queries[0])
+	}
+	if got, want := recorder.args[0][0].Value, `{"0xabc","0xdef"}`; got != want {
+		t.Errorf("addresses argument = %v, want %v", got, want)
+	}
+}
+
+func TestGetUnifiedTokensWithoutAddressesListsAll(t *testing.T) {
+	d := newRecordingDatabase(t)
+
+	noBlockscoutTokens := func() ([]client.BlockscoutToken, error) { return nil, nil }
+	if _, err := d.GetUnifiedTokens(context.Background(), "1", false, nil, noBlockscoutTokens); err != nil {
+		t.Fatalf("GetUnifiedTokens: %v", err)
+	}
+
+	if len(recorder.queries) != 1 || strings.Contains(recorder.queries[0], "ANY(") {
+		t.Errorf("queries = %q, want the unfiltered token listing", recorder.queries)
+	}
+}
+
+func TestReadsUseReplicaAndWritesUsePrimary(t *testing.T) {
+	resetRecorder()
+	d := &Database{db: openRecording(t, "primary"), readDB: openRecording(t, "replica")}
+	ctx := context.Background()
+
+	if _, err := d.GetAllTokens(ctx, false); err != nil {
+		t.Fatalf("GetAllTokens: %v", err)
+	}
+	if _, err := d.GetTokenInfo(ctx, "0xabc", "1", false); err != nil {
+		t.Fatalf("GetTokenInfo: %v", err)
+	}
+	if _, err := d.DeleteTokenInfo(ctx, "0xabc", "1", true); err != nil {
+		t.Fatalf("DeleteTokenInfo: %v", err)
+	}
+
+	if len(recorder.queries) != 3 {
+		t.Fatalf("ran %d statements, want 3: %q", len(recorder.queries), recorder.queries)
+	}
+	for i, wantPool := range []string{"replica:", "replica:", "primary:"} {
+		if !strings.HasPrefix(recorder.queries[i], wantPool) {
+			t.Errorf("statement %d ran on %q, want %s", i, recorder.queries[i], strings.TrimSuffix(wantPool, ":"))
+		}
+	}
+}
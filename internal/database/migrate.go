@@ -41,6 +41,18 @@ func runMigrations(db *sql.DB) error {
 	return goose.Up(db, "migrations")
 }
 
+// MigrationVersion returns the version of the latest migration applied to the sidecar database
+func (d *Database) MigrationVersion() (int64, error) {
+	if err := goose.SetDialect("postgres"); err != nil {
+		return 0, err
+	}
+	version, err := goose.GetDBVersion(d.db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get migration version: %w", err)
+	}
+	return version, nil
+}
+
 // createDatabaseIfNotExists creates the database if it doesn't exist
 // Uses net/url for robust URL parsing instead of brittle string splitting
 func createDatabaseIfNotExists(dbURL string) error {
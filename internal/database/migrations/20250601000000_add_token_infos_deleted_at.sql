@@ -0,0 +1,6 @@
+-- +goose Up
+-- Soft-deleted tokens keep their row with deleted_at set so they can be restored
+ALTER TABLE token_infos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE token_infos DROP COLUMN IF EXISTS deleted_at;
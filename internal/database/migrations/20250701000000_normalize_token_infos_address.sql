@@ -0,0 +1,41 @@
+-- +goose Up
+-- Token addresses are stored lowercase so 0xABC and 0xabc can't both exist,
+-- whichever path writes the row (API, import or direct SQL)
+
+-- Collapse existing case variants, keeping the most recently updated row
+DELETE FROM token_infos
+WHERE (token_address, chain_id) IN (
+    SELECT token_address, chain_id FROM (
+        SELECT token_address, chain_id,
+               ROW_NUMBER() OVER (
+                   PARTITION BY lower(token_address), chain_id
+                   ORDER BY updated_at DESC NULLS LAST, (token_address = lower(token_address)) DESC, token_address
+               ) AS rank
+        FROM token_infos
+    ) ranked
+    WHERE rank > 1
+);
+
+UPDATE token_infos SET token_address = lower(token_address) WHERE token_address <> lower(token_address);
+
+-- Lowercase on write; BEFORE INSERT runs ahead of ON CONFLICT, so upserts of any case hit the same row
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION token_infos_lower_address() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.token_address := lower(NEW.token_address);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER token_infos_lower_address
+    BEFORE INSERT OR UPDATE OF token_address ON token_infos
+    FOR EACH ROW EXECUTE FUNCTION token_infos_lower_address();
+
+ALTER TABLE token_infos
+    ADD CONSTRAINT token_infos_token_address_lower CHECK (token_address = lower(token_address));
+
+-- +goose Down
+ALTER TABLE token_infos DROP CONSTRAINT IF EXISTS token_infos_token_address_lower;
+DROP TRIGGER IF EXISTS token_infos_lower_address ON token_infos;
+DROP FUNCTION IF EXISTS token_infos_lower_address();
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"blockscout-vc/internal/models"
+)
+
+func TestUpsertTokenInfoCollapsesAddressCaseVariants(t *testing.T) {
+	ctx := context.Background()
+	d := newTokenDatabase(t)
+
+	for _, address := range []string{"0xABCdef", "0xabcDEF", "0xabcdef"} {
+		form := &models.TokenInfoForm{TokenAddress: address, ChainID: "1", ProjectName: "Aurora"}
+		if err := d.UpsertTokenInfo(ctx, form, nil); err != nil {
+			t.Fatalf("UpsertTokenInfo(%s): %v", address, err)
+		}
+	}
+
+	tokens, err := d.GetAllTokens(ctx, true)
+	if err != nil {
+		t.Fatalf("GetAllTokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].TokenAddress != "0xabcdef" {
+		t.Errorf("GetAllTokens() = %+v, want a single row for 0xabcdef", tokens)
+	}
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenTable is an in-memory token_infos table for chain 1 serving the token lookup,
+// listing, upsert, delete and restore statements. Like Postgres, it compares addresses
+// case-sensitively
+type tokenTable struct {
+	mux     sync.Mutex
+	deleted map[string]*time.Time // token address -> deleted_at
+}
+
+func (t *tokenTable) Connect(context.Context) (driver.Conn, error) { return tokenConn{t}, nil }
+func (t *tokenTable) Driver() driver.Driver                        { return nil }
+
+type tokenConn struct{ t *tokenTable }
+
+func (c tokenConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c tokenConn) Close() error                        { return nil }
+func (c tokenConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c tokenConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.t.mux.Lock()
+	defer c.t.mux.Unlock()
+	address := args[0].Value.(string)
+	deletedAt, exists := c.t.deleted[address]
+
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(query), "INSERT"):
+		// The upsert inserts or updates the row and clears deleted_at
+		c.t.deleted[address] = nil
+	case strings.HasPrefix(strings.TrimSpace(query), "DELETE"):
+		if !exists {
+			return driver.RowsAffected(0), nil
+		}
+		delete(c.t.deleted, address)
+	case strings.Contains(query, "SET deleted_at = CURRENT_TIMESTAMP"):
+		if !exists || deletedAt != nil {
+			return driver.RowsAffected(0), nil
+		}
+		now := time.Now()
+		c.t.deleted[address] = &now
+	case strings.Contains(query, "SET deleted_at = NULL"):
+		if !exists || deletedAt == nil {
+			return driver.RowsAffected(0), nil
+		}
+		c.t.deleted[address] = nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c tokenConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.t.mux.Lock()
+	defer c.t.mux.Unlock()
+
+	// The upsert reads the current icon_url first
+	if strings.Contains(query, "SELECT icon_url") {
+		rows := &tokenRows{columns: 1}
+		if _, exists := c.t.deleted[args[0].Value.(string)]; exists {
+			rows.values = [][]driver.Value{{""}}
+		}
+		return rows, nil
+	}
+
+	// GetTokenInfo passes the address, chain and includeDeleted; the listing only includeDeleted
+	var address string
+	var includeDeleted bool
+	if len(args) == 3 {
+		address, includeDeleted = args[0].Value.(string), args[2].Value.(bool)
+	} else {
+		includeDeleted = args[0].Value.(bool)
+	}
+	rows := &tokenRows{columns: 26}
+	for tokenAddress, deletedAt := range c.t.deleted {
+		if (address != "" && tokenAddress != address) || (deletedAt != nil && !includeDeleted) {
+			continue
+		}
+		values := make([]driver.Value, 26)
+		for i := range values {
+			values[i] = ""
+		}
+		values[0], values[1] = tokenAddress, "1"
+		values[25] = nil
+		if deletedAt != nil {
+			values[25] = *deletedAt
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+type tokenRows struct {
+	columns int
+	values  [][]driver.Value
+}
+
+func (r *tokenRows) Columns() []string { return make([]string, r.columns) }
+func (r *tokenRows) Close() error      { return nil }
+func (r *tokenRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newTokenDatabase returns a Database over an in-memory table holding the given tokens
+func newTokenDatabase(t *testing.T, addresses ...string) *Database {
+	t.Helper()
+	table := &tokenTable{deleted: make(map[string]*time.Time)}
+	for _, address := range addresses {
+		table.deleted[address] = nil
+	}
+	db := sql.OpenDB(table)
+	t.Cleanup(func() { db.Close() })
+	return &Database{db: db, readDB: db}
+}
+
+func TestSoftDeleteHidesTokenUntilRestored(t *testing.T) {
+	ctx := context.Background()
+	d := newTokenDatabase(t, "0xaaa", "0xbbb")
+
+	if deleted, err := d.DeleteTokenInfo(ctx, "0xaaa", "1", true); err != nil || !deleted {
+		t.Fatalf("DeleteTokenInfo() = %t, %v, want the token soft-deleted", deleted, err)
+	}
+
+	if token, err := d.GetTokenInfo(ctx, "0xaaa", "1", false); err != nil || token != nil {
+		t.Errorf("GetTokenInfo() = %+v, %v, want the soft-deleted token hidden", token, err)
+	}
+	token, err := d.GetTokenInfo(ctx, "0xaaa", "1", true)
+	if err != nil || token == nil || token.DeletedAt == nil {
+		t.Errorf("GetTokenInfo(includeDeleted) = %+v, %v, want the token with deleted_at", token, err)
+	}
+	if tokens, err := d.GetAllTokens(ctx, false); err != nil || len(tokens) != 1 || tokens[0].TokenAddress != "0xbbb" {
+		t.Errorf("GetAllTokens() = %+v, %v, want only the remaining token", tokens, err)
+	}
+	if tokens, err := d.GetAllTokens(ctx, true); err != nil || len(tokens) != 2 {
+		t.Errorf("GetAllTokens(includeDeleted) = %d tokens, %v, want both", len(tokens), err)
+	}
+
+	// Deleting again finds nothing to delete
+	if deleted, _ := d.DeleteTokenInfo(ctx, "0xaaa", "1", true); deleted {
+		t.Error("a soft-deleted token was deleted twice")
+	}
+
+	if restored, err := d.RestoreTokenInfo(ctx, "0xaaa", "1"); err != nil || !restored {
+		t.Fatalf("RestoreTokenInfo() = %t, %v, want the token restored", restored, err)
+	}
+	if token, err := d.GetTokenInfo(ctx, "0xaaa", "1", false); err != nil || token == nil || token.DeletedAt != nil {
+		t.Errorf("GetTokenInfo() after restore = %+v, %v, want the token back", token, err)
+	}
+	if restored, _ := d.RestoreTokenInfo(ctx, "0xbbb", "1"); restored {
+		t.Error("a token that was never deleted was restored")
+	}
+}
+
+func TestHardDeleteCannotBeRestored(t *testing.T) {
+	ctx := context.Background()
+	d := newTokenDatabase(t, "0xaaa")
+
+	if deleted, err := d.DeleteTokenInfo(ctx, "0xaaa", "1", false); err != nil || !deleted {
+		t.Fatalf("DeleteTokenInfo() = %t, %v, want the token deleted", deleted, err)
+	}
+	if token, _ := d.GetTokenInfo(ctx, "0xaaa", "1", true); token != nil {
+		t.Errorf("hard-deleted token still found: %+v", token)
+	}
+	if restored, _ := d.RestoreTokenInfo(ctx, "0xaaa", "1"); restored {
+		t.Error("a hard-deleted token was restored")
+	}
+}
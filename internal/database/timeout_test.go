@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// hangingDB answers no query until the query's context ends, like a database under load
+type hangingDB struct{}
+
+func (hangingDB) Connect(context.Context) (driver.Conn, error) { return hangingConn{}, nil }
+func (hangingDB) Driver() driver.Driver                        { return nil }
+
+type hangingConn struct{}
+
+func (hangingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (hangingConn) Close() error                        { return nil }
+func (hangingConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (hangingConn) QueryContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGetTokenInfoStopsAtContextDeadline(t *testing.T) {
+	db := sql.OpenDB(hangingDB{})
+	t.Cleanup(func() { db.Close() })
+	d := &Database{db: db, readDB: db}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := d.GetTokenInfo(ctx, "0xabc", "1", false)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetTokenInfo returned after %s, want it to stop near 50ms", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetTokenInfo() = %v, want context.DeadlineExceeded", err)
+	}
+}
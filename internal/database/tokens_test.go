@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"blockscout-vc/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+// generatedTokens serves a token listing of n generated rows
+type generatedTokens struct{ n int }
+
+func (g generatedTokens) Connect(context.Context) (driver.Conn, error) { return generatedConn(g), nil }
+func (g generatedTokens) Driver() driver.Driver                        { return nil }
+
+type generatedConn generatedTokens
+
+func (c generatedConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c generatedConn) Close() error                        { return nil }
+func (c generatedConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c generatedConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &generatedRows{n: c.n}, nil
+}
+
+type generatedRows struct{ i, n int }
+
+func (r *generatedRows) Columns() []string { return make([]string, 26) }
+func (r *generatedRows) Close() error      { return nil }
+func (r *generatedRows) Next(dest []driver.Value) error {
+	if r.i == r.n {
+		return io.EOF
+	}
+	for i := range dest {
+		dest[i] = ""
+	}
+	dest[0] = fmt.Sprintf("0x%040x", r.i)
+	dest[len(dest)-1] = nil // deleted_at
+	r.i++
+	return nil
+}
+
+func TestGetAllTokensCapsTokensInMemory(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int
+		maxTokens int
+		wantErr   error
+	}{
+		{"unlimited", 10000, 0, nil},
+		{"at the cap", 1000, 1000, nil},
+		{"over the cap", 10000, 1000, models.ErrTooManyTokens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("maxTokensInMemory", tt.maxTokens)
+			db := sql.OpenDB(generatedTokens{tt.rows})
+			t.Cleanup(func() { db.Close() })
+			d := &Database{db: db, readDB: db}
+
+			tokens, err := d.GetAllTokens(context.Background(), false)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && len(tokens) != tt.rows {
+				t.Errorf("loaded %d tokens, want %d", len(tokens), tt.rows)
+			}
+			if tt.wantErr != nil && tokens != nil {
+				t.Errorf("loaded %d tokens past the cap", len(tokens))
+			}
+		})
+	}
+}
@@ -1,18 +1,33 @@
 package docker
 
 import (
+	"blockscout-vc/internal/config"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"os/exec"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 type Docker struct {
 	ContainerName       string
 	PathToDockerCompose string
 	ComposeFile         map[string]interface{}
+	Output              io.Writer // Where recreation output is streamed; os.Stdout when nil
+
+	lastLog    *LogBuffer // Output of the most recently started recreation, see LastLog
+	lastLogMux sync.Mutex // Protects lastLog, which concurrent recreations replace
 }
 
 func NewDocker() *Docker {
@@ -21,91 +36,416 @@ func NewDocker() *Docker {
 	}
 }
 
+// recreationOutput starts a log for one recreation, publishes it as the last log and
+// returns a writer streaming to both Output and that log. Each recreation writes to its
+// own buffer, so concurrent jobs never wipe or interleave each other's output
+func (d *Docker) recreationOutput() io.Writer {
+	var out io.Writer = os.Stdout
+	if d.Output != nil {
+		out = d.Output
+	}
+	jobLog := NewLogBuffer(DefaultLogLines)
+
+	d.lastLogMux.Lock()
+	d.lastLog = jobLog
+	d.lastLogMux.Unlock()
+	return io.MultiWriter(out, jobLog)
+}
+
+// LastLog returns the buffered output of the most recently started recreation
+func (d *Docker) LastLog() []string {
+	d.lastLogMux.Lock()
+	jobLog := d.lastLog
+	d.lastLogMux.Unlock()
+
+	if jobLog == nil {
+		return []string{}
+	}
+	return jobLog.Lines()
+}
+
 type Container struct {
-	Name        string
-	ServiceName string
+	Name        string `json:"name"`
+	ServiceName string `json:"serviceName"`
+	ChainID     int    `json:"chainId"` // Chain whose compose project the container belongs to; 0 means the configured chain
+}
+
+// ConfiguredContainers returns every container/service pair configured for the sidecar
+// Pairs with an empty container or service name (e.g. no proxy) are skipped
+func ConfiguredContainers() []Container {
+	keys := []struct {
+		container string
+		service   string
+	}{
+		{container: "frontendContainerName", service: "frontendServiceName"},
+		{container: "backendContainerName", service: "backendServiceName"},
+		{container: "statsContainerName", service: "statsServiceName"},
+		{container: "proxyContainerName", service: "proxyServiceName"},
+	}
+
+	containers := []Container{}
+	for _, key := range keys {
+		name := viper.GetString(key.container)
+		serviceName := viper.GetString(key.service)
+		if name == "" || serviceName == "" {
+			continue
+		}
+		containers = append(containers, Container{Name: name, ServiceName: serviceName, ChainID: viper.GetInt("chainId")})
+	}
+	return containers
+}
+
+// ChainContainers returns every container/service pair configured for a chain,
+// honoring the per-chain overrides under chains.<chainId>
+func ChainContainers(chainID int) []Container {
+	keys := []struct {
+		container string
+		service   string
+	}{
+		{container: "frontendContainerName", service: "frontendServiceName"},
+		{container: "backendContainerName", service: "backendServiceName"},
+		{container: "statsContainerName", service: "statsServiceName"},
+		{container: "proxyContainerName", service: "proxyServiceName"},
+	}
+
+	containers := []Container{}
+	for _, key := range keys {
+		name := config.GetChainString(chainID, key.container)
+		serviceName := config.GetChainString(chainID, key.service)
+		if name == "" || serviceName == "" {
+			continue
+		}
+		containers = append(containers, Container{Name: name, ServiceName: serviceName, ChainID: chainID})
+	}
+	return containers
+}
+
+// RecreateError reports the services that failed to come up after recreation
+// Services not listed in FailedServices were recreated successfully
+type RecreateError struct {
+	FailedServices []string
+	Err            error
+}
+
+func (e *RecreateError) Error() string {
+	return fmt.Sprintf("failed to recreate services %v: %v", e.FailedServices, e.Err)
+}
+
+func (e *RecreateError) Unwrap() error {
+	return e.Err
 }
 
 // RecreateContainers stops, removes and recreates specified containers
-// It uses docker-compose to handle the container lifecycle
+// It uses docker-compose to handle the container lifecycle, targeting the compose
+// project of each container's chain (see config.GetProjectName)
+// If compose only partially succeeds, the failed services are retried once
+// and a *RecreateError listing the services still down is returned
 func (d *Docker) RecreateContainers(containers []Container) error {
-	pathToDockerCompose := viper.GetString("pathToDockerCompose")
-	projectName := viper.GetString("projectName")
-	uniqueContainers := d.UniqueContainers(containers)
+	out := d.recreationOutput()
 
 	dockerPath, err := exec.LookPath("docker")
 	if err != nil {
 		return fmt.Errorf("docker executable not found: %w", err)
 	}
+	if err := config.CheckComposeFile(); err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		return err
+	}
+
+	// Group containers by chain so each compose call targets a single project
+	byChain := make(map[int][]Container)
+	for _, container := range containers {
+		chainID := container.ChainID
+		if chainID == 0 {
+			chainID = viper.GetInt("chainId")
+		}
+		byChain[chainID] = append(byChain[chainID], container)
+	}
+	chainIDs := make([]int, 0, len(byChain))
+	for chainID := range byChain {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Ints(chainIDs)
+
+	var errs []error
+	for _, chainID := range chainIDs {
+		if err := d.recreateProject(dockerPath, config.GetProjectName(chainID), byChain[chainID], out); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// Compose reports success as soon as containers start; catch services that crash right after
+		if config.GetRecreationVerifyHealth() {
+			if err := d.verifyHealth(d.UniqueContainers(byChain[chainID]), out); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// recreateProject recreates containers belonging to a single compose project
+func (d *Docker) recreateProject(dockerPath, projectName string, containers []Container, out io.Writer) error {
+	uniqueContainers := d.UniqueContainers(containers)
 
-	// Define the sequence of commands to execute
 	containerNames := d.GetContainerNames(uniqueContainers)
 	serviceNames := d.GetServiceNames(uniqueContainers)
-	commands := []struct {
-		args       []string
-		desc       string
-		errMessage string
-	}{
-		{
-			args:       append([]string{"rm", "-f"}, containerNames...),
-			desc:       "Stopping and removing containers",
-			errMessage: "Error stopping and removing containers",
-		},
-		{
-			args: append([]string{"compose",
-				"-f", pathToDockerCompose,
-				"--project-name", projectName,
-				"up", "-d", "--force-recreate", "--remove-orphans", "--no-deps"},
-				serviceNames...),
-			desc:       "Recreating containers",
-			errMessage: "Error recreating containers",
-		},
-	}
-
-	// Execute each command in sequence
-	for _, cmd := range commands {
-		execCmd := exec.Command(dockerPath, cmd.args...)
-		execCmd.Stdout = os.Stdout
-		execCmd.Stderr = os.Stderr
-
-		fmt.Printf("%s: %s\n", cmd.desc, execCmd.String())
-		if err := execCmd.Run(); err != nil {
-			fmt.Printf("%s: %v\n", cmd.errMessage, err)
+
+	// Stop and remove the containers before recreating them
+	// Containers that don't exist yet are fine, compose creates them below
+	rmArgs := append([]string{"rm", "-f"}, containerNames...)
+	fmt.Fprintf(out, "Stopping and removing containers: %s\n", commandLine(dockerPath, rmArgs))
+	var rmOutput bytes.Buffer
+	if _, err := runDocker(dockerPath, rmArgs, io.MultiWriter(out, &rmOutput)); err != nil {
+		if errors.Is(err, ErrCommandTimeout) || !onlyMissingContainers(rmOutput.String()) {
+			fmt.Fprintf(out, "Error stopping and removing containers: %v\n", err)
 			return err
 		}
+		fmt.Fprintln(out, "Some containers did not exist, continuing with recreation")
+	}
+
+	upErr := d.composeUp(dockerPath, projectName, serviceNames, out)
+	if upErr == nil {
+		fmt.Fprintln(out, "Docker containers recreated successfully!")
+		return nil
+	}
+	fmt.Fprintf(out, "Error recreating containers: %v\n", upErr)
+
+	// Compose may have brought up some of the services; find out which ones failed
+	failed, err := d.failedServices(dockerPath, projectName, serviceNames)
+	if err != nil {
+		return &RecreateError{FailedServices: serviceNames, Err: errors.Join(upErr, err)}
+	}
+	if len(failed) == 0 {
+		fmt.Fprintln(out, "Compose reported an error but all services are running")
+		return nil
+	}
+	if len(failed) == len(serviceNames) {
+		return &RecreateError{FailedServices: failed, Err: upErr}
+	}
+
+	// Partial success: retry only the services that did not come up
+	fmt.Fprintf(out, "Partially recreated containers, retrying failed services: %v\n", failed)
+	if retryErr := d.composeUp(dockerPath, projectName, failed, out); retryErr != nil {
+		stillFailed, err := d.failedServices(dockerPath, projectName, failed)
+		if err != nil {
+			return &RecreateError{FailedServices: failed, Err: errors.Join(retryErr, err)}
+		}
+		if len(stillFailed) > 0 {
+			return &RecreateError{FailedServices: stillFailed, Err: retryErr}
+		}
 	}
 
-	fmt.Println("Docker containers recreated successfully!")
+	fmt.Fprintln(out, "Docker containers recreated successfully after retry!")
 	return nil
 }
 
-// UniqueContainerNames returns a sorted list of unique container names
+// onlyMissingContainers reports whether every error in docker rm output is a
+// "No such container" error, meaning the remaining containers were removed
+func onlyMissingContainers(output string) bool {
+	missing := false
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(strings.ToLower(line), "error") {
+			continue
+		}
+		if !strings.Contains(line, "No such container") {
+			return false
+		}
+		missing = true
+	}
+	return missing
+}
+
+// composeArgs returns the common docker compose arguments selecting the compose
+// files and project. The compose override is included in composeOverride output mode
+func (d *Docker) composeArgs(projectName string) []string {
+	args := []string{"compose", "-f", viper.GetString("pathToDockerCompose")}
+	if config.GetOutputMode() == config.OutputModeComposeOverride {
+		args = append(args, "-f", config.GetComposeOverridePath())
+	}
+	return append(args, "--project-name", projectName)
+}
+
+// composeUp force-recreates the given services with docker compose, streaming its output to out
+func (d *Docker) composeUp(dockerPath, projectName string, serviceNames []string, out io.Writer) error {
+	args := append(d.composeArgs(projectName), "up", "-d", "--force-recreate", "--remove-orphans", "--no-deps")
+	args = append(args, serviceNames...)
+
+	fmt.Fprintf(out, "Recreating containers: %s\n", commandLine(dockerPath, args))
+	_, err := runDocker(dockerPath, args, out)
+	return err
+}
+
+// ErrCommandTimeout is returned when a docker command runs longer than dockerCommandTimeout
+var ErrCommandTimeout = errors.New("docker command timed out")
+
+// runDocker runs docker with args, killing its process group once dockerCommandTimeout elapses
+// Output and errors are streamed to out; when out is nil stdout is captured and returned instead
+func runDocker(dockerPath string, args []string, out io.Writer) ([]byte, error) {
+	timeout := config.GetDockerCommandTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, dockerPath, args...)
+	setProcessGroup(cmd)
+	// Don't wait forever on pipes held open by orphaned children after a kill
+	cmd.WaitDelay = 10 * time.Second
+
+	var output []byte
+	var err error
+	if out != nil {
+		cmd.Stdout = out
+		cmd.Stderr = out
+		err = cmd.Run()
+	} else {
+		output, err = cmd.Output()
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("%w after %s: %s", ErrCommandTimeout, timeout, commandLine(dockerPath, args))
+	}
+	return output, err
+}
+
+// commandLine renders a command for logging
+func commandLine(path string, args []string) string {
+	return strings.Join(append([]string{path}, args...), " ")
+}
+
+// failedServices returns the subset of serviceNames that compose does not report as running
+func (d *Docker) failedServices(dockerPath, projectName string, serviceNames []string) ([]string, error) {
+	args := append(d.composeArgs(projectName), "ps", "--services", "--filter", "status=running")
+
+	output, err := runDocker(dockerPath, args, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running services: %w", err)
+	}
+
+	running := make(map[string]struct{})
+	for _, line := range strings.Split(string(output), "\n") {
+		if service := strings.TrimSpace(line); service != "" {
+			running[service] = struct{}{}
+		}
+	}
+
+	failed := []string{}
+	for _, serviceName := range serviceNames {
+		if _, ok := running[serviceName]; !ok {
+			failed = append(failed, serviceName)
+		}
+	}
+	return failed, nil
+}
+
+// LoadComposeFile reads and parses the docker-compose file into ComposeFile
+func (d *Docker) LoadComposeFile() error {
+	data, err := os.ReadFile(d.PathToDockerCompose)
+	if err != nil {
+		return fmt.Errorf("failed to read compose file: %w", err)
+	}
+
+	composeFile := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &composeFile); err != nil {
+		return fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	d.ComposeFile = composeFile
+	return nil
+}
+
+// HasService reports whether the loaded compose file defines the given service
+func (d *Docker) HasService(serviceName string) bool {
+	services, ok := d.ComposeFile["services"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, exists := services[serviceName]
+	return exists
+}
+
+// ContainerKey identifies a container by its name, service and chain, the tuple
+// UniqueContainers deduplicates on, e.g. "frontend-1/frontend/1313161555"
+// Chain 0 stands for the configured chain, so both spellings give the same key
+func ContainerKey(container Container) string {
+	chainID := container.ChainID
+	if chainID == 0 {
+		chainID = viper.GetInt("chainId")
+	}
+	return fmt.Sprintf("%s/%s/%d", container.Name, container.ServiceName, chainID)
+}
+
+// UniqueContainers removes duplicate containers, keeping the first occurrence of each
+// container/service pair so no service is dropped from a recreation. A container name
+// configured for more than one service is a misconfiguration and is logged
 func (d *Docker) UniqueContainers(containers []Container) []Container {
-	unique := make(map[string]Container)
+	seen := make(map[string]struct{})
+	services := make(map[string]string)
+	uniqueContainers := make([]Container, 0, len(containers))
 	for _, container := range containers {
-		unique[container.Name] = container
-	}
-	uniqueContainers := make([]Container, 0, len(unique))
-	for _, container := range unique {
+		key := ContainerKey(container)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		if serviceName, exists := services[container.Name]; exists && serviceName != container.ServiceName {
+			log.Printf("Warning: container %s is configured for services %s and %s, recreating both",
+				container.Name, serviceName, container.ServiceName)
+		}
+		services[container.Name] = container.ServiceName
 		uniqueContainers = append(uniqueContainers, container)
 	}
 	return uniqueContainers
 }
 
+// GetContainerNames returns the sorted, unique container names
 func (d *Docker) GetContainerNames(containers []Container) []string {
 	names := make([]string, 0, len(containers))
 	for _, container := range containers {
 		names = append(names, container.Name)
 	}
 	sort.Strings(names)
-	return names
+	return slices.Compact(names)
+}
+
+// GetContainerKeys returns the sorted, deduplicated ContainerKey of every container
+func (d *Docker) GetContainerKeys(containers []Container) []string {
+	keys := make([]string, 0, len(containers))
+	for _, container := range containers {
+		keys = append(keys, ContainerKey(container))
+	}
+	sort.Strings(keys)
+	return slices.Compact(keys)
 }
 
+// GetServiceNames returns the service names in restart order: services listed in
+// restartOrder come first in that order, followed by the others alphabetically
 func (d *Docker) GetServiceNames(containers []Container) []string {
 	names := make([]string, 0, len(containers))
 	for _, container := range containers {
-		names = append(names, container.ServiceName)
+		if !slices.Contains(names, container.ServiceName) {
+			names = append(names, container.ServiceName)
+		}
 	}
-	sort.Strings(names)
+
+	priority := make(map[string]int)
+	for i, serviceName := range config.GetRestartOrder() {
+		if _, exists := priority[serviceName]; !exists {
+			priority[serviceName] = i
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		pi, iListed := priority[names[i]]
+		pj, jListed := priority[names[j]]
+		switch {
+		case iListed && jListed:
+			return pi < pj
+		case iListed != jListed:
+			return iListed
+		default:
+			return names[i] < names[j]
+		}
+	})
 	return names
 }
@@ -0,0 +1,299 @@
+package docker
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// stubDocker writes a fake docker binary that records its arguments to calls.log. Every
+// "compose up" fails; "compose ps" reports the running services, so the services missing
+// from running are the ones that failed. With retrySucceeds the second "up" passes instead
+func stubDocker(t *testing.T, running string, retrySucceeds bool) (dockerPath, callsFile string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the docker stub is a shell script")
+	}
+	dir := t.TempDir()
+	callsFile = filepath.Join(dir, "calls.log")
+	succeed := "false"
+	if retrySucceeds {
+		succeed = "true"
+	}
+	script := `#!/bin/sh
+echo "$*" >> "` + callsFile + `"
+case "$*" in
+  *" ps "*) printf '` + running + `' ;;
+  *" up "*)
+    if [ "` + succeed + `" = true ] && [ "$(grep -c ' up ' "` + callsFile + `")" -gt 1 ]; then exit 0; fi
+    echo "service failed to start" >&2; exit 1 ;;
+esac
+`
+	dockerPath = filepath.Join(dir, "docker")
+	if err := os.WriteFile(dockerPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dockerPath, callsFile
+}
+
+// upCalls returns the services passed to each recorded "compose up"
+func upCalls(t *testing.T, callsFile string) [][]string {
+	t.Helper()
+	content, err := os.ReadFile(callsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		if _, services, ok := strings.Cut(line, "--no-deps "); ok {
+			calls = append(calls, strings.Fields(services))
+		}
+	}
+	return calls
+}
+
+var threeContainers = []Container{
+	{Name: "frontend-1", ServiceName: "frontend"},
+	{Name: "backend-1", ServiceName: "backend"},
+	{Name: "stats-1", ServiceName: "stats"},
+}
+
+func TestRecreateProjectReportsPartialFailure(t *testing.T) {
+	dockerPath, callsFile := stubDocker(t, `frontend\nbackend\n`, false)
+
+	var out bytes.Buffer
+	err := (&Docker{}).recreateProject(dockerPath, "blockscout", threeContainers, &out)
+
+	var recreateErr *RecreateError
+	if !errors.As(err, &recreateErr) {
+		t.Fatalf("recreateProject() = %v, want a *RecreateError", err)
+	}
+	if !reflect.DeepEqual(recreateErr.FailedServices, []string{"stats"}) {
+		t.Errorf("FailedServices = %v, want [stats]", recreateErr.FailedServices)
+	}
+
+	// The retry targets only the failed service
+	want := [][]string{{"backend", "frontend", "stats"}, {"stats"}}
+	if got := upCalls(t, callsFile); !reflect.DeepEqual(got, want) {
+		t.Errorf("compose up calls = %v, want %v", got, want)
+	}
+}
+
+func TestRecreateProjectRetrySucceeds(t *testing.T) {
+	dockerPath, callsFile := stubDocker(t, `frontend\nbackend\n`, true)
+
+	var out bytes.Buffer
+	if err := (&Docker{}).recreateProject(dockerPath, "blockscout", threeContainers, &out); err != nil {
+		t.Fatalf("recreateProject: %v", err)
+	}
+	if got := upCalls(t, callsFile); len(got) != 2 || !reflect.DeepEqual(got[1], []string{"stats"}) {
+		t.Errorf("compose up calls = %v, want a retry of [stats] only", got)
+	}
+}
+
+func TestRecreateProjectAllServicesFailed(t *testing.T) {
+	dockerPath, callsFile := stubDocker(t, ``, true)
+
+	var out bytes.Buffer
+	err := (&Docker{}).recreateProject(dockerPath, "blockscout", threeContainers, &out)
+
+	var recreateErr *RecreateError
+	if !errors.As(err, &recreateErr) || len(recreateErr.FailedServices) != 3 {
+		t.Fatalf("recreateProject() = %v, want every service reported failed", err)
+	}
+	// Nothing came up, so there is no partial success to retry
+	if got := upCalls(t, callsFile); len(got) != 1 {
+		t.Errorf("compose up calls = %v, want no retry", got)
+	}
+}
+
+func TestRecreateContainersUsesPerChainProjectName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the docker stub is a shell script")
+	}
+	dir := t.TempDir()
+	callsFile := filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\necho \"$*\" >> \"" + callsFile + "\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	composeFile := filepath.Join(dir, "docker-compose.yaml")
+	if err := os.WriteFile(composeFile, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Cleanup(viper.Reset)
+	viper.Set("pathToDockerCompose", composeFile)
+	viper.Set("projectName", "blockscout")
+	viper.Set("projectNameTemplate", "blockscout-{chainId}")
+
+	err := (&Docker{}).RecreateContainers([]Container{
+		{Name: "frontend-2", ServiceName: "frontend", ChainID: 2},
+		{Name: "frontend-1", ServiceName: "frontend", ChainID: 1},
+	})
+	if err != nil {
+		t.Fatalf("RecreateContainers: %v", err)
+	}
+
+	content, err := os.ReadFile(callsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var projects []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if _, rest, ok := strings.Cut(line, "--project-name "); ok && strings.Contains(rest, " up ") {
+			projects = append(projects, strings.Fields(rest)[0])
+		}
+	}
+	if want := []string{"blockscout-1", "blockscout-2"}; !reflect.DeepEqual(projects, want) {
+		t.Errorf("compose up projects = %v, want %v", projects, want)
+	}
+}
+
+func TestRecreateContainersChecksComposeFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the docker stub is a shell script")
+	}
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, dir string) string
+		wantError string
+	}{
+		{
+			name:      "missing path",
+			setup:     func(t *testing.T, dir string) string { return filepath.Join(dir, "docker-compose.yaml") },
+			wantError: "compose file not found at %s",
+		},
+		{
+			name:      "directory",
+			setup:     func(t *testing.T, dir string) string { return dir },
+			wantError: "compose file at %s is a directory",
+		},
+		{
+			name: "unreadable file",
+			setup: func(t *testing.T, dir string) string {
+				if os.Geteuid() == 0 {
+					t.Skip("root can read files without read permission")
+				}
+				path := filepath.Join(dir, "docker-compose.yaml")
+				if err := os.WriteFile(path, []byte("services: {}\n"), 0); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+			wantError: "compose file at %s is not readable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			callsFile := filepath.Join(dir, "calls.log")
+			script := "#!/bin/sh\necho \"$*\" >> \"" + callsFile + "\"\n"
+			if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", dir)
+			t.Cleanup(viper.Reset)
+			path := tt.setup(t, t.TempDir())
+			viper.Set("pathToDockerCompose", path)
+
+			var output bytes.Buffer
+			err := (&Docker{Output: &output}).RecreateContainers([]Container{{Name: "frontend-1", ServiceName: "frontend", ChainID: 1}})
+			want := strings.ReplaceAll(tt.wantError, "%s", path)
+			if err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("RecreateContainers() = %v, want %q", err, want)
+			}
+			if !strings.Contains(output.String(), want) {
+				t.Errorf("recreation output = %q, want the compose file error", output.String())
+			}
+			if _, err := os.Stat(callsFile); !errors.Is(err, os.ErrNotExist) {
+				t.Error("docker was called despite the invalid compose file")
+			}
+		})
+	}
+}
+
+func TestRecreateProjectUpListsServicesInRestartOrder(t *testing.T) {
+	tests := []struct {
+		restartOrder string
+		want         []string
+	}{
+		{"", []string{"backend", "frontend", "proxy", "stats"}},
+		{"backend,frontend,proxy", []string{"backend", "frontend", "proxy", "stats"}},
+		{"stats, backend", []string{"stats", "backend", "frontend", "proxy"}},
+		{"proxy,unknown,frontend", []string{"proxy", "frontend", "backend", "stats"}},
+	}
+	for _, tt := range tests {
+		t.Run("restartOrder "+tt.restartOrder, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("restartOrder", tt.restartOrder)
+			dockerPath, callsFile := stubDocker(t, `backend\nfrontend\nproxy\nstats\n`, false)
+
+			containers := append([]Container{{Name: "proxy-1", ServiceName: "proxy"}}, threeContainers...)
+			if err := (&Docker{}).recreateProject(dockerPath, "blockscout", containers, io.Discard); err != nil {
+				t.Fatalf("recreateProject: %v", err)
+			}
+			if got := upCalls(t, callsFile); len(got) != 1 || !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("compose up calls = %v, want one call with %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubRemove writes a fake docker binary whose "rm" prints stderr and exits 1,
+// while every other command succeeds and is recorded to calls.log
+func stubRemove(t *testing.T, stderr string) (dockerPath, callsFile string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the docker stub is a shell script")
+	}
+	dir := t.TempDir()
+	callsFile = filepath.Join(dir, "calls.log")
+	script := `#!/bin/sh
+echo "$*" >> "` + callsFile + `"
+case "$1" in
+  rm) printf '` + stderr + `' >&2; exit 1 ;;
+esac
+`
+	dockerPath = filepath.Join(dir, "docker")
+	if err := os.WriteFile(dockerPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dockerPath, callsFile
+}
+
+func TestRecreateProjectToleratesMissingContainers(t *testing.T) {
+	tests := []struct {
+		name    string
+		stderr  string
+		wantUp  bool
+		wantErr bool
+	}{
+		{"no such container", `Error response from daemon: No such container: stats-1\n`, true, false},
+		{"every container missing", `Error response from daemon: No such container: frontend-1\nError response from daemon: No such container: stats-1\n`, true, false},
+		{"genuine failure", `Error response from daemon: cannot remove container: permission denied\n`, false, true},
+		{"mixed failures", `Error response from daemon: No such container: stats-1\nError response from daemon: driver failed\n`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerPath, callsFile := stubRemove(t, tt.stderr)
+
+			var out bytes.Buffer
+			err := (&Docker{}).recreateProject(dockerPath, "blockscout", threeContainers, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("recreateProject() = %v, want error: %t", err, tt.wantErr)
+			}
+			if got := len(upCalls(t, callsFile)) > 0; got != tt.wantUp {
+				t.Errorf("compose up ran: %t, want %t\n%s", got, tt.wantUp, out.String())
+			}
+		})
+	}
+}
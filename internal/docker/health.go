@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"blockscout-vc/internal/config"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// healthPollInterval is how often container status is checked while verifying health
+const healthPollInterval = 2 * time.Second
+
+// ContainersReady reports whether every named container is running and, when it
+// defines a health check, healthy. Containers that don't exist yet are not ready
+func (d *Docker) ContainersReady(names []string) (bool, error) {
+	unready, err := d.UnreadyContainers(names)
+	if err != nil {
+		return false, err
+	}
+	return len(unready) == 0, nil
+}
+
+// UnreadyContainers returns the named containers that are not running or, when they
+// define a health check, not healthy. Missing containers are reported as not ready
+func (d *Docker) UnreadyContainers(names []string) ([]string, error) {
+	dockerPath, err := exec.LookPath("docker")
+	if err != nil {
+		return nil, fmt.Errorf("docker executable not found: %w", err)
+	}
+
+	args := append([]string{"inspect", "--format", "{{.Name}} {{.State.Status}} {{if .State.Health}}{{.State.Health.Status}}{{end}}"}, names...)
+	// docker inspect fails when any container is missing, e.g. still being created,
+	// but still prints the containers it found
+	output, err := runDocker(dockerPath, args, nil)
+	if errors.Is(err, ErrCommandTimeout) {
+		return nil, err
+	}
+
+	ready := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name := strings.TrimPrefix(fields[0], "/")
+		ready[name] = fields[1] == "running" && (len(fields) == 2 || fields[2] == "healthy")
+	}
+
+	unready := []string{}
+	for _, name := range names {
+		if !ready[name] {
+			unready = append(unready, name)
+		}
+	}
+	return unready, nil
+}
+
+// verifyHealth watches recreated containers for recreation.verifyDuration and fails with a
+// *RecreateError when any of them is not running and healthy at the end, e.g. crash-looping
+func (d *Docker) verifyHealth(containers []Container, out io.Writer) error {
+	names := d.GetContainerNames(containers)
+	duration := config.GetRecreationVerifyDuration()
+	fmt.Fprintf(out, "Verifying containers %v are healthy for %s...\n", names, duration)
+
+	deadline := time.Now().Add(duration)
+	var unready []string
+	for {
+		var err error
+		unready, err = d.UnreadyContainers(names)
+		if err != nil {
+			return &RecreateError{FailedServices: d.GetServiceNames(containers), Err: fmt.Errorf("failed to verify container health: %w", err)}
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(min(healthPollInterval, time.Until(deadline)))
+	}
+
+	if len(unready) == 0 {
+		fmt.Fprintf(out, "Containers %v are healthy\n", names)
+		return nil
+	}
+
+	failed := []Container{}
+	for _, container := range containers {
+		if slices.Contains(unready, container.Name) {
+			failed = append(failed, container)
+		}
+	}
+	fmt.Fprintf(out, "Containers %v are not running and healthy after recreation\n", unready)
+	return &RecreateError{
+		FailedServices: d.GetServiceNames(failed),
+		Err:            fmt.Errorf("containers %v not running and healthy %s after recreation", unready, duration),
+	}
+}
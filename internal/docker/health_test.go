@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// stubInspect writes a fake docker binary whose inspect prints status, one
+// "/<name> <state> [<health>]" line per container, and fails when missing is set,
+// as docker does when some of the containers don't exist
+func stubInspect(t *testing.T, status string, missing bool) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the docker stub is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nprintf '" + status + "'\n"
+	if missing {
+		script += "echo 'Error: No such object: stats-1' >&2\nexit 1\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestUnreadyContainers(t *testing.T) {
+	names := []string{"frontend-1", "backend-1", "stats-1"}
+	tests := []struct {
+		name    string
+		status  string
+		missing bool
+		want    []string
+	}{
+		{"all running and healthy", `/frontend-1 running healthy\n/backend-1 running\n/stats-1 running healthy\n`, false, []string{}},
+		{"unhealthy", `/frontend-1 running unhealthy\n/backend-1 running\n/stats-1 running healthy\n`, false, []string{"frontend-1"}},
+		{"restarting", `/frontend-1 running healthy\n/backend-1 restarting\n/stats-1 running starting\n`, false, []string{"backend-1", "stats-1"}},
+		{"missing container", `/frontend-1 running healthy\n/backend-1 running\n`, true, []string{"stats-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubInspect(t, tt.status, tt.missing)
+			got, err := (&Docker{}).UnreadyContainers(names)
+			if err != nil {
+				t.Fatalf("UnreadyContainers: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnreadyContainers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyHealthFailsForUnhealthyContainer(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("recreation.verifyDuration", "10ms")
+	stubInspect(t, `/frontend-1 running unhealthy\n/backend-1 running healthy\n`, false)
+	containers := []Container{
+		{Name: "frontend-1", ServiceName: "frontend"},
+		{Name: "backend-1", ServiceName: "backend"},
+	}
+
+	err := (&Docker{}).verifyHealth(containers, io.Discard)
+	var recreateErr *RecreateError
+	if !errors.As(err, &recreateErr) {
+		t.Fatalf("verifyHealth() = %v, want a *RecreateError", err)
+	}
+	if !reflect.DeepEqual(recreateErr.FailedServices, []string{"frontend"}) {
+		t.Errorf("failed services = %v, want only frontend", recreateErr.FailedServices)
+	}
+}
+
+func TestVerifyHealthPassesForHealthyContainers(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("recreation.verifyDuration", "10ms")
+	stubInspect(t, `/frontend-1 running healthy\n/backend-1 running\n`, false)
+	containers := []Container{
+		{Name: "frontend-1", ServiceName: "frontend"},
+		{Name: "backend-1", ServiceName: "backend"},
+	}
+
+	if err := (&Docker{}).verifyHealth(containers, io.Discard); err != nil {
+		t.Errorf("verifyHealth() = %v, want healthy containers to pass", err)
+	}
+}
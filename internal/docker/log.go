@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultLogLines is how many lines of recreation output are kept by default
+const DefaultLogLines = 500
+
+// LogBuffer is an io.Writer keeping the most recent lines written to it
+// Partial lines are held until their newline arrives
+type LogBuffer struct {
+	mux      sync.Mutex
+	maxLines int
+	lines    []string
+	partial  string
+}
+
+func NewLogBuffer(maxLines int) *LogBuffer {
+	if maxLines <= 0 {
+		maxLines = DefaultLogLines
+	}
+	return &LogBuffer{maxLines: maxLines}
+}
+
+// Write splits p into lines and appends them, dropping the oldest lines past maxLines
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	parts := strings.Split(b.partial+string(p), "\n")
+	b.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		b.lines = append(b.lines, strings.TrimRight(line, "\r"))
+	}
+	if overflow := len(b.lines) - b.maxLines; overflow > 0 {
+		b.lines = append([]string(nil), b.lines[overflow:]...)
+	}
+	return len(p), nil
+}
+
+// Reset discards all buffered output
+func (b *LogBuffer) Reset() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.lines = nil
+	b.partial = ""
+}
+
+// Lines returns a copy of the buffered lines, including a trailing partial line
+func (b *LogBuffer) Lines() []string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	lines := append([]string{}, b.lines...)
+	if b.partial != "" {
+		lines = append(lines, b.partial)
+	}
+	return lines
+}
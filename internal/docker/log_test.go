@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentRecreationsKeepSeparateLogs(t *testing.T) {
+	d := &Docker{Output: io.Discard}
+
+	first := d.recreationOutput()
+	second := d.recreationOutput()
+
+	// Both jobs write at the same time; the published log must only hold the later job
+	var wg sync.WaitGroup
+	for name, out := range map[string]io.Writer{"first": first, "second": second} {
+		wg.Add(1)
+		go func(name string, out io.Writer) {
+			defer wg.Done()
+			for i := 0; i < 3; i++ {
+				fmt.Fprintf(out, "%s %d\n", name, i)
+			}
+		}(name, out)
+	}
+	wg.Wait()
+
+	want := []string{"second 0", "second 1", "second 2"}
+	if got := d.LastLog(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("LastLog() = %q, want %q", got, want)
+	}
+}
+
+func TestLastLogEmptyBeforeFirstRecreation(t *testing.T) {
+	d := &Docker{}
+	if got := d.LastLog(); len(got) != 0 {
+		t.Fatalf("LastLog() = %q, want empty", got)
+	}
+}
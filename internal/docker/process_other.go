@@ -0,0 +1,9 @@
+//go:build windows
+
+package docker
+
+import "os/exec"
+
+// setProcessGroup is a no-op where process groups are unavailable;
+// cancellation kills only the docker process itself
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package docker
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs cmd in its own process group and makes cancellation kill the
+// whole group, so compose plugins and pulls spawned by docker do not outlive a timeout
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package docker
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestRunDockerKillsProcessGroupOnTimeout(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("dockerCommandTimeout", 200*time.Millisecond)
+
+	// The child holds the output pipe open, so only killing the whole group ends the run quickly
+	dockerPath := filepath.Join(t.TempDir(), "docker")
+	script := "#!/bin/sh\necho pulling\nsleep 30 &\nsleep 30\n"
+	if err := os.WriteFile(dockerPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	start := time.Now()
+	_, err := runDocker(dockerPath, []string{"compose", "up"}, &out)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("runDocker returned after %s, want the stub killed at the timeout", elapsed)
+	}
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Errorf("err = %v, want ErrCommandTimeout", err)
+	}
+	if out.String() != "pulling\n" {
+		t.Errorf("output = %q, want the lines written before the timeout", out.String())
+	}
+}
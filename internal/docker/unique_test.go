@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUniqueContainersKeepsEveryServicePair(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	d := &Docker{}
+	containers := []Container{
+		{Name: "frontend-1", ServiceName: "frontend"},
+		{Name: "backend-1", ServiceName: "backend"},
+		{Name: "frontend-1", ServiceName: "frontend"},     // exact duplicate, dropped
+		{Name: "frontend-1", ServiceName: "frontend-web"}, // same name, another service: kept
+		{Name: "frontend-1", ServiceName: "frontend", ChainID: 2},
+	}
+	want := []Container{
+		{Name: "frontend-1", ServiceName: "frontend"},
+		{Name: "backend-1", ServiceName: "backend"},
+		{Name: "frontend-1", ServiceName: "frontend-web"},
+		{Name: "frontend-1", ServiceName: "frontend", ChainID: 2},
+	}
+	if got := d.UniqueContainers(containers); !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueContainers() = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(logs.String(), "container frontend-1 is configured for services frontend and frontend-web") {
+		t.Errorf("log %q does not report the conflicting services", logs.String())
+	}
+
+	services := d.GetServiceNames(d.UniqueContainers(containers))
+	for _, service := range []string{"frontend", "frontend-web", "backend"} {
+		if !slices.Contains(services, service) {
+			t.Errorf("services %v miss %s", services, service)
+		}
+	}
+}
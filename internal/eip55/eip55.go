@@ -0,0 +1,33 @@
+// Package eip55 renders Ethereum addresses in their EIP-55 mixed-case checksum form
+package eip55
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// Checksum returns the EIP-55 form of a 0x-prefixed, 40 hex digit address. Anything
+// else is returned unchanged so malformed stored values are still shown as they are
+func Checksum(address string) string {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") && !strings.HasPrefix(address, "0X") {
+		return address
+	}
+	lower := strings.ToLower(address[2:])
+	if _, err := hex.DecodeString(lower); err != nil {
+		return address
+	}
+
+	hash := keccak256([]byte(lower))
+	out := []byte("0x" + lower)
+	for i := 0; i < len(lower); i++ {
+		// Letters are uppercased when the matching nibble of the hash is 8 or more
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if out[i+2] >= 'a' && nibble >= 8 {
+			out[i+2] -= 'a' - 'A'
+		}
+	}
+	return string(out)
+}
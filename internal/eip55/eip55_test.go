@@ -0,0 +1,62 @@
+package eip55
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestChecksumMatchesEIP55Vectors(t *testing.T) {
+	// Test vectors from the EIP-55 specification
+	vectors := []string{
+		// All caps
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+		// All lower
+		"0xde709f2102306220921060314715629080e2fb77",
+		"0x27b1fdb04752bbc536007a920d24acb045561c26",
+		// Normal
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+	for _, want := range vectors {
+		stored := "0x" + strings.ToLower(want[2:])
+		if got := Checksum(stored); got != want {
+			t.Errorf("Checksum(%s) = %s, want %s", stored, got, want)
+		}
+		if got := Checksum("0X" + strings.ToUpper(want[2:])); got != want {
+			t.Errorf("Checksum of the uppercase %s = %s, want %s", want, got, want)
+		}
+	}
+}
+
+func TestChecksumLeavesMalformedAddresses(t *testing.T) {
+	for _, address := range []string{
+		"",
+		"0xabc",
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz",
+	} {
+		if got := Checksum(address); got != address {
+			t.Errorf("Checksum(%q) = %q, want it unchanged", address, got)
+		}
+	}
+}
+
+func TestKeccak256(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+	}
+	for _, tt := range tests {
+		hash := keccak256([]byte(tt.input))
+		if got := hex.EncodeToString(hash[:]); got != tt.want {
+			t.Errorf("keccak256(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package env
+
+import (
+	"blockscout-vc/internal/config"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// envChange is a single key changed by UpdateEnvVars or ReplaceEnvVars; existed is false
+// for a new key and removed is set for a key that was deleted
+type envChange struct {
+	key      string
+	oldValue string
+	newValue string
+	existed  bool
+	removed  bool
+}
+
+// appendChangeLog appends the changes as a timestamped unified diff to the envChangeLog file,
+// giving a durable history of env changes. Failures are only logged so they never block the update
+func (e *Env) appendChangeLog(changes []envChange) {
+	path := config.GetEnvChangeLogPath()
+	if path == "" || len(changes) == 0 {
+		return
+	}
+	if err := appendToFile(path, formatChangeLogEntry(e.PathToEnvFile, changes, time.Now())); err != nil {
+		fmt.Printf("Warning: failed to append to env change log %s: %v\n", path, err)
+	}
+}
+
+// formatChangeLogEntry renders the changes as a unified diff with one hunk per key, sorted by key
+func formatChangeLogEntry(envFile string, changes []envChange, at time.Time) string {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].key < changes[j].key })
+
+	timestamp := at.UTC().Format(time.RFC3339)
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "--- %s\t%s\n+++ %s\t%s\n", envFile, timestamp, envFile, timestamp)
+	for _, change := range changes {
+		fmt.Fprintf(&entry, "@@ %s @@\n", change.key)
+		if change.existed {
+			fmt.Fprintf(&entry, "-%s=%s\n", change.key, formatValue(change.oldValue))
+		}
+		if !change.removed {
+			fmt.Fprintf(&entry, "+%s=%s\n", change.key, formatValue(change.newValue))
+		}
+	}
+	return entry.String()
+}
+
+// appendToFile appends text to the file at path, creating it if needed
+func appendToFile(path, text string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,90 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestUpdateEnvVarsAppendsChangeLogDiff(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	dir := t.TempDir()
+	changeLog := filepath.Join(dir, "env-changes.log")
+	viper.Set("envChangeLog", changeLog)
+	envPath := filepath.Join(dir, "sidecar-injected.env")
+	if err := os.WriteFile(envPath, []byte("NAME=old\nKEEP=same\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := &Env{PathToEnvFile: envPath, EnvFile: make(map[string]string)}
+
+	for _, updates := range []map[string]string{
+		{"NAME": "new", "KEEP": "same", "ADDED": "1"},
+		{"NAME": "newer"},
+		{"NAME": "newer"}, // No change, no entry
+	} {
+		if _, err := e.UpdateEnvVars(updates); err != nil {
+			t.Fatalf("UpdateEnvVars(%v): %v", updates, err)
+		}
+	}
+
+	data, err := os.ReadFile(changeLog)
+	if err != nil {
+		t.Fatalf("reading change log: %v", err)
+	}
+	log := string(data)
+	if entries := strings.Count(log, "--- "+envPath); entries != 2 {
+		t.Fatalf("change log has %d entries, want 2:\n%s", entries, log)
+	}
+	for _, line := range []string{"@@ ADDED @@\n+ADDED=1\n", "@@ NAME @@\n-NAME=old\n+NAME=new\n", "@@ NAME @@\n-NAME=new\n+NAME=newer\n"} {
+		if !strings.Contains(log, line) {
+			t.Errorf("change log is missing %q:\n%s", line, log)
+		}
+	}
+	if strings.Contains(log, "KEEP") {
+		t.Errorf("change log lists an unchanged key:\n%s", log)
+	}
+}
+
+func TestUpdateEnvVarsIgnoresChangeLogFailure(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	dir := t.TempDir()
+	// A path below a regular file can't be created, even when running as root
+	parent := filepath.Join(dir, "not-a-directory")
+	if err := os.WriteFile(parent, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("envChangeLog", filepath.Join(parent, "env-changes.log"))
+	envPath := filepath.Join(dir, "sidecar-injected.env")
+	if err := os.WriteFile(envPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := &Env{PathToEnvFile: envPath, EnvFile: make(map[string]string)}
+
+	updated, err := e.UpdateEnvVars(map[string]string{"NAME": "value"})
+	if err != nil || !updated {
+		t.Fatalf("UpdateEnvVars() = %v, %v, want the update despite the change log failure", updated, err)
+	}
+	onDisk := &Env{PathToEnvFile: e.PathToEnvFile, EnvFile: make(map[string]string)}
+	if err := onDisk.ReadEnvFile(); err != nil || onDisk.EnvFile["NAME"] != "value" {
+		t.Errorf("env file holds %v (%v), want NAME=value", onDisk.EnvFile, err)
+	}
+}
+
+func TestFormatChangeLogEntry(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	changes := []envChange{
+		{key: "B", newValue: "2"},
+		{key: "A", oldValue: "1", existed: true, removed: true},
+	}
+
+	got := formatChangeLogEntry("app.env", changes, at)
+	want := "--- app.env\t2024-05-01T12:00:00Z\n+++ app.env\t2024-05-01T12:00:00Z\n" +
+		"@@ A @@\n-A=1\n@@ B @@\n+B=2\n"
+	if got != want {
+		t.Errorf("formatChangeLogEntry() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 package env
 
 import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/metrics"
 	"bufio"
 	"fmt"
 	"os"
@@ -10,18 +12,72 @@ import (
 	"github.com/spf13/viper"
 )
 
+var (
+	writeFailures = metrics.NewCounter(
+		"blockscout_vc_env_write_failures_total",
+		"Total number of failed env file writes",
+	)
+	lastWriteSuccess = metrics.NewGauge(
+		"blockscout_vc_env_last_write_success_timestamp",
+		"Unix timestamp of the last successful env file write",
+	)
+)
+
+// recordWrite updates the env write metrics with the outcome of a write
+func recordWrite(err error) {
+	if err != nil {
+		writeFailures.Inc()
+		return
+	}
+	lastWriteSuccess.SetToCurrentTime()
+}
+
 type Env struct {
 	PathToEnvFile string
 	EnvFile       map[string]string
+	lines         []envLine // Lines as last read, used to preserve comments and order on write
 }
 
+// envLine is a single line of the env file; key is empty for comments and blank lines
+type envLine struct {
+	key string
+	raw string
+}
+
+// Env write modes
+const (
+	WriteModeSorted   = "sorted"
+	WriteModePreserve = "preserve"
+)
+
+// NewEnv returns the env file of the configured chain
 func NewEnv() *Env {
+	return NewEnvForChain(viper.GetInt("chainId"))
+}
+
+// NewEnvForChain returns the env file a chain's records are written to
+func NewEnvForChain(chainID int) *Env {
 	return &Env{
-		PathToEnvFile: viper.GetString("pathToEnvFile"),
+		PathToEnvFile: config.GetEnvFilePath(chainID),
 		EnvFile:       make(map[string]string),
 	}
 }
 
+// EnsureEnvFile creates the env file if it does not exist yet
+func (e *Env) EnsureEnvFile() error {
+	if _, err := os.Stat(e.PathToEnvFile); !os.IsNotExist(err) {
+		return nil
+	}
+	file, err := os.Create(e.PathToEnvFile)
+	if err != nil {
+		return fmt.Errorf("failed to create env file: %w", err)
+	}
+	if closeErr := file.Close(); closeErr != nil {
+		fmt.Printf("Warning: failed to close env file: %v\n", closeErr)
+	}
+	return nil
+}
+
 // ReadEnvFile reads and parses the environment file
 func (e *Env) ReadEnvFile() error {
 	file, err := os.Open(e.PathToEnvFile)
@@ -36,23 +92,24 @@ func (e *Env) ReadEnvFile() error {
 
 	scanner := bufio.NewScanner(file)
 
+	e.lines = nil
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
 		if line == "" || strings.HasPrefix(line, "#") {
+			e.lines = append(e.lines, envLine{raw: raw})
 			continue
 		}
 
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
+			e.lines = append(e.lines, envLine{raw: raw})
 			continue
 		}
 
 		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		// Remove quotes if present
-		value = strings.Trim(value, `"'`)
-
-		e.EnvFile[key] = value
+		e.EnvFile[key] = parseValue(strings.TrimSpace(parts[1]))
+		e.lines = append(e.lines, envLine{key: key, raw: raw})
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -63,7 +120,14 @@ func (e *Env) ReadEnvFile() error {
 }
 
 // WriteEnvFile writes the environment variables back to the file
+// Every attempt is recorded in the env write metrics
 func (e *Env) WriteEnvFile() error {
+	err := e.writeEnvFile()
+	recordWrite(err)
+	return err
+}
+
+func (e *Env) writeEnvFile() error {
 	file, err := os.Create(e.PathToEnvFile)
 	if err != nil {
 		return fmt.Errorf("failed to create env file: %w", err)
@@ -75,51 +139,151 @@ func (e *Env) WriteEnvFile() error {
 	}()
 
 	writer := bufio.NewWriter(file)
+	for _, line := range e.renderLines() {
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			return fmt.Errorf("failed to write line to env file: %w", err)
+		}
+	}
 
-	// Sort keys for consistent output
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush env file: %w", err)
+	}
+
+	return nil
+}
+
+// renderLines returns the lines to write for the configured envWriteMode
+// "sorted" (default) rewrites every key alphabetically and drops comments; "preserve" keeps
+// comments and the order read from the file, rewriting only changed values and appending new keys
+func (e *Env) renderLines() []string {
+	written := make(map[string]struct{}, len(e.EnvFile))
+	lines := []string{}
+
+	if viper.GetString("envWriteMode") == WriteModePreserve {
+		for _, line := range e.lines {
+			if line.key == "" {
+				lines = append(lines, line.raw)
+				continue
+			}
+			value, exists := e.EnvFile[line.key]
+			if !exists {
+				continue
+			}
+			written[line.key] = struct{}{}
+			// Keep the original text when the value is unchanged so diffs stay minimal
+			if parts := strings.SplitN(strings.TrimSpace(line.raw), "=", 2); parseValue(strings.TrimSpace(parts[1])) == value {
+				lines = append(lines, line.raw)
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s=%s", line.key, formatValue(value)))
+		}
+	}
+
+	// Sort remaining keys for consistent output
 	keys := make([]string, 0, len(e.EnvFile))
 	for k := range e.EnvFile {
-		keys = append(keys, k)
+		if _, done := written[k]; !done {
+			keys = append(keys, k)
+		}
 	}
 	sort.Strings(keys)
 
 	for _, key := range keys {
-		value := e.EnvFile[key]
-		// Add quotes if value contains spaces
-		if strings.Contains(value, " ") {
-			value = fmt.Sprintf(`"%s"`, value)
-		}
+		lines = append(lines, fmt.Sprintf("%s=%s", key, formatValue(e.EnvFile[key])))
+	}
+	return lines
+}
 
-		line := fmt.Sprintf("%s=%s\n", key, value)
-		if _, err := writer.WriteString(line); err != nil {
-			return fmt.Errorf("failed to write line to env file: %w", err)
-		}
+// envSpecialChars are the characters that force a value to be written double-quoted
+const envSpecialChars = " \t#=\"'\\\n\r"
+
+// formatValue renders a value so that parseValue returns it unchanged
+// Values containing special characters are double-quoted with backslash escapes
+func formatValue(value string) string {
+	if !strings.ContainsAny(value, envSpecialChars) {
+		return value
 	}
 
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush env file: %w", err)
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
 	}
+	b.WriteByte('"')
+	return b.String()
+}
 
-	return nil
+// parseValue parses the raw text after "=" on an env line
+// Double-quoted values are unescaped, single-quoted values are taken literally and
+// unquoted values end at an inline " #" comment
+func parseValue(raw string) string {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			if c == '"' {
+				return b.String()
+			}
+			if c == '\\' && i+1 < len(raw) {
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(raw[i])
+				}
+				continue
+			}
+			b.WriteByte(c)
+		}
+		// Unterminated quote: keep the rest of the line
+		return b.String()
+	case strings.HasPrefix(raw, "'"):
+		if end := strings.Index(raw[1:], "'"); end >= 0 {
+			return raw[1 : end+1]
+		}
+		return raw[1:]
+	default:
+		if idx := strings.Index(raw, " #"); idx >= 0 {
+			raw = raw[:idx]
+		}
+		return strings.TrimSpace(raw)
+	}
 }
 
 // UpdateEnvVars updates environment variables in the env file
-// Returns whether any changes were made
+// Returns whether any changes were made. Changes are appended to envChangeLog, when set, before writing
 func (e *Env) UpdateEnvVars(updates map[string]string) (bool, error) {
 	err := e.ReadEnvFile()
 	if err != nil {
 		return false, fmt.Errorf("failed to read env file: %w", err)
 	}
 
-	updated := false
+	changes := []envChange{}
 	for key, newValue := range updates {
 		if currentValue, exists := e.EnvFile[key]; !exists || currentValue != newValue {
 			e.EnvFile[key] = newValue
-			updated = true
+			changes = append(changes, envChange{key: key, oldValue: currentValue, newValue: newValue, existed: exists})
 		}
 	}
+	updated := len(changes) > 0
 
 	if updated {
+		e.appendChangeLog(changes)
 		if err := e.WriteEnvFile(); err != nil {
 			return false, fmt.Errorf("failed to write env file: %w", err)
 		}
@@ -127,3 +291,83 @@ func (e *Env) UpdateEnvVars(updates map[string]string) (bool, error) {
 
 	return updated, nil
 }
+
+// VerifyEnvVars re-reads the env file from disk and checks that every expected key holds
+// its expected value, catching writes lost to a concurrent writer or a disk issue
+func (e *Env) VerifyEnvVars(expected map[string]string) error {
+	onDisk := &Env{PathToEnvFile: e.PathToEnvFile, EnvFile: make(map[string]string)}
+	if err := onDisk.ReadEnvFile(); err != nil {
+		return err
+	}
+
+	mismatched := []string{}
+	for key, value := range expected {
+		if current, exists := onDisk.EnvFile[key]; !exists || current != value {
+			mismatched = append(mismatched, key)
+		}
+	}
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		return fmt.Errorf("keys %v in %s do not hold the written values", mismatched, e.PathToEnvFile)
+	}
+	return nil
+}
+
+// ReplaceEnvVars makes the env file hold exactly vars, removing keys not in it, e.g. to
+// restore a snapshot. Returns whether any changes were made
+func (e *Env) ReplaceEnvVars(vars map[string]string) (bool, error) {
+	if err := e.ReadEnvFile(); err != nil {
+		return false, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	changes := []envChange{}
+	for key, currentValue := range e.EnvFile {
+		if _, keep := vars[key]; !keep {
+			delete(e.EnvFile, key)
+			changes = append(changes, envChange{key: key, oldValue: currentValue, existed: true, removed: true})
+		}
+	}
+	for key, newValue := range vars {
+		if currentValue, exists := e.EnvFile[key]; !exists || currentValue != newValue {
+			e.EnvFile[key] = newValue
+			changes = append(changes, envChange{key: key, oldValue: currentValue, newValue: newValue, existed: exists})
+		}
+	}
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	e.appendChangeLog(changes)
+	if err := e.WriteEnvFile(); err != nil {
+		return false, fmt.Errorf("failed to write env file: %w", err)
+	}
+	return true, nil
+}
+
+// RemoveKeys deletes every key for which remove returns true and writes the env file
+// Returns the sorted removed keys; the file is left untouched when nothing matches
+func (e *Env) RemoveKeys(remove func(key string) bool) ([]string, error) {
+	if err := e.ReadEnvFile(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	removed := []string{}
+	changes := []envChange{}
+	for key, currentValue := range e.EnvFile {
+		if remove(key) {
+			delete(e.EnvFile, key)
+			removed = append(removed, key)
+			changes = append(changes, envChange{key: key, oldValue: currentValue, existed: true, removed: true})
+		}
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+	sort.Strings(removed)
+
+	e.appendChangeLog(changes)
+	if err := e.WriteEnvFile(); err != nil {
+		return nil, fmt.Errorf("failed to write env file: %w", err)
+	}
+	return removed, nil
+}
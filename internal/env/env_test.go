@@ -0,0 +1,167 @@
+package env
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWriteEnvFileFailureIncrementsCounter(t *testing.T) {
+	// A path below a regular file can't be created, even when running as root
+	parent := filepath.Join(t.TempDir(), "not-a-directory")
+	if err := os.WriteFile(parent, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := &Env{PathToEnvFile: filepath.Join(parent, "sidecar-injected.env"), EnvFile: map[string]string{"A": "1"}}
+
+	before := writeFailures.Value()
+	if err := e.WriteEnvFile(); err == nil {
+		t.Fatal("WriteEnvFile succeeded below a regular file")
+	}
+	if got := writeFailures.Value(); got != before+1 {
+		t.Errorf("write failures = %d, want %d", got, before+1)
+	}
+}
+
+func TestWriteEnvFileSuccessSetsTimestamp(t *testing.T) {
+	e := &Env{PathToEnvFile: filepath.Join(t.TempDir(), "sidecar-injected.env"), EnvFile: map[string]string{"A": "1"}}
+
+	lastWriteSuccess.Set(0)
+	before := writeFailures.Value()
+	if err := e.WriteEnvFile(); err != nil {
+		t.Fatalf("WriteEnvFile: %v", err)
+	}
+	if lastWriteSuccess.Value() == 0 {
+		t.Error("last write success timestamp was not set")
+	}
+	if writeFailures.Value() != before {
+		t.Error("a successful write counted as a failure")
+	}
+}
+
+func TestEnvFileRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"EMPTY":     "",
+		"PLAIN":     "value",
+		"EQUALS":    "a=b=c",
+		"HASH":      "#fff",
+		"INLINE":    "value # not a comment",
+		"SPACES":    "  padded  ",
+		"DOUBLE":    `say "hi"`,
+		"SINGLE":    "it's",
+		"BACKSLASH": `C:\path\n`,
+		"NEWLINES":  "line1\nline2\r\n",
+		"JSON":      `[{'title':'Aurora','url':"https://x.dev/?a=1#b"}]`,
+	}
+	// Random values over the characters that need escaping
+	const chars = "ab1 \t#=\"'\\\n\r$"
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		value := make([]byte, rng.Intn(12))
+		for j := range value {
+			value[j] = chars[rng.Intn(len(chars))]
+		}
+		values[fmt.Sprintf("RANDOM_%d", i)] = string(value)
+	}
+
+	path := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	written := &Env{PathToEnvFile: path, EnvFile: values}
+	if err := written.WriteEnvFile(); err != nil {
+		t.Fatalf("WriteEnvFile: %v", err)
+	}
+
+	read := &Env{PathToEnvFile: path, EnvFile: map[string]string{}}
+	if err := read.ReadEnvFile(); err != nil {
+		t.Fatalf("ReadEnvFile: %v", err)
+	}
+	if !reflect.DeepEqual(read.EnvFile, values) {
+		for key, want := range values {
+			if got := read.EnvFile[key]; got != want {
+				t.Errorf("%s = %q, want %q", key, got, want)
+			}
+		}
+		if len(read.EnvFile) != len(values) {
+			t.Errorf("read %d keys, want %d", len(read.EnvFile), len(values))
+		}
+	}
+}
+
+func TestPreserveModeKeepsCommentsAndOrder(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("envWriteMode", WriteModePreserve)
+
+	original := "# Frontend settings\nZ_LAST=1\n\n# Network\nNAME=\"Aurora Mainnet\" \nA_FIRST='keep me'\n"
+	path := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Env{PathToEnvFile: path, EnvFile: map[string]string{}}
+	if err := e.ReadEnvFile(); err != nil {
+		t.Fatalf("ReadEnvFile: %v", err)
+	}
+	if _, err := e.UpdateEnvVars(map[string]string{"Z_LAST": "2", "NEW_KEY": "new"}); err != nil {
+		t.Fatalf("UpdateEnvVars: %v", err)
+	}
+	if err := e.WriteEnvFile(); err != nil {
+		t.Fatalf("WriteEnvFile: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Frontend settings\nZ_LAST=2\n\n# Network\nNAME=\"Aurora Mainnet\" \nA_FIRST='keep me'\nNEW_KEY=new\n"
+	if string(content) != want {
+		t.Errorf("env file =\n%s\nwant\n%s", content, want)
+	}
+}
+
+func TestSortedModeRewritesAlphabetically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	if err := os.WriteFile(path, []byte("# comment\nZ=1\nA=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Env{PathToEnvFile: path, EnvFile: map[string]string{}}
+	if err := e.ReadEnvFile(); err != nil {
+		t.Fatalf("ReadEnvFile: %v", err)
+	}
+	if err := e.WriteEnvFile(); err != nil {
+		t.Fatalf("WriteEnvFile: %v", err)
+	}
+
+	if content, _ := os.ReadFile(path); string(content) != "A=2\nZ=1\n" {
+		t.Errorf("env file = %q, want the keys sorted without comments", content)
+	}
+}
+
+func TestVerifyEnvVarsDetectsClobberedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	e := &Env{PathToEnvFile: path, EnvFile: map[string]string{"NAME": "Aurora", "COIN": "ETH"}}
+	if err := e.WriteEnvFile(); err != nil {
+		t.Fatalf("WriteEnvFile: %v", err)
+	}
+	expected := map[string]string{"NAME": "Aurora", "COIN": "ETH"}
+	if err := e.VerifyEnvVars(expected); err != nil {
+		t.Fatalf("VerifyEnvVars after a clean write: %v", err)
+	}
+
+	// A concurrent writer replaces the file, changing one key and dropping another
+	if err := os.WriteFile(path, []byte("NAME=Other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := e.VerifyEnvVars(expected)
+	if err == nil {
+		t.Fatal("VerifyEnvVars missed the clobbered keys")
+	}
+	if !strings.Contains(err.Error(), "[COIN NAME]") {
+		t.Errorf("error = %v, want it to name COIN and NAME", err)
+	}
+}
@@ -0,0 +1,60 @@
+package env
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultGitAuthorName  = "blockscout-vc-sidecar"
+	defaultGitAuthorEmail = "sidecar@blockscout-vc.local"
+)
+
+// CommitEnvFile commits the env file to the git repository that contains it
+// It does nothing unless envGit.enabled is set, or when the file has no changes
+// The author is taken from envGit.authorName and envGit.authorEmail
+func (e *Env) CommitEnvFile(message string) error {
+	if !viper.GetBool("envGit.enabled") {
+		return nil
+	}
+
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return fmt.Errorf("git executable not found: %w", err)
+	}
+
+	authorName := viper.GetString("envGit.authorName")
+	if authorName == "" {
+		authorName = defaultGitAuthorName
+	}
+	authorEmail := viper.GetString("envGit.authorEmail")
+	if authorEmail == "" {
+		authorEmail = defaultGitAuthorEmail
+	}
+
+	dir := filepath.Dir(e.PathToEnvFile)
+	file := filepath.Base(e.PathToEnvFile)
+
+	if output, err := exec.Command(gitPath, "-C", dir, "add", "--", file).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage env file: %w: %s", err, output)
+	}
+
+	// Nothing staged for the env file means there is nothing to commit
+	if err := exec.Command(gitPath, "-C", dir, "diff", "--cached", "--quiet", "--", file).Run(); err == nil {
+		return nil
+	}
+
+	commitCmd := exec.Command(gitPath, "-C", dir,
+		"-c", "user.name="+authorName,
+		"-c", "user.email="+authorEmail,
+		"commit", "-m", message, "--", file)
+	if output, err := commitCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to commit env file: %w: %s", err, output)
+	}
+
+	fmt.Printf("Committed env file changes: %s\n", message)
+	return nil
+}
@@ -0,0 +1,77 @@
+package env
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// gitRepo creates a temporary git repository holding an env file and returns the file path
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, output)
+	}
+	envFile := filepath.Join(dir, "sidecar-injected.env")
+	if err := os.WriteFile(envFile, []byte("NEXT_PUBLIC_NETWORK_NAME=Aurora\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return envFile
+}
+
+// lastCommit returns the subject and author of the newest commit in dir, or "" without commits
+func lastCommit(t *testing.T, dir string) string {
+	t.Helper()
+	output, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%s|%an <%ae>").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestCommitEnvFileCreatesCommit(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	envFile := gitRepo(t)
+	viper.Set("pathToEnvFile", envFile)
+	viper.Set("envGit.enabled", true)
+	viper.Set("envGit.authorName", "Sidecar Bot")
+	viper.Set("envGit.authorEmail", "sidecar@example.com")
+
+	message := `Update env from silos record 7 (chain 1313161555, name "Aurora")`
+	if err := NewEnv().CommitEnvFile(message); err != nil {
+		t.Fatalf("CommitEnvFile: %v", err)
+	}
+	want := message + "|Sidecar Bot <sidecar@example.com>"
+	if got := lastCommit(t, filepath.Dir(envFile)); got != want {
+		t.Errorf("last commit = %q, want %q", got, want)
+	}
+
+	// Committing again without changes creates no empty commit
+	if err := NewEnv().CommitEnvFile("second"); err != nil {
+		t.Fatalf("CommitEnvFile without changes: %v", err)
+	}
+	if got := lastCommit(t, filepath.Dir(envFile)); got != want {
+		t.Errorf("last commit = %q after an unchanged commit, want %q", got, want)
+	}
+}
+
+func TestCommitEnvFileDisabled(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	envFile := gitRepo(t)
+	viper.Set("pathToEnvFile", envFile)
+
+	if err := NewEnv().CommitEnvFile("ignored"); err != nil {
+		t.Fatalf("CommitEnvFile: %v", err)
+	}
+	if got := lastCommit(t, filepath.Dir(envFile)); got != "" {
+		t.Errorf("commit %q created while envGit.enabled is unset", got)
+	}
+}
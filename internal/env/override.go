@@ -0,0 +1,144 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeOverride is a docker-compose override file holding the environment
+// variables the sidecar manages for each service
+// The file is owned by the sidecar: anything other than service environments is not preserved
+type ComposeOverride struct {
+	PathToOverrideFile string
+	Services           map[string]map[string]string
+}
+
+type composeOverrideFile struct {
+	Services map[string]composeOverrideService `yaml:"services"`
+}
+
+type composeOverrideService struct {
+	Environment map[string]string `yaml:"environment"`
+}
+
+func NewComposeOverride(path string) *ComposeOverride {
+	return &ComposeOverride{
+		PathToOverrideFile: path,
+		Services:           make(map[string]map[string]string),
+	}
+}
+
+// ReadOverrideFile reads and parses the override file
+// A missing file is treated as an override without any services
+func (o *ComposeOverride) ReadOverrideFile() error {
+	data, err := os.ReadFile(o.PathToOverrideFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read compose override file: %w", err)
+	}
+
+	var file composeOverrideFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse compose override file: %w", err)
+	}
+
+	for serviceName, service := range file.Services {
+		if o.Services[serviceName] == nil {
+			o.Services[serviceName] = make(map[string]string)
+		}
+		for key, value := range service.Environment {
+			o.Services[serviceName][key] = value
+		}
+	}
+	return nil
+}
+
+// WriteOverrideFile writes the service environments back to the override file
+func (o *ComposeOverride) WriteOverrideFile() error {
+	file := composeOverrideFile{Services: make(map[string]composeOverrideService)}
+	for serviceName, environment := range o.Services {
+		file.Services[serviceName] = composeOverrideService{Environment: environment}
+	}
+
+	// yaml.v3 sorts map keys, so the output is stable between writes
+	data, err := yaml.Marshal(&file)
+	if err != nil {
+		return fmt.Errorf("failed to encode compose override file: %w", err)
+	}
+
+	if err := os.WriteFile(o.PathToOverrideFile, data, 0644); err != nil {
+		recordWrite(err)
+		return fmt.Errorf("failed to write compose override file: %w", err)
+	}
+	recordWrite(nil)
+	return nil
+}
+
+// UpdateServiceEnvVars updates the environment of each service in the override file
+// Returns whether any changes were made
+func (o *ComposeOverride) UpdateServiceEnvVars(updates map[string]map[string]string) (bool, error) {
+	if err := o.ReadOverrideFile(); err != nil {
+		return false, err
+	}
+
+	updated := false
+	for serviceName, envVars := range updates {
+		if o.Services[serviceName] == nil {
+			o.Services[serviceName] = make(map[string]string)
+		}
+		for key, newValue := range envVars {
+			if currentValue, exists := o.Services[serviceName][key]; !exists || currentValue != newValue {
+				o.Services[serviceName][key] = newValue
+				updated = true
+			}
+		}
+	}
+
+	if updated {
+		if err := o.WriteOverrideFile(); err != nil {
+			return false, err
+		}
+	}
+
+	return updated, nil
+}
+
+// ReplaceServices makes the override file hold exactly the given service environments,
+// e.g. to restore a snapshot. Returns the names of the services whose environment changed
+func (o *ComposeOverride) ReplaceServices(services map[string]map[string]string) ([]string, error) {
+	if err := o.ReadOverrideFile(); err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for serviceName, environment := range o.Services {
+		if _, keep := services[serviceName]; !keep && len(environment) > 0 {
+			changed = append(changed, serviceName)
+		}
+	}
+	for serviceName, environment := range services {
+		if !maps.Equal(o.Services[serviceName], environment) {
+			changed = append(changed, serviceName)
+		}
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	o.Services = make(map[string]map[string]string, len(services))
+	for serviceName, environment := range services {
+		o.Services[serviceName] = maps.Clone(environment)
+	}
+	if err := o.WriteOverrideFile(); err != nil {
+		return nil, err
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
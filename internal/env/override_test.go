@@ -0,0 +1,70 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUpdateServiceEnvVarsWritesOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docker-compose.override.yml")
+	override := NewComposeOverride(path)
+
+	updated, err := override.UpdateServiceEnvVars(map[string]map[string]string{
+		"frontend": {"NEXT_PUBLIC_NETWORK_NAME": "Aurora", "NEXT_PUBLIC_NETWORK_ID": "1313161555"},
+		"backend":  {"CHAIN_ID": "1313161555"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateServiceEnvVars: %v", err)
+	}
+	if !updated {
+		t.Fatal("UpdateServiceEnvVars reported no change for a new file")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]map[string]map[string]map[string]string
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("override is not valid YAML: %v\n%s", err, data)
+	}
+	want := map[string]map[string]map[string]map[string]string{
+		"services": {
+			"frontend": {"environment": {"NEXT_PUBLIC_NETWORK_NAME": "Aurora", "NEXT_PUBLIC_NETWORK_ID": "1313161555"}},
+			"backend":  {"environment": {"CHAIN_ID": "1313161555"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("override = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateServiceEnvVarsKeepsOtherServices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docker-compose.override.yml")
+	existing := "services:\n  stats:\n    environment:\n      STATS__BLOCKSCOUT_API_URL: https://explorer.example.com\n"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	override := NewComposeOverride(path)
+	if _, err := override.UpdateServiceEnvVars(map[string]map[string]string{"frontend": {"A": "1"}}); err != nil {
+		t.Fatalf("UpdateServiceEnvVars: %v", err)
+	}
+
+	reread := NewComposeOverride(path)
+	if err := reread.ReadOverrideFile(); err != nil {
+		t.Fatalf("ReadOverrideFile: %v", err)
+	}
+	if reread.Services["stats"]["STATS__BLOCKSCOUT_API_URL"] != "https://explorer.example.com" || reread.Services["frontend"]["A"] != "1" {
+		t.Errorf("services = %v, want stats kept and frontend added", reread.Services)
+	}
+
+	// Writing the same values again is not a change
+	if updated, err := reread.UpdateServiceEnvVars(map[string]map[string]string{"frontend": {"A": "1"}}); err != nil || updated {
+		t.Errorf("UpdateServiceEnvVars() = %t, %v, want no change", updated, err)
+	}
+}
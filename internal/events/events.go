@@ -0,0 +1,111 @@
+// Package events publishes handled records to an external message queue so other
+// services can consume chain config changes
+package events
+
+import (
+	"blockscout-vc/internal/handlers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Supported values of events.sink
+const (
+	SinkNone  = "none"
+	SinkNATS  = "nats"
+	SinkKafka = "kafka"
+)
+
+// DefaultSubject is the NATS subject or Kafka topic used when none is configured
+const DefaultSubject = "blockscout-vc.records"
+
+// Event is published after a record has been handled
+type Event struct {
+	Table     string           `json:"table"`
+	Record    handlers.Record  `json:"record"`
+	Changes   []map[string]any `json:"changes"` // HandlerResult.LogFields of every handler that ran
+	HandledAt time.Time        `json:"handledAt"`
+}
+
+// EventSink receives handled records
+type EventSink interface {
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+// NoopSink discards every event; it is used when events.sink is none or unset
+type NoopSink struct{}
+
+func (NoopSink) Publish(ctx context.Context, event Event) error { return nil }
+func (NoopSink) Close() error                                   { return nil }
+
+var (
+	sink    EventSink = NoopSink{}
+	sinkMux sync.RWMutex
+)
+
+// SetSink replaces the sink events are published to
+func SetSink(s EventSink) {
+	sinkMux.Lock()
+	defer sinkMux.Unlock()
+	sink = s
+}
+
+// Publish sends the event to the configured sink. Failures are only logged,
+// so publishing never affects record handling
+func Publish(event Event) {
+	sinkMux.RLock()
+	s := sink
+	sinkMux.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout())
+	defer cancel()
+	if err := s.Publish(ctx, event); err != nil {
+		log.Printf("Warning: failed to publish event for record %d: %v", event.Record.ID, err)
+	}
+}
+
+// NewSink creates the sink selected by events.sink
+func NewSink() (EventSink, error) {
+	switch strings.ToLower(viper.GetString("events.sink")) {
+	case "", SinkNone:
+		return NoopSink{}, nil
+	case SinkNATS:
+		return NewNATSSink(viper.GetString("events.nats.url"), subject("events.nats.subject"))
+	case SinkKafka:
+		return NewKafkaSink(viper.GetString("events.kafka.restProxyUrl"), subject("events.kafka.topic"))
+	default:
+		return nil, fmt.Errorf("unknown events.sink %q, expected nats, kafka or none", viper.GetString("events.sink"))
+	}
+}
+
+// encode serializes an event for the wire
+func encode(event Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	return data, nil
+}
+
+// timeout returns how long a single publish may take (events.timeout, default 5s)
+func timeout() time.Duration {
+	if timeout := viper.GetDuration("events.timeout"); timeout > 0 {
+		return timeout
+	}
+	return 5 * time.Second
+}
+
+// subject returns the configured subject or topic, defaulting to DefaultSubject
+func subject(key string) string {
+	if subject := viper.GetString(key); subject != "" {
+		return subject
+	}
+	return DefaultSubject
+}
@@ -0,0 +1,207 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"blockscout-vc/internal/handlers"
+
+	"github.com/spf13/viper"
+)
+
+// memorySink keeps published events in memory, failing every publish when err is set
+type memorySink struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+func (s *memorySink) Publish(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+// useSink publishes to sink for the duration of the test
+func useSink(t *testing.T, sink EventSink) {
+	t.Helper()
+	SetSink(sink)
+	t.Cleanup(func() { SetSink(NoopSink{}) })
+}
+
+func TestPublishDeliversToSink(t *testing.T) {
+	sink := &memorySink{}
+	useSink(t, sink)
+
+	Publish(Event{Table: "silos", Record: handlers.Record{ID: 7, ChainID: 1313161554, Name: "Aurora"}})
+
+	if len(sink.events) != 1 {
+		t.Fatalf("sink received %d events, want 1", len(sink.events))
+	}
+	if event := sink.events[0]; event.Table != "silos" || event.Record.ID != 7 || event.Record.Name != "Aurora" {
+		t.Errorf("event = %+v, want record 7 from silos", event)
+	}
+}
+
+func TestPublishFailureIsOnlyLogged(t *testing.T) {
+	useSink(t, &memorySink{err: errors.New("queue unavailable")})
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	Publish(Event{Record: handlers.Record{ID: 7}})
+
+	if !strings.Contains(logs.String(), "failed to publish event for record 7: queue unavailable") {
+		t.Errorf("log %q does not report the failure", logs.String())
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		want    any
+		wantErr bool
+	}{
+		{"unset", nil, NoopSink{}, false},
+		{"none", map[string]any{"events.sink": "none"}, NoopSink{}, false},
+		{"nats", map[string]any{"events.sink": "nats", "events.nats.url": "nats://localhost"}, &NATSSink{}, false},
+		{"nats without url", map[string]any{"events.sink": "nats"}, nil, true},
+		{"kafka", map[string]any{"events.sink": "KAFKA", "events.kafka.restProxyUrl": "http://localhost:8082"}, &KafkaSink{}, false},
+		{"kafka without url", map[string]any{"events.sink": "kafka"}, nil, true},
+		{"unknown", map[string]any{"events.sink": "sqs"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			for key, value := range tt.config {
+				viper.Set(key, value)
+			}
+
+			sink, err := NewSink()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSink() error = %v, want error: %t", err, tt.wantErr)
+			}
+			if got, want := fmt.Sprintf("%T", sink), fmt.Sprintf("%T", tt.want); !tt.wantErr && got != want {
+				t.Errorf("NewSink() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestKafkaSinkPublish(t *testing.T) {
+	var path string
+	var body struct {
+		Records []struct {
+			Key   string `json:"key"`
+			Value Event  `json:"value"`
+		} `json:"records"`
+	}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"offsets":[{"partition":0,"offset":1}]}`)
+	}))
+	t.Cleanup(proxy.Close)
+
+	sink, err := NewKafkaSink(proxy.URL+"/", "chain-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Publish(context.Background(), Event{Table: "silos", Record: handlers.Record{ID: 7, ChainID: 1313161554}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if path != "/topics/chain-config" {
+		t.Errorf("produced to %q, want /topics/chain-config", path)
+	}
+	if len(body.Records) != 1 || body.Records[0].Key != "1313161554" || body.Records[0].Value.Record.ID != 7 {
+		t.Errorf("records = %+v, want record 7 keyed by its chain", body.Records)
+	}
+}
+
+func TestKafkaSinkReportsRejectedEvent(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"offsets":[{"error_code":40403,"error":"topic not found"}]}`)
+	}))
+	t.Cleanup(proxy.Close)
+
+	sink, err := NewKafkaSink(proxy.URL, DefaultSubject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Publish(context.Background(), Event{})
+	if err == nil || !strings.Contains(err.Error(), "topic not found") {
+		t.Errorf("Publish() = %v, want the proxy's error", err)
+	}
+}
+
+func TestNATSSinkPublish(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	// A minimal NATS server accepting one publish
+	published := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := reader.ReadString('\n')
+				published <- line + payload
+			case strings.HasPrefix(line, "PING"):
+				io.WriteString(conn, "PONG\r\n")
+			}
+		}
+	}()
+
+	sink, err := NewNATSSink("nats://"+listener.Addr().String(), "chain-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Publish(context.Background(), Event{Table: "silos", Record: handlers.Record{ID: 7}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	message := <-published
+	if !strings.HasPrefix(message, "PUB chain-config ") || !strings.Contains(message, `"table":"silos"`) {
+		t.Errorf("published %q, want the event on chain-config", message)
+	}
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// KafkaSink publishes events to a Kafka topic through a Kafka REST Proxy (v2 API)
+// Events are keyed by chain ID so a chain's events stay ordered
+type KafkaSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+// kafkaProduceResponse is the subset of the REST Proxy produce response we check
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Error *string `json:"error"`
+	} `json:"offsets"`
+}
+
+// NewKafkaSink returns a sink producing to topic through the REST Proxy at proxyURL
+func NewKafkaSink(proxyURL, topic string) (*KafkaSink, error) {
+	if proxyURL == "" {
+		return nil, fmt.Errorf("events.kafka.restProxyUrl is required for the kafka sink")
+	}
+	if _, err := url.ParseRequestURI(proxyURL); err != nil {
+		return nil, fmt.Errorf("invalid events.kafka.restProxyUrl: %w", err)
+	}
+	return &KafkaSink{
+		endpoint: strings.TrimSuffix(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		client:   &http.Client{},
+	}, nil
+}
+
+// Publish produces the event and waits for the proxy to report its offset
+func (s *KafkaSink) Publish(ctx context.Context, event Event) error {
+	data, err := encode(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"records": []map[string]any{
+			{"key": strconv.Itoa(event.Record.ChainID), "value": json.RawMessage(data)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode Kafka records: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return fmt.Errorf("failed to read Kafka response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Kafka REST Proxy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.Unmarshal(respBody, &produced); err != nil {
+		return fmt.Errorf("failed to parse Kafka response: %w", err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.Error != nil {
+			return fmt.Errorf("Kafka rejected the event: %s", *offset.Error)
+		}
+	}
+	return nil
+}
+
+// Close releases idle proxy connections
+func (s *KafkaSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
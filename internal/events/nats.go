@@ -0,0 +1,181 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSSink publishes events to a NATS subject using the plain text client protocol
+// The connection is opened on the first publish and reopened after any failure
+type NATSSink struct {
+	url     *url.URL
+	subject string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSSink returns a sink for the NATS server at rawURL (nats:// or tls://, with optional user:pass)
+func NewNATSSink(rawURL, subject string) (*NATSSink, error) {
+	if rawURL == "" {
+		return nil, fmt.Errorf("events.nats.url is required for the nats sink")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid events.nats.url: %w", err)
+	}
+	if parsed.Scheme != "nats" && parsed.Scheme != "tls" {
+		return nil, fmt.Errorf("events.nats.url must start with nats:// or tls://")
+	}
+	if parsed.Port() == "" {
+		parsed.Host = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	if strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("invalid NATS subject %q", subject)
+	}
+	return &NATSSink{url: parsed, subject: subject}, nil
+}
+
+// Publish sends the event and waits for the server to acknowledge it with a PONG
+func (s *NATSSink) Publish(ctx context.Context, event Event) error {
+	data, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(deadline)
+	} else {
+		s.conn.SetDeadline(time.Time{})
+	}
+
+	message := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", s.subject, len(data), data)
+	if _, err := s.conn.Write([]byte(message)); err != nil {
+		s.reset()
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	if err := s.awaitPong(); err != nil {
+		s.reset()
+		return err
+	}
+	return nil
+}
+
+// connect dials the server, reads its INFO and sends CONNECT
+func (s *NATSSink) connect(ctx context.Context) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", s.url.Host)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read NATS server info: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS greeting: %q", strings.TrimSpace(line))
+	}
+	if s.url.Scheme == "tls" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: s.url.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("NATS TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "blockscout-vc-sidecar",
+		"lang":     "go",
+	}
+	if user := s.url.User; user != nil {
+		options["user"] = user.Username()
+		if pass, ok := user.Password(); ok {
+			options["pass"] = pass
+		}
+	}
+	connectOptions, err := json.Marshal(options)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to encode NATS connect options: %w", err)
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\n", connectOptions); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to send NATS connect: %w", err)
+	}
+
+	s.conn = conn
+	s.reader = reader
+	return nil
+}
+
+// awaitPong reads server messages until the PONG answering our PING
+func (s *NATSSink) awaitPong() error {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read NATS reply: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("failed to answer NATS ping: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// INFO updates and +OK are ignored
+	}
+}
+
+// reset drops the connection so the next publish reconnects
+func (s *NATSSink) reset() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = nil
+	s.reader = nil
+}
+
+// Close closes the connection
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	s.reader = nil
+	return err
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/docker"
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type ChainIDHandler struct {
+	BaseHandler
+}
+
+func NewChainIDHandler() *ChainIDHandler {
+	return &ChainIDHandler{
+		BaseHandler: NewBaseHandler(),
+	}
+}
+
+// Handle writes the record's chain ID to the frontend and backend env keys
+// configured under chainIdEnv and restarts the services whose value changed
+func (h *ChainIDHandler) Handle(ctx context.Context, record *Record) HandlerResult {
+	result := HandlerResult{}
+
+	chainID := strconv.Itoa(record.ChainID)
+	services := []struct {
+		serviceName   string
+		containerName string
+		keys          []string
+	}{
+		{
+			serviceName:   config.GetChainString(record.ChainID, "frontendServiceName"),
+			containerName: config.GetChainString(record.ChainID, "frontendContainerName"),
+			keys:          config.GetChainIDFrontendKeys(),
+		},
+		{
+			serviceName:   config.GetChainString(record.ChainID, "backendServiceName"),
+			containerName: config.GetChainString(record.ChainID, "backendContainerName"),
+			keys:          config.GetChainIDBackendKeys(),
+		},
+	}
+
+	for _, service := range services {
+		if len(service.keys) == 0 {
+			continue
+		}
+		updates := make(map[string]string)
+		for _, key := range service.keys {
+			updates[key] = chainID
+		}
+
+		changed, err := h.ApplyServiceChanges(ctx, record.ChainID, map[string]map[string]string{service.serviceName: updates})
+		if err != nil {
+			result.Error = fmt.Errorf("failed to update environment: %w", err)
+			return result
+		}
+		result.ChangedKeys = append(result.ChangedKeys, changed...)
+		if len(changed) > 0 {
+			fmt.Printf("Updated environment with chain ID changes: %+v\n", updates)
+			result.EnvUpdated = true
+			result.ContainersToRestart = append(result.ContainersToRestart, docker.Container{
+				Name:        service.containerName,
+				ServiceName: service.serviceName,
+				ChainID:     record.ChainID,
+			})
+		}
+	}
+
+	return result
+}
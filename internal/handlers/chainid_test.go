@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestChainIDHandlerWritesNothingByDefault(t *testing.T) {
+	envFile := useEnvFile(t, "A=1\n")
+
+	result := NewChainIDHandler().Handle(context.Background(), &Record{ChainID: 1313161555})
+	if result.Error != nil {
+		t.Fatalf("Handle: %v", result.Error)
+	}
+	if result.EnvUpdated || len(result.ContainersToRestart) > 0 {
+		t.Fatalf("unconfigured handler updated env (%t) or restarted %v", result.EnvUpdated, result.ContainersToRestart)
+	}
+	if content, _ := os.ReadFile(envFile); string(content) != "A=1\n" {
+		t.Fatalf("env file changed to %q", content)
+	}
+}
+
+func TestChainIDHandlerWritesKeysAndRestarts(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("chainIdEnv.frontendKeys", "NEXT_PUBLIC_NETWORK_ID")
+	viper.Set("chainIdEnv.backendKeys", "CHAIN_ID")
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+	viper.Set("backendServiceName", "backend")
+	viper.Set("backendContainerName", "backend-1")
+
+	h := NewChainIDHandler()
+	result := h.Handle(context.Background(), &Record{ChainID: 1313161555})
+	if result.Error != nil {
+		t.Fatalf("Handle: %v", result.Error)
+	}
+
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"NEXT_PUBLIC_NETWORK_ID=1313161555", "CHAIN_ID=1313161555"} {
+		if !strings.Contains(string(content), line) {
+			t.Errorf("env file %q is missing %s", content, line)
+		}
+	}
+
+	restarted := map[string]bool{}
+	for _, container := range result.ContainersToRestart {
+		restarted[container.Name] = true
+	}
+	if len(restarted) != 2 || !restarted["frontend-1"] || !restarted["backend-1"] {
+		t.Errorf("restarted %v, want frontend-1 and backend-1", result.ContainersToRestart)
+	}
+
+	// Applying the same chain ID again changes nothing and restarts nothing
+	if again := h.Handle(context.Background(), &Record{ChainID: 1313161555}); len(again.ContainersToRestart) > 0 {
+		t.Errorf("unchanged chain ID restarted %v", again.ContainersToRestart)
+	}
+}
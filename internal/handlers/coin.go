@@ -1,10 +1,10 @@
 package handlers
 
 import (
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/docker"
+	"context"
 	"fmt"
-
-	"github.com/spf13/viper"
 )
 
 // MaxCoinLength defines the maximum allowed length for a coin symbol
@@ -21,7 +21,7 @@ func NewCoinHandler() *CoinHandler {
 }
 
 // Handle processes coin-related changes and updates service configurations
-func (h *CoinHandler) Handle(record *Record) HandlerResult {
+func (h *CoinHandler) Handle(ctx context.Context, record *Record) HandlerResult {
 	result := HandlerResult{}
 
 	if err := h.validateCoin(record.Coin); err != nil {
@@ -31,43 +31,50 @@ func (h *CoinHandler) Handle(record *Record) HandlerResult {
 
 	updates := []EnvUpdate{
 		{
-			ServiceName:   viper.GetString("frontendServiceName"),
+			ServiceName:   config.GetChainString(record.ChainID, "frontendServiceName"),
 			Key:           "NEXT_PUBLIC_NETWORK_CURRENCY_SYMBOL",
 			Value:         record.Coin,
-			ContainerName: viper.GetString("frontendContainerName"),
+			ContainerName: config.GetChainString(record.ChainID, "frontendContainerName"),
 		},
 		{
-			ServiceName:   viper.GetString("backendServiceName"),
+			ServiceName:   config.GetChainString(record.ChainID, "backendServiceName"),
 			Key:           "COIN",
 			Value:         record.Coin,
-			ContainerName: viper.GetString("backendContainerName"),
+			ContainerName: config.GetChainString(record.ChainID, "backendContainerName"),
 		},
 		{
-			ServiceName:   viper.GetString("statsServiceName"),
+			ServiceName:   config.GetChainString(record.ChainID, "statsServiceName"),
 			Key:           "STATS_CHARTS__TEMPLATE_VALUES__NATIVE_COIN_SYMBOL",
 			Value:         record.Coin,
-			ContainerName: viper.GetString("statsContainerName"),
+			ContainerName: config.GetChainString(record.ChainID, "statsContainerName"),
 		},
 	}
 
 	// Apply updates to each service
-	allUpdates := make(map[string]string)
+	serviceUpdates := make(map[string]map[string]string)
 	for _, env := range updates {
-		allUpdates[env.Key] = env.Value
+		if serviceUpdates[env.ServiceName] == nil {
+			serviceUpdates[env.ServiceName] = make(map[string]string)
+		}
+		serviceUpdates[env.ServiceName][env.Key] = env.Value
 	}
 
-	updated, err := h.UpdateEnvFile(allUpdates)
+	changed, err := h.ApplyServiceChanges(ctx, record.ChainID, serviceUpdates)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to update environment: %w", err)
 		return result
 	}
+	updated := len(changed) > 0
+	result.EnvUpdated = updated
+	result.ChangedKeys = changed
 	if updated {
-		fmt.Printf("Updated environment with coin changes: %+v\n", allUpdates)
+		fmt.Printf("Updated environment with coin changes: %+v\n", serviceUpdates)
 		// Add all containers to restart list
 		for _, env := range updates {
 			result.ContainersToRestart = append(result.ContainersToRestart, docker.Container{
 				Name:        env.ContainerName,
 				ServiceName: env.ServiceName,
+				ChainID:     record.ChainID,
 			})
 		}
 	}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// useEnvFile points pathToEnvFile at a temporary file holding content
+func useEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("pathToEnvFile", envFile)
+	t.Cleanup(viper.Reset)
+	return envFile
+}
+
+func TestImageHandlerCancelledContextAbortsRequest(t *testing.T) {
+	useEnvFile(t, "")
+	viper.Set("imageValidation.allowPrivate", true)
+
+	requested := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-requested
+		cancel()
+	}()
+
+	start := time.Now()
+	result := NewImageHandler().Handle(ctx, &Record{ChainID: 1, LightLogoURL: server.URL + "/logo.png"})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("handler ran for %s after its context was cancelled", elapsed)
+	}
+	if !errors.Is(result.Error, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", result.Error)
+	}
+	if result.EnvUpdated {
+		t.Fatal("env must not be updated after cancellation")
+	}
+}
+
+func TestApplyServiceChangesSkipsWriteWhenCancelled(t *testing.T) {
+	envFile := useEnvFile(t, "A=1\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h := NewBaseHandler()
+	if _, err := h.ApplyServiceChanges(ctx, 1, map[string]map[string]string{"frontend": {"A": "2"}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "A=1\n" {
+		t.Fatalf("env file changed to %q", data)
+	}
+}
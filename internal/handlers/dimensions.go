@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF decoder for image.DecodeConfig
+	_ "image/jpeg" // Register JPEG decoder for image.DecodeConfig
+	_ "image/png"  // Register PNG decoder for image.DecodeConfig
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// MaxImageDownloadSize caps how much of an image is downloaded for dimension checks
+const MaxImageDownloadSize = 10 << 20
+
+// validateDimensions downloads the image and checks its size and aspect ratio
+// against the imageValidation limits. It only runs when imageValidation.checkDimensions is set
+func (h *ImageHandler) validateDimensions(ctx context.Context, imageURL string) error {
+	if !viper.GetBool("imageValidation.checkDimensions") {
+		return nil
+	}
+
+	if err := checkImageHost(imageURL); err != nil {
+		return err
+	}
+
+	release, err := acquireImageSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to download image: %w", err)
+	}
+	defer release()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid image request: %w", err)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download image: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("image not downloadable, status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageDownloadSize))
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+
+	width, height, err := decodeImageDimensions(data)
+	if err != nil {
+		return err
+	}
+
+	return checkDimensions(width, height)
+}
+
+// decodeImageDimensions returns the width and height of a PNG, JPEG, GIF or SVG image
+func decodeImageDimensions(data []byte) (int, int, error) {
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		return cfg.Width, cfg.Height, nil
+	}
+	return decodeSVGDimensions(data)
+}
+
+// decodeSVGDimensions reads the width and height attributes of the root svg element,
+// falling back to the viewBox when they are missing or not in pixels
+func decodeSVGDimensions(data []byte) (int, int, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, fmt.Errorf("unsupported image format")
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "svg" {
+			return 0, 0, fmt.Errorf("unsupported image format")
+		}
+
+		var width, height float64
+		var viewBox string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "width":
+				width = parseSVGLength(attr.Value)
+			case "height":
+				height = parseSVGLength(attr.Value)
+			case "viewBox":
+				viewBox = attr.Value
+			}
+		}
+
+		if (width <= 0 || height <= 0) && viewBox != "" {
+			fields := strings.Fields(strings.ReplaceAll(viewBox, ",", " "))
+			if len(fields) == 4 {
+				width, _ = strconv.ParseFloat(fields[2], 64)
+				height, _ = strconv.ParseFloat(fields[3], 64)
+			}
+		}
+
+		if width <= 0 || height <= 0 {
+			return 0, 0, fmt.Errorf("SVG has no usable width/height or viewBox")
+		}
+		return int(math.Round(width)), int(math.Round(height)), nil
+	}
+}
+
+// parseSVGLength parses a unitless or pixel SVG length, returning 0 for other units
+func parseSVGLength(value string) float64 {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	length, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return length
+}
+
+// checkDimensions validates width, height and aspect ratio (width/height) against
+// the configured limits. A zero limit is treated as unset
+func checkDimensions(width, height int) error {
+	minWidth := viper.GetInt("imageValidation.minWidth")
+	maxWidth := viper.GetInt("imageValidation.maxWidth")
+	minHeight := viper.GetInt("imageValidation.minHeight")
+	maxHeight := viper.GetInt("imageValidation.maxHeight")
+	minAspectRatio := viper.GetFloat64("imageValidation.minAspectRatio")
+	maxAspectRatio := viper.GetFloat64("imageValidation.maxAspectRatio")
+
+	if minWidth > 0 && width < minWidth {
+		return fmt.Errorf("image width %d is below minimum %d", width, minWidth)
+	}
+	if maxWidth > 0 && width > maxWidth {
+		return fmt.Errorf("image width %d exceeds maximum %d", width, maxWidth)
+	}
+	if minHeight > 0 && height < minHeight {
+		return fmt.Errorf("image height %d is below minimum %d", height, minHeight)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		return fmt.Errorf("image height %d exceeds maximum %d", height, maxHeight)
+	}
+
+	if height == 0 {
+		return fmt.Errorf("image height cannot be zero")
+	}
+	aspectRatio := float64(width) / float64(height)
+	if minAspectRatio > 0 && aspectRatio < minAspectRatio {
+		return fmt.Errorf("image aspect ratio %.2f is below minimum %.2f", aspectRatio, minAspectRatio)
+	}
+	if maxAspectRatio > 0 && aspectRatio > maxAspectRatio {
+		return fmt.Errorf("image aspect ratio %.2f exceeds maximum %.2f", aspectRatio, maxAspectRatio)
+	}
+
+	return nil
+}
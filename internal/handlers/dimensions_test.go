@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// encodeImage returns a width x height image encoded with encode
+func encodeImage(t *testing.T, width, height int, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(buf *bytes.Buffer, img image.Image) error  { return png.Encode(buf, img) }
+func encodeJPEG(buf *bytes.Buffer, img image.Image) error { return jpeg.Encode(buf, img, nil) }
+func encodeGIF(buf *bytes.Buffer, img image.Image) error  { return gif.Encode(buf, img, nil) }
+
+func TestDecodeImageDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantWidth  int
+		wantHeight int
+	}{
+		{"png", encodeImage(t, 200, 100, encodePNG), 200, 100},
+		{"jpeg", encodeImage(t, 64, 32, encodeJPEG), 64, 32},
+		{"gif", encodeImage(t, 16, 16, encodeGIF), 16, 16},
+		{"svg attributes", []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="120px" height="40"></svg>`), 120, 40},
+		{"svg viewBox", []byte(`<?xml version="1.0"?><svg viewBox="0 0 300 150" width="100%"></svg>`), 300, 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := decodeImageDimensions(tt.data)
+			if err != nil {
+				t.Fatalf("decodeImageDimensions: %v", err)
+			}
+			if width != tt.wantWidth || height != tt.wantHeight {
+				t.Errorf("dimensions = %dx%d, want %dx%d", width, height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+
+	if _, _, err := decodeImageDimensions([]byte("<html></html>")); err == nil {
+		t.Error("decodeImageDimensions accepted HTML")
+	}
+}
+
+func TestValidateDimensions(t *testing.T) {
+	images := map[string][]byte{
+		"/square.png": encodeImage(t, 256, 256, encodePNG),
+		"/tiny.png":   encodeImage(t, 8, 8, encodePNG),
+		"/wide.png":   encodeImage(t, 400, 100, encodePNG),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(images[r.URL.Path])
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"/square.png", ""},
+		{"/tiny.png", "below minimum"},
+		{"/wide.png", "aspect ratio 4.00 exceeds maximum 2.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("imageValidation.allowPrivate", true)
+			viper.Set("imageValidation.checkDimensions", true)
+			viper.Set("imageValidation.minWidth", 32)
+			viper.Set("imageValidation.maxWidth", 512)
+			viper.Set("imageValidation.maxAspectRatio", 2.0)
+
+			err := NewImageHandler().validateDimensions(context.Background(), server.URL+tt.path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateDimensions: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateDimensions() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDimensionsDisabled(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	// Nothing is downloaded unless imageValidation.checkDimensions is set
+	if err := NewImageHandler().validateDimensions(context.Background(), "http://invalid.invalid/logo.png"); err != nil {
+		t.Fatalf("validateDimensions() = %v, want nil when disabled", err)
+	}
+}
@@ -1,12 +1,12 @@
 package handlers
 
 import (
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/docker"
+	"context"
 	"fmt"
 	"net/url"
 	"strings"
-
-	"github.com/spf13/viper"
 )
 
 // MaxExplorerURLLength defines the maximum allowed length for an explorer URL
@@ -23,55 +23,38 @@ func NewExplorerHandler() *ExplorerHandler {
 }
 
 // Handle processes explorer URL changes and updates service configurations
-func (h *ExplorerHandler) Handle(record *Record) HandlerResult {
+func (h *ExplorerHandler) Handle(ctx context.Context, record *Record) HandlerResult {
 	result := HandlerResult{}
 
-	if err := h.validateExplorerURL(record.ExplorerURL); err != nil {
-		result.Error = fmt.Errorf("invalid explorer URL: %w", err)
+	// Skip if no explorer URL is set yet, e.g. on a freshly created chain row
+	if skipEmptyExplorerURL(record.ExplorerURL) {
+		fmt.Printf("Explorer URL is empty for chain %d, skipping explorer handler\n", record.ChainID)
 		return result
 	}
 
-	// Extract host from explorer URL
-	host, err := h.extractHostFromURL(record.ExplorerURL)
+	serviceUpdates, err := h.computeUpdates(record)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to extract host from explorer URL: %w", err)
+		result.Error = err
 		return result
 	}
 
-	// Extract protocol from explorer URL
-	protocol := h.extractProtocolFromURL(record.ExplorerURL)
-
-	// Get service names from config with defaults for backward compatibility
-	frontendServiceName := viper.GetString("frontendServiceName")
-	frontendContainerName := viper.GetString("frontendContainerName")
-	backendServiceName := viper.GetString("backendServiceName")
-	backendContainerName := viper.GetString("backendContainerName")
-	statsServiceName := viper.GetString("statsServiceName")
-	statsContainerName := viper.GetString("statsContainerName")
+	// Get service names from config, honouring per-chain overrides
+	frontendServiceName := config.GetChainString(record.ChainID, "frontendServiceName")
+	frontendContainerName := config.GetChainString(record.ChainID, "frontendContainerName")
+	backendServiceName := config.GetChainString(record.ChainID, "backendServiceName")
+	backendContainerName := config.GetChainString(record.ChainID, "backendContainerName")
+	statsServiceName := config.GetChainString(record.ChainID, "statsServiceName")
+	statsContainerName := config.GetChainString(record.ChainID, "statsContainerName")
 
 	// Get proxy service configuration - only restart if both are present
-	proxyServiceName := viper.GetString("proxyServiceName")
-	proxyContainerName := viper.GetString("proxyContainerName")
+	proxyServiceName := config.GetChainString(record.ChainID, "proxyServiceName")
+	proxyContainerName := config.GetChainString(record.ChainID, "proxyContainerName")
 
 	fmt.Printf("proxyServiceName='%s', proxyContainerName='%s'\n", proxyServiceName, proxyContainerName)
+	host := serviceUpdates[frontendServiceName]["BLOCKSCOUT_HOST"]
 
-	// Update the sidecar-injected.env file with all explorer-related environment variables
-	// This file is loaded by all services and will override values from other env files
-	sidecarUpdates := map[string]string{
-		"BLOCKSCOUT_HOST":                    host,
-		"MICROSERVICE_VISUALIZE_SOL2UML_URL": fmt.Sprintf("%s://visualize.%s", protocol, host),
-		"NEXT_PUBLIC_FEATURED_NETWORKS":      fmt.Sprintf(`[{'title':'Aurora','url':'https://explorer.aurora.dev/','group':'Mainnets'}, {'title':'%s','url':'%s://%s','group':'Mainnets', 'isActive':true}]`, record.Name, protocol, host),
-		"NEXT_PUBLIC_API_HOST":               host,
-		"NEXT_PUBLIC_APP_HOST":               host,
-		"NEXT_PUBLIC_STATS_API_HOST":         fmt.Sprintf("%s://%s", protocol, host),
-		"NEXT_PUBLIC_VISUALIZE_API_HOST":     fmt.Sprintf("%s://%s", protocol, host),
-		"STATS__BLOCKSCOUT_API_URL":          fmt.Sprintf("%s://%s", protocol, host),
-		"EXPLORER_URL":                       host,
-		"BLOCKSCOUT_HTTP_PROTOCOL":           protocol,
-	}
-
-	// Apply updates to the sidecar-injected.env file
-	updated, err := h.UpdateEnvFile(sidecarUpdates)
+	// Apply updates to the sidecar-injected.env file (or the compose override)
+	changed, err := h.ApplyServiceChanges(ctx, record.ChainID, serviceUpdates)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to update sidecar-injected environment: %w", err)
 		return result
@@ -79,6 +62,9 @@ func (h *ExplorerHandler) Handle(record *Record) HandlerResult {
 
 	// If any environment variables were updated, restart all services
 	containersToRestart := []docker.Container{}
+	updated := len(changed) > 0
+	result.EnvUpdated = updated
+	result.ChangedKeys = changed
 	if updated {
 		fmt.Printf("Updated explorer host to: %s\n", host)
 
@@ -86,14 +72,17 @@ func (h *ExplorerHandler) Handle(record *Record) HandlerResult {
 			{
 				Name:        backendContainerName,
 				ServiceName: backendServiceName,
+				ChainID:     record.ChainID,
 			},
 			{
 				Name:        frontendContainerName,
 				ServiceName: frontendServiceName,
+				ChainID:     record.ChainID,
 			},
 			{
 				Name:        statsContainerName,
 				ServiceName: statsServiceName,
+				ChainID:     record.ChainID,
 			},
 		}
 
@@ -103,6 +92,7 @@ func (h *ExplorerHandler) Handle(record *Record) HandlerResult {
 			containersToRestart = append(containersToRestart, docker.Container{
 				Name:        proxyContainerName,
 				ServiceName: proxyServiceName,
+				ChainID:     record.ChainID,
 			})
 		}
 	}
@@ -111,6 +101,69 @@ func (h *ExplorerHandler) Handle(record *Record) HandlerResult {
 	return result
 }
 
+// computeUpdates validates the explorer URL and returns the variables to write for each
+// affected service, without touching the env file
+func (h *ExplorerHandler) computeUpdates(record *Record) (map[string]map[string]string, error) {
+	if err := h.validateExplorerURL(record.ExplorerURL); err != nil {
+		return nil, fmt.Errorf("invalid explorer URL: %w", err)
+	}
+
+	// Extract host from explorer URL
+	host, err := h.extractHostFromURL(record.ExplorerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract host from explorer URL: %w", err)
+	}
+
+	// Extract protocol from explorer URL
+	protocol := h.extractProtocolFromURL(record.ExplorerURL)
+
+	// Update the sidecar-injected.env file with all explorer-related environment variables
+	// This file is loaded by all services and will override values from other env files
+	sidecarUpdates := map[string]string{
+		"BLOCKSCOUT_HOST":                    host,
+		"MICROSERVICE_VISUALIZE_SOL2UML_URL": fmt.Sprintf("%s://visualize.%s", protocol, host),
+		"NEXT_PUBLIC_FEATURED_NETWORKS":      featuredNetworks(record.Name, fmt.Sprintf("%s://%s", protocol, host)),
+		"NEXT_PUBLIC_API_HOST":               host,
+		"NEXT_PUBLIC_APP_HOST":               host,
+		"NEXT_PUBLIC_STATS_API_HOST":         fmt.Sprintf("%s://%s", protocol, host),
+		"NEXT_PUBLIC_VISUALIZE_API_HOST":     fmt.Sprintf("%s://%s", protocol, host),
+		"STATS__BLOCKSCOUT_API_URL":          fmt.Sprintf("%s://%s", protocol, host),
+		"EXPLORER_URL":                       host,
+		"BLOCKSCOUT_HTTP_PROTOCOL":           protocol,
+	}
+
+	// The existing variables each hold a single host, so additional hosts kept alive during a
+	// migration go to dedicated lists. They are only written when configured, so deployments
+	// without additional hosts see no new keys and nothing restarts on upgrade
+	if additional := config.GetExplorerAdditionalHosts(); len(additional) > 0 {
+		hosts := h.buildHostList(host, additional)
+		origins := make([]string, 0, len(hosts))
+		for _, hostEntry := range hosts {
+			origins = append(origins, fmt.Sprintf("%s://%s", protocol, hostEntry))
+		}
+		sidecarUpdates["EXPLORER_HOSTS"] = strings.Join(hosts, ",")
+		sidecarUpdates["EXPLORER_ORIGINS"] = strings.Join(origins, ",")
+	}
+
+	// All explorer variables apply to every affected service
+	serviceUpdates := map[string]map[string]string{
+		config.GetChainString(record.ChainID, "backendServiceName"):  sidecarUpdates,
+		config.GetChainString(record.ChainID, "frontendServiceName"): sidecarUpdates,
+		config.GetChainString(record.ChainID, "statsServiceName"):    sidecarUpdates,
+	}
+	proxyServiceName := config.GetChainString(record.ChainID, "proxyServiceName")
+	if proxyServiceName != "" && config.GetChainString(record.ChainID, "proxyContainerName") != "" {
+		serviceUpdates[proxyServiceName] = sidecarUpdates
+	}
+	return serviceUpdates, nil
+}
+
+// skipEmptyExplorerURL reports whether an empty explorer URL should skip the handler
+// rather than fail it (explorer.requireURL unset)
+func skipEmptyExplorerURL(explorerURL string) bool {
+	return explorerURL == "" && !config.GetExplorerRequireURL()
+}
+
 // validateExplorerURL checks if the explorer URL meets the required criteria
 func (h *ExplorerHandler) validateExplorerURL(explorerURL string) error {
 	if explorerURL == "" {
@@ -121,12 +174,12 @@ func (h *ExplorerHandler) validateExplorerURL(explorerURL string) error {
 	}
 
 	// Validate URL format
-	parsedURL, err := url.Parse(explorerURL)
+	parsedURL, err := url.Parse(withDefaultProtocol(explorerURL))
 	if err != nil {
 		return fmt.Errorf("invalid URL format: %w", err)
 	}
 	if parsedURL.Scheme == "" {
-		return fmt.Errorf("URL must include scheme (http:// or https://)")
+		return fmt.Errorf("URL must include a valid scheme")
 	}
 	if parsedURL.Host == "" {
 		return fmt.Errorf("URL must include a valid host")
@@ -135,9 +188,18 @@ func (h *ExplorerHandler) validateExplorerURL(explorerURL string) error {
 	return nil
 }
 
+// withDefaultProtocol prefixes a URL that has no scheme, e.g. "explorer.example.com",
+// with explorer.defaultProtocol so it can be validated and parsed like a full URL
+func withDefaultProtocol(urlStr string) string {
+	if urlStr == "" || strings.Contains(urlStr, "://") {
+		return urlStr
+	}
+	return config.GetExplorerDefaultProtocol() + "://" + urlStr
+}
+
 // extractHostFromURL extracts the host from a URL string
 func (h *ExplorerHandler) extractHostFromURL(urlStr string) (string, error) {
-	parsedURL, err := url.Parse(urlStr)
+	parsedURL, err := url.Parse(withDefaultProtocol(urlStr))
 	if err != nil {
 		return "", fmt.Errorf("failed to parse URL: %w", err)
 	}
@@ -151,15 +213,32 @@ func (h *ExplorerHandler) extractHostFromURL(urlStr string) (string, error) {
 	return host, nil
 }
 
-// extractProtocolFromURL extracts the protocol (http or https) from a URL string
+// buildHostList returns the primary host followed by the additional hosts,
+// skipping duplicates of the primary host
+func (h *ExplorerHandler) buildHostList(primary string, additional []string) []string {
+	hosts := []string{primary}
+	seen := map[string]struct{}{primary: {}}
+	for _, host := range additional {
+		if _, exists := seen[host]; exists {
+			continue
+		}
+		seen[host] = struct{}{}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// extractProtocolFromURL extracts the protocol (http or https) from a URL string,
+// falling back to explorer.defaultProtocol when parsing fails or the scheme is absent
 func (h *ExplorerHandler) extractProtocolFromURL(urlStr string) string {
-	parsedURL, err := url.Parse(urlStr)
+	parsedURL, err := url.Parse(withDefaultProtocol(urlStr))
 	if err != nil {
-		return "https" // Default to https if parsing fails
+		return config.GetExplorerDefaultProtocol()
 	}
 
-	if parsedURL.Scheme == "http" {
-		return "http"
+	switch parsedURL.Scheme {
+	case "http", "https":
+		return parsedURL.Scheme
 	}
-	return "https" // Default to https if no scheme is found
+	return config.GetExplorerDefaultProtocol()
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"blockscout-vc/internal/env"
+
+	"github.com/spf13/viper"
+)
+
+func TestExplorerSchemelessURLUsesDefaultProtocol(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("frontendServiceName", "frontend")
+
+	tests := []struct {
+		name            string
+		explorerURL     string
+		defaultProtocol string
+		wantHost        string
+		wantProtocol    string
+	}{
+		{"schemeless uses https by default", "explorer.example.com", "", "explorer.example.com", "https"},
+		{"schemeless uses configured http", "explorer.example.com:8080", "http", "explorer.example.com", "http"},
+		{"explicit scheme wins", "http://explorer.example.com", "https", "explorer.example.com", "http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("explorer.defaultProtocol", tt.defaultProtocol)
+			h := NewExplorerHandler()
+
+			if err := h.validateExplorerURL(tt.explorerURL); err != nil {
+				t.Fatalf("validateExplorerURL(%q) = %v", tt.explorerURL, err)
+			}
+			updates, err := h.computeUpdates(&Record{ChainID: 1, ExplorerURL: tt.explorerURL})
+			if err != nil {
+				t.Fatalf("computeUpdates: %v", err)
+			}
+			vars := updates["frontend"]
+			if vars["BLOCKSCOUT_HOST"] != tt.wantHost {
+				t.Errorf("BLOCKSCOUT_HOST = %q, want %q", vars["BLOCKSCOUT_HOST"], tt.wantHost)
+			}
+			if vars["BLOCKSCOUT_HTTP_PROTOCOL"] != tt.wantProtocol {
+				t.Errorf("BLOCKSCOUT_HTTP_PROTOCOL = %q, want %q", vars["BLOCKSCOUT_HTTP_PROTOCOL"], tt.wantProtocol)
+			}
+		})
+	}
+}
+
+func TestValidateExplorerURLRejectsInvalid(t *testing.T) {
+	h := NewExplorerHandler()
+	for _, explorerURL := range []string{"", "https://", "://explorer.example.com"} {
+		if err := h.validateExplorerURL(explorerURL); err == nil {
+			t.Errorf("validateExplorerURL(%q) = nil, want an error", explorerURL)
+		}
+	}
+}
+
+func TestExplorerAdditionalHostsInHostLists(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("explorer.additionalHosts", "old.example.com, new.example.com")
+
+	updates, err := NewExplorerHandler().computeUpdates(&Record{ChainID: 1, ExplorerURL: "https://new.example.com"})
+	if err != nil {
+		t.Fatalf("computeUpdates: %v", err)
+	}
+	vars := updates["frontend"]
+
+	// The primary host comes first and is not repeated
+	if got, want := vars["EXPLORER_HOSTS"], "new.example.com,old.example.com"; got != want {
+		t.Errorf("EXPLORER_HOSTS = %q, want %q", got, want)
+	}
+	if got, want := vars["EXPLORER_ORIGINS"], "https://new.example.com,https://old.example.com"; got != want {
+		t.Errorf("EXPLORER_ORIGINS = %q, want %q", got, want)
+	}
+	if got, want := vars["BLOCKSCOUT_HOST"], "new.example.com"; got != want {
+		t.Errorf("BLOCKSCOUT_HOST = %q, want %q", got, want)
+	}
+}
+
+func TestExplorerWithoutAdditionalHostsWritesNoHostLists(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+	record := &Record{ChainID: 1, Name: "Aurora", ExplorerURL: "https://explorer.example.com"}
+
+	h := NewExplorerHandler()
+	if result := h.Handle(context.Background(), record); result.Error != nil {
+		t.Fatalf("Handle: %v", result.Error)
+	}
+
+	// Exactly the keys written before explorer.additionalHosts existed, so upgrading
+	// rewrites nothing and restarts nothing
+	e := &env.Env{PathToEnvFile: envFile, EnvFile: make(map[string]string)}
+	if err := e.ReadEnvFile(); err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(e.EnvFile))
+	for key := range e.EnvFile {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	want := []string{
+		"BLOCKSCOUT_HOST", "BLOCKSCOUT_HTTP_PROTOCOL", "EXPLORER_URL", "MICROSERVICE_VISUALIZE_SOL2UML_URL",
+		"NEXT_PUBLIC_API_HOST", "NEXT_PUBLIC_APP_HOST", "NEXT_PUBLIC_FEATURED_NETWORKS",
+		"NEXT_PUBLIC_STATS_API_HOST", "NEXT_PUBLIC_VISUALIZE_API_HOST", "STATS__BLOCKSCOUT_API_URL",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("env keys = %v, want %v", keys, want)
+	}
+
+	before, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := h.Handle(context.Background(), record)
+	if again.Error != nil || again.EnvUpdated || len(again.ContainersToRestart) > 0 {
+		t.Errorf("repeated Handle = %+v, want no change", again)
+	}
+	if after, _ := os.ReadFile(envFile); string(after) != string(before) {
+		t.Errorf("env file changed from %q to %q", before, after)
+	}
+}
@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/netguard"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/viper"
@@ -14,23 +21,61 @@ import (
 // MaxImageLength defines the maximum allowed length for image URLs
 const MaxImageLength = 2000
 
+// DefaultImageMaxConcurrent is the default limit on simultaneous image validation requests
+const DefaultImageMaxConcurrent = 4
+
+var (
+	imageRequestSlots     chan struct{}
+	imageRequestSlotsOnce sync.Once
+)
+
+// acquireImageSlot blocks until an image validation request may run or ctx is done and
+// returns its release func. The limit comes from imageValidation.maxConcurrent and is
+// shared by all handler instances
+func acquireImageSlot(ctx context.Context) (func(), error) {
+	imageRequestSlotsOnce.Do(func() {
+		limit := viper.GetInt("imageValidation.maxConcurrent")
+		if limit <= 0 {
+			limit = DefaultImageMaxConcurrent
+		}
+		imageRequestSlots = make(chan struct{}, limit)
+	})
+
+	select {
+	case imageRequestSlots <- struct{}{}:
+		return func() { <-imageRequestSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 type ImageHandler struct {
 	BaseHandler
 	client *http.Client
 }
 
 func NewImageHandler() *ImageHandler {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		// CheckURL only pre-checks the host; the transport checks the address actually dialed
+		Transport: netguard.NewTransport(config.GetImageAllowPrivate(), &tls.Config{MinVersion: config.GetTLSMinVersion()}),
+	}
+	if !config.GetImageAllowPrivate() {
+		client.CheckRedirect = netguard.CheckRedirect
+	}
+	if len(config.GetImageAllowedHosts()) > 0 {
+		client.CheckRedirect = checkImageRedirect
+	}
+
 	return &ImageHandler{
 		BaseHandler: NewBaseHandler(),
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client:      client,
 	}
 }
 
 // Handle processes image-related changes and updates service configurations
 // It handles light logo, dark logo, and favicon URL updates
-func (h *ImageHandler) Handle(record *Record) HandlerResult {
+func (h *ImageHandler) Handle(ctx context.Context, record *Record) HandlerResult {
 	result := HandlerResult{}
 
 	// Skip if no image URLs are provided
@@ -38,84 +83,104 @@ func (h *ImageHandler) Handle(record *Record) HandlerResult {
 		return result
 	}
 
-	frontendServiceName := viper.GetString("frontendServiceName")
-	frontendContainerName := viper.GetString("frontendContainerName")
+	frontendServiceName := config.GetChainString(record.ChainID, "frontendServiceName")
+	frontendContainerName := config.GetChainString(record.ChainID, "frontendContainerName")
 
 	// Initialize updates with string map
 	updates := map[string]map[string]string{
 		frontendServiceName: make(map[string]string),
 	}
 
-	// Validate and update light logo URL
-	if err := h.validateImage(record.LightLogoURL); err != nil {
-		result.Error = fmt.Errorf("invalid light logo URL: %w", err)
-	} else {
-		updates[frontendServiceName]["NEXT_PUBLIC_NETWORK_LOGO"] = record.LightLogoURL
-	}
-
-	// Validate and update dark logo URL
-	if err := h.validateImage(record.DarkLogoURL); err != nil {
-		result.Error = fmt.Errorf("invalid dark logo URL: %w", err)
-	} else {
-		updates[frontendServiceName]["NEXT_PUBLIC_NETWORK_LOGO_DARK"] = record.DarkLogoURL
+	// Read current values so only fields that actually changed are validated
+	currentEnv, err := h.CurrentEnvVars(record.ChainID, frontendServiceName)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to read environment: %w", err)
+		return result
 	}
 
-	// Validate and update favicon URL
-	if err := h.validateImage(record.FaviconURL); err != nil {
-		result.Error = fmt.Errorf("invalid favicon URL: %w", err)
-	} else {
-		updates[frontendServiceName]["NEXT_PUBLIC_NETWORK_ICON"] = record.FaviconURL
+	images := []struct {
+		key      string
+		field    string
+		url      string
+		validate func(context.Context, string) error
+	}{
+		{key: "NEXT_PUBLIC_NETWORK_LOGO", field: "light logo URL", url: record.LightLogoURL, validate: h.validateLogo},
+		{key: "NEXT_PUBLIC_NETWORK_LOGO_DARK", field: "dark logo URL", url: record.DarkLogoURL, validate: h.validateLogo},
+		{key: "NEXT_PUBLIC_NETWORK_ICON", field: "favicon URL", url: record.FaviconURL, validate: h.validateImage},
 	}
 
-	// Apply updates to services
-	allUpdates := make(map[string]string)
-	for _, env := range updates {
-		for key, value := range env {
-			allUpdates[key] = value
+	for _, image := range images {
+		// Empty or unchanged fields are left as they are, so e.g. a favicon-only change succeeds
+		if image.url == "" {
+			continue
+		}
+		if image.url == currentEnv[image.key] {
+			h.ClaimKeys(image.key)
+			continue
+		}
+		if err := image.validate(ctx, image.url); err != nil {
+			result.Error = fmt.Errorf("invalid %s: %w", image.field, err)
+			continue
 		}
+		updates[frontendServiceName][image.key] = image.url
 	}
 
-	updated, err := h.UpdateEnvFile(allUpdates)
+	// Apply updates to services
+	changed, err := h.ApplyServiceChanges(ctx, record.ChainID, updates)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to update environment: %w", err)
 		return result
 	}
+	updated := len(changed) > 0
+	result.EnvUpdated = updated
+	result.ChangedKeys = changed
 	if updated {
-		fmt.Printf("Updated environment with image changes: %+v\n", allUpdates)
+		fmt.Printf("Updated environment with image changes: %+v\n", updates)
 		fmt.Printf("Frontend container name: %s\n", frontendContainerName)
 		fmt.Printf("Frontend service name: %s\n", frontendServiceName)
 		result.ContainersToRestart = append(result.ContainersToRestart, docker.Container{
 			Name:        frontendContainerName,
 			ServiceName: frontendServiceName,
+			ChainID:     record.ChainID,
 		})
 	}
 
 	return result
 }
 
+// validateLogo validates a logo URL like any image and additionally checks
+// its dimensions when imageValidation.checkDimensions is enabled
+func (h *ImageHandler) validateLogo(ctx context.Context, imageURL string) error {
+	if err := h.validateImage(ctx, imageURL); err != nil {
+		return err
+	}
+	return h.validateDimensions(ctx, imageURL)
+}
+
 // validateImage checks if the image URL meets the required criteria
-func (h *ImageHandler) validateImage(imageURL string) error {
+func (h *ImageHandler) validateImage(ctx context.Context, imageURL string) error {
 	if imageURL == "" {
 		return fmt.Errorf("image cannot be empty")
 	}
 
-	if len(imageURL) > MaxImageLength {
-		return fmt.Errorf("image length cannot exceed %d characters", MaxImageLength)
+	if err := validateImageURLFormat(imageURL); err != nil {
+		return err
+	}
+	if err := checkImageHost(imageURL); err != nil {
+		return err
 	}
 
-	// Parse and validate URL
-	parsedURL, err := url.Parse(imageURL)
+	// Check if image is accessible
+	release, err := acquireImageSlot(ctx)
 	if err != nil {
-		return fmt.Errorf("invalid URL format: %w", err)
+		return fmt.Errorf("failed to access image: %w", err)
 	}
-
-	// Check if scheme is http or https
-	if !strings.HasPrefix(parsedURL.Scheme, "http") {
-		return fmt.Errorf("URL must start with http:// or https://")
+	defer release()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, imageURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid image request: %w", err)
 	}
-
-	// Check if image is accessible
-	resp, err := h.client.Head(imageURL)
+	resp, err := h.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to access image: %w", err)
 	}
@@ -134,6 +199,93 @@ func (h *ImageHandler) validateImage(imageURL string) error {
 	if !strings.HasPrefix(contentType, "image/") {
 		return fmt.Errorf("URL does not point to an image (content-type: %s)", contentType)
 	}
+	if err := h.validateContentType(contentType); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// checkImageHost rejects image URLs whose host is not in imageValidation.allowedHosts,
+// and those resolving to private, loopback or link-local addresses unless
+// imageValidation.allowPrivate is set
+func checkImageHost(imageURL string) error {
+	if err := checkAllowedHost(imageURL); err != nil {
+		return err
+	}
+	if config.GetImageAllowPrivate() {
+		return nil
+	}
+	return netguard.CheckURL(imageURL)
+}
+
+// checkImageRedirect applies checkImageHost to every redirect target
+func checkImageRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return checkImageHost(req.URL.String())
+}
+
+// checkAllowedHost rejects image URLs whose host does not match one of the
+// configured host suffixes; any host is allowed when none are configured
+func checkAllowedHost(imageURL string) error {
+	allowedHosts := config.GetImageAllowedHosts()
+	if len(allowedHosts) == 0 {
+		return nil
+	}
+
+	parsedURL, err := url.Parse(imageURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL format: %w", err)
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsedURL.Hostname()), ".")
+	for _, allowed := range allowedHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("image host %q is not in the allowed hosts", host)
+}
+
+// validateImageURLFormat checks the image URL length and scheme without fetching it
+func validateImageURLFormat(imageURL string) error {
+	if len(imageURL) > MaxImageLength {
+		return fmt.Errorf("image length cannot exceed %d characters", MaxImageLength)
+	}
+
+	// Parse and validate URL
+	parsedURL, err := url.Parse(imageURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL format: %w", err)
+	}
+
+	// Check if scheme is http or https
+	if !strings.HasPrefix(parsedURL.Scheme, "http") {
+		return fmt.Errorf("URL must start with http:// or https://")
+	}
+
+	return nil
+}
+
+// validateContentType restricts images to the configured allowed types
+// When no allowed types are configured, any image/* type is accepted
+func (h *ImageHandler) validateContentType(contentType string) error {
+	allowedTypes := config.GetImageAllowedTypes()
+	if len(allowedTypes) == 0 {
+		return nil
+	}
+
+	// Strip parameters such as charset before comparing
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content-type %q: %w", contentType, err)
+	}
+
+	for _, allowed := range allowedTypes {
+		if mediaType == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("image content-type %s is not allowed (allowed: %s)", mediaType, strings.Join(allowedTypes, ","))
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/netguard"
+
+	"github.com/spf13/viper"
+)
+
+// imageServer serves HEAD requests with the content type named by the "type" query parameter
+func imageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestValidateImageAllowedTypes(t *testing.T) {
+	server := imageServer(t)
+	tests := []struct {
+		name         string
+		allowedTypes string
+		contentType  string
+		wantErr      string
+	}{
+		{"any image type when unset", "", "image/x-icon", ""},
+		{"non-image rejected when unset", "", "text/html", "does not point to an image"},
+		{"listed type accepted", "image/png,image/svg+xml", "image/svg+xml", ""},
+		{"parameters ignored", "image/png", "image/png; charset=binary", ""},
+		{"valid but unlisted image type rejected", "image/png,image/jpeg", "image/x-icon", "is not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("imageValidation.allowPrivate", true)
+			viper.Set("imageValidation.allowedTypes", tt.allowedTypes)
+
+			err := NewImageHandler().validateImage(context.Background(), server.URL+"/logo?type="+url.QueryEscape(tt.contentType))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateImage: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateImage() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageHandlerFaviconOnlyUpdate(t *testing.T) {
+	server := imageServer(t)
+	// The logos in the env file point at a host that is never contacted
+	envFile := useEnvFile(t, "NEXT_PUBLIC_NETWORK_LOGO=http://invalid.invalid/logo.png\n")
+	viper.Set("imageValidation.allowPrivate", true)
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+
+	favicon := server.URL + "/favicon?type=image/png"
+	result := NewImageHandler().Handle(context.Background(), &Record{
+		ChainID:      1,
+		LightLogoURL: "http://invalid.invalid/logo.png", // unchanged
+		FaviconURL:   favicon,                           // dark logo left empty
+	})
+	if result.Error != nil {
+		t.Fatalf("Handle: %v", result.Error)
+	}
+	if !result.EnvUpdated || len(result.ContainersToRestart) != 1 {
+		t.Fatalf("result = %+v, want the favicon written and the frontend restarted", result)
+	}
+
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), favicon) {
+		t.Errorf("env file %q is missing the favicon", content)
+	}
+	if strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_LOGO_DARK") {
+		t.Errorf("env file %q has the empty dark logo written", content)
+	}
+}
+
+func TestImageValidationConcurrencyLimit(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("imageValidation.allowPrivate", true)
+	viper.Set("imageValidation.maxConcurrent", 2)
+	// The slots are sized once per process; size them for this test and again for later ones
+	imageRequestSlotsOnce = sync.Once{}
+	t.Cleanup(func() { imageRequestSlotsOnce = sync.Once{} })
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "image/png")
+	}))
+	t.Cleanup(server.Close)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Separate handlers share the limit
+			if err := NewImageHandler().validateImage(context.Background(), server.URL+"/logo.png"); err != nil {
+				t.Errorf("validateImage: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got != 2 {
+		t.Errorf("at most %d requests ran at once, want 2", got)
+	}
+}
+
+func TestImageHandlerTransportMinTLSVersion(t *testing.T) {
+	tests := []struct {
+		minVersion string
+		want       uint16
+	}{
+		{"", tls.VersionTLS12},
+		{"1.3", tls.VersionTLS13},
+		{"1.1", tls.VersionTLS11},
+		{"ssl3", tls.VersionTLS12}, // unsupported values fall back to the default
+	}
+	for _, tt := range tests {
+		t.Run("minVersion "+tt.minVersion, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("tls.minVersion", tt.minVersion)
+
+			transport, ok := NewImageHandler().client.Transport.(*http.Transport)
+			if !ok || transport.TLSClientConfig == nil {
+				t.Fatal("image handler client has no TLS config")
+			}
+			if got := transport.TLSClientConfig.MinVersion; got != tt.want {
+				t.Errorf("MinVersion = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateImageRejectsPrivateAddresses(t *testing.T) {
+	server := imageServer(t) // listens on loopback
+	imageURL := server.URL + "/logo?type=image%2Fpng"
+
+	t.Cleanup(viper.Reset)
+	err := NewImageHandler().validateImage(context.Background(), imageURL)
+	if !errors.Is(err, netguard.ErrPrivateAddress) {
+		t.Fatalf("validateImage(loopback) = %v, want ErrPrivateAddress", err)
+	}
+	if err := checkImageHost("https://93.184.216.34/logo.png"); err != nil {
+		t.Errorf("checkImageHost(public) = %v, want allowed", err)
+	}
+
+	// The client refuses the loopback address on its own, even when the pre-check is bypassed
+	// as with a host whose DNS answer changes between the check and the dial
+	if resp, err := NewImageHandler().client.Head(imageURL); !errors.Is(err, netguard.ErrPrivateAddress) {
+		if err == nil {
+			resp.Body.Close()
+		}
+		t.Errorf("HEAD through the image client = %v, want ErrPrivateAddress", err)
+	}
+
+	viper.Set("imageValidation.allowPrivate", true)
+	if err := NewImageHandler().validateImage(context.Background(), imageURL); err != nil {
+		t.Errorf("validateImage with allowPrivate = %v, want allowed", err)
+	}
+}
+
+func TestValidateImageAllowedHosts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name         string
+		allowedHosts string
+		wantErr      bool
+		wantRequests int32
+	}{
+		{"any host when unset", "", false, 1},
+		{"listed host accepted", "cdn.example.com,127.0.0.1", false, 1},
+		{"off-list host rejected before fetching", "cdn.example.com", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("imageValidation.allowPrivate", true)
+			viper.Set("imageValidation.allowedHosts", tt.allowedHosts)
+			requests.Store(0)
+
+			err := NewImageHandler().validateImage(context.Background(), server.URL+"/logo.png")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateImage() = %v, want error: %v", err, tt.wantErr)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCheckAllowedHostMatchesSuffixes(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("imageValidation.allowedHosts", "cdn.example.com")
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://cdn.example.com/logo.png", true},
+		{"https://images.cdn.example.com/logo.png", true},
+		{"https://CDN.Example.com./logo.png", true},
+		{"https://evilcdn.example.com/logo.png", false},
+		{"https://cdn.example.com.evil.io/logo.png", false},
+	}
+	for _, tt := range tests {
+		if err := checkAllowedHost(tt.url); (err == nil) != tt.allowed {
+			t.Errorf("checkAllowedHost(%q) = %v, want allowed: %v", tt.url, err, tt.allowed)
+		}
+	}
+}
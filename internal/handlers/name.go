@@ -1,10 +1,10 @@
 package handlers
 
 import (
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/docker"
+	"context"
 	"fmt"
-
-	"github.com/spf13/viper"
 )
 
 // MaxCoinLength defines the maximum allowed length for a coin symbol
@@ -21,7 +21,7 @@ func NewNameHandler() *NameHandler {
 }
 
 // Handle processes coin-related changes and updates service configurations
-func (h *NameHandler) Handle(record *Record) HandlerResult {
+func (h *NameHandler) Handle(ctx context.Context, record *Record) HandlerResult {
 	result := HandlerResult{}
 
 	if err := h.validateName(record.Name); err != nil {
@@ -29,44 +29,44 @@ func (h *NameHandler) Handle(record *Record) HandlerResult {
 		return result
 	}
 
-	frontendServiceName := viper.GetString("frontendServiceName")
-	frontendContainerName := viper.GetString("frontendContainerName")
-
-	// Create updates with string values
-	updates := map[string]map[string]string{
-		frontendServiceName: {
-			"NEXT_PUBLIC_NETWORK_NAME":       record.Name,
-			"NEXT_PUBLIC_NETWORK_SHORT_NAME": record.Name,
-			"NEXT_PUBLIC_FEATURED_NETWORKS":  `[{'title':'Aurora','url':'https://explorer.aurora.dev/','group':'Mainnets'}, {'title':'` + record.Name + `','url':'` + record.ExplorerURL + `','group':'Mainnets', 'isActive':true}]`,
-		},
-	}
+	frontendServiceName := config.GetChainString(record.ChainID, "frontendServiceName")
+	frontendContainerName := config.GetChainString(record.ChainID, "frontendContainerName")
+	updates := h.computeUpdates(record)
 
 	// Apply updates to services
-	allUpdates := make(map[string]string)
-	for _, env := range updates {
-		for key, value := range env {
-			allUpdates[key] = value
-		}
-	}
-
-	updated, err := h.UpdateEnvFile(allUpdates)
+	changed, err := h.ApplyServiceChanges(ctx, record.ChainID, updates)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to update environment: %w", err)
 		return result
 	}
+	updated := len(changed) > 0
+	result.EnvUpdated = updated
+	result.ChangedKeys = changed
 	if updated {
-		fmt.Printf("Updated environment with name changes: %+v\n", allUpdates)
+		fmt.Printf("Updated environment with name changes: %+v\n", updates)
 		fmt.Printf("Frontend container name: %s\n", frontendContainerName)
 		fmt.Printf("Frontend service name: %s\n", frontendServiceName)
 		result.ContainersToRestart = append(result.ContainersToRestart, docker.Container{
 			Name:        frontendContainerName,
 			ServiceName: frontendServiceName,
+			ChainID:     record.ChainID,
 		})
 	}
 
 	return result
 }
 
+// computeUpdates returns the variables to write for the frontend, without touching the env file
+func (h *NameHandler) computeUpdates(record *Record) map[string]map[string]string {
+	return map[string]map[string]string{
+		config.GetChainString(record.ChainID, "frontendServiceName"): {
+			"NEXT_PUBLIC_NETWORK_NAME":       record.Name,
+			"NEXT_PUBLIC_NETWORK_SHORT_NAME": record.Name,
+			"NEXT_PUBLIC_FEATURED_NETWORKS":  featuredNetworks(record.Name, record.ExplorerURL),
+		},
+	}
+}
+
 // validateCoin checks if the coin symbol meets the required criteria
 func (h *NameHandler) validateName(name string) error {
 	if name == "" {
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/docker"
+	"context"
+	"fmt"
+)
+
+type NetworkTypeHandler struct {
+	BaseHandler
+}
+
+func NewNetworkTypeHandler() *NetworkTypeHandler {
+	return &NetworkTypeHandler{
+		BaseHandler: NewBaseHandler(),
+	}
+}
+
+// Handle writes the network type (mainnet, testnet, ...) to the frontend env key configured
+// as networkType.envKey and restarts the frontend when it changed. The record's network_type
+// column wins over networkType.value; nothing is written when neither is set
+func (h *NetworkTypeHandler) Handle(ctx context.Context, record *Record) HandlerResult {
+	result := HandlerResult{}
+
+	envKey := config.GetChainString(record.ChainID, "networkType.envKey")
+	networkType := record.NetworkType
+	if networkType == "" {
+		networkType = config.GetChainString(record.ChainID, "networkType.value")
+	}
+	if envKey == "" || networkType == "" {
+		return result
+	}
+
+	frontendServiceName := config.GetChainString(record.ChainID, "frontendServiceName")
+	frontendContainerName := config.GetChainString(record.ChainID, "frontendContainerName")
+	updates := map[string]map[string]string{
+		frontendServiceName: {envKey: networkType},
+	}
+
+	changed, err := h.ApplyServiceChanges(ctx, record.ChainID, updates)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to update environment: %w", err)
+		return result
+	}
+	result.EnvUpdated = len(changed) > 0
+	result.ChangedKeys = changed
+	if result.EnvUpdated {
+		fmt.Printf("Updated environment with network type changes: %+v\n", updates)
+		result.ContainersToRestart = append(result.ContainersToRestart, docker.Container{
+			Name:        frontendContainerName,
+			ServiceName: frontendServiceName,
+			ChainID:     record.ChainID,
+		})
+	}
+
+	return result
+}
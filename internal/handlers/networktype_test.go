@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNetworkTypeHandler(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+	viper.Set("networkType.envKey", "NEXT_PUBLIC_NETWORK_TYPE")
+	viper.Set("networkType.value", "mainnet")
+	h := NewNetworkTypeHandler()
+
+	steps := []struct {
+		name        string
+		record      Record
+		want        string
+		wantRestart bool
+	}{
+		{"configured value written", Record{ChainID: 1}, "NEXT_PUBLIC_NETWORK_TYPE=mainnet", true},
+		{"unchanged value", Record{ChainID: 1}, "NEXT_PUBLIC_NETWORK_TYPE=mainnet", false},
+		{"record column wins", Record{ChainID: 1, NetworkType: "testnet"}, "NEXT_PUBLIC_NETWORK_TYPE=testnet", true},
+	}
+	for _, step := range steps {
+		result := h.Handle(context.Background(), &step.record)
+		if result.Error != nil {
+			t.Fatalf("%s: Handle: %v", step.name, result.Error)
+		}
+		restarted := len(result.ContainersToRestart) == 1 && result.ContainersToRestart[0].Name == "frontend-1"
+		if result.EnvUpdated != step.wantRestart || restarted != step.wantRestart {
+			t.Errorf("%s: result = %+v, want env updated and frontend restarted: %t", step.name, result, step.wantRestart)
+		}
+		if content, _ := os.ReadFile(envFile); !strings.Contains(string(content), step.want) {
+			t.Errorf("%s: env file = %q, want %s", step.name, content, step.want)
+		}
+	}
+}
+
+func TestNetworkTypeHandlerOptional(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("networkType.value", "mainnet") // no envKey configured
+
+	result := NewNetworkTypeHandler().Handle(context.Background(), &Record{ChainID: 1, NetworkType: "testnet"})
+	if result.Error != nil || result.EnvUpdated || len(result.ContainersToRestart) != 0 {
+		t.Errorf("result = %+v, want nothing done without networkType.envKey", result)
+	}
+	if content, _ := os.ReadFile(envFile); len(content) != 0 {
+		t.Errorf("env file = %q, want it untouched", content)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+// FeaturedNetworksPreview is the NEXT_PUBLIC_FEATURED_NETWORKS value the handlers
+// would write for a record, together with the inputs used to render it
+type FeaturedNetworksPreview struct {
+	Value     string            `json:"value"`     // Value left in the env once every handler has run
+	ByHandler map[string]string `json:"byHandler"` // Value rendered by each handler, in run order
+	Name      string            `json:"name"`
+	Host      string            `json:"host"`
+	Protocol  string            `json:"protocol"`
+}
+
+// PreviewFeaturedNetworks runs the name and explorer handlers among names (all handlers
+// when empty) in compute-only mode and returns the rendered featured networks value
+// Nothing is written to the env file; configured defaults apply as they would for a change
+func PreviewFeaturedNetworks(record *Record, names []string) (*FeaturedNetworksPreview, error) {
+	record = record.WithDefaults()
+	if len(names) == 0 {
+		names = HandlerNames
+	}
+
+	preview := &FeaturedNetworksPreview{
+		ByHandler: make(map[string]string),
+		Name:      record.Name,
+	}
+	explorer := NewExplorerHandler()
+	if host, err := explorer.extractHostFromURL(record.ExplorerURL); err == nil {
+		preview.Host = host
+	}
+	preview.Protocol = explorer.extractProtocolFromURL(record.ExplorerURL)
+
+	for _, name := range names {
+		var updates map[string]map[string]string
+		switch name {
+		case "name":
+			updates = NewNameHandler().computeUpdates(record)
+		case "explorer":
+			if skipEmptyExplorerURL(record.ExplorerURL) {
+				continue
+			}
+			var err error
+			if updates, err = explorer.computeUpdates(record); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+
+		for _, envVars := range updates {
+			if value, ok := envVars["NEXT_PUBLIC_FEATURED_NETWORKS"]; ok {
+				preview.ByHandler[name] = value
+				preview.Value = value
+				break
+			}
+		}
+	}
+	return preview, nil
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"blockscout-vc/internal/env"
+)
+
+func TestPreviewFeaturedNetworksMatchesWrittenValue(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	record := &Record{ChainID: 1313161555, Name: "Aurora Testnet", ExplorerURL: "http://explorer.testnet.aurora.dev"}
+	names := []string{"name", "explorer"}
+
+	preview, err := PreviewFeaturedNetworks(record, names)
+	if err != nil {
+		t.Fatalf("PreviewFeaturedNetworks: %v", err)
+	}
+	if preview.Name != "Aurora Testnet" || preview.Host != "explorer.testnet.aurora.dev" || preview.Protocol != "http" {
+		t.Errorf("preview inputs = %q, %q, %q, want the record's name, host and protocol", preview.Name, preview.Host, preview.Protocol)
+	}
+	if len(preview.ByHandler) != 2 {
+		t.Errorf("preview rendered by %v, want both handlers", preview.ByHandler)
+	}
+	if content, _ := os.ReadFile(envFile); len(content) != 0 {
+		t.Fatalf("preview wrote the env file: %q", content)
+	}
+
+	hs, err := NewHandlers(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hs {
+		if result := h.Handle(context.Background(), record); result.Error != nil {
+			t.Fatalf("Handle: %v", result.Error)
+		}
+	}
+
+	written := env.NewEnv()
+	if err := written.ReadEnvFile(); err != nil {
+		t.Fatal(err)
+	}
+	if got := written.EnvFile["NEXT_PUBLIC_FEATURED_NETWORKS"]; got != preview.Value {
+		t.Errorf("written value = %q, preview = %q", got, preview.Value)
+	}
+}
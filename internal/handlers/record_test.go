@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validRecord returns a record every handler accepts without network access
+func validRecord() *Record {
+	return &Record{
+		ID:          1,
+		ChainID:     1313161555,
+		Name:        "Aurora",
+		Coin:        "ETH",
+		ExplorerURL: "https://explorer.aurora.dev",
+	}
+}
+
+func TestRecordValidateRejectsOnlyExplorerURL(t *testing.T) {
+	record := validRecord()
+	if err := record.Validate(); err != nil {
+		t.Fatalf("Validate() on a valid record = %v", err)
+	}
+
+	record.ExplorerURL = "https://"
+	err := record.Validate()
+	if err == nil {
+		t.Fatal("Validate() accepted an explorer URL without a host")
+	}
+	if !strings.Contains(err.Error(), "invalid explorer URL") {
+		t.Errorf("Validate() = %v, want an explorer URL error", err)
+	}
+	for _, field := range []string{"invalid name", "invalid coin"} {
+		if strings.Contains(err.Error(), field) {
+			t.Errorf("Validate() = %v, reported %s for a valid field", err, field)
+		}
+	}
+}
+
+func TestRecordValidateForOnlyChecksNamedHandlers(t *testing.T) {
+	record := validRecord()
+	record.ExplorerURL = "https://"
+
+	if err := record.ValidateFor([]string{"coin", "name"}); err != nil {
+		t.Errorf("ValidateFor(coin, name) = %v, want the explorer URL ignored", err)
+	}
+	if err := record.ValidateFor([]string{"explorer"}); err == nil {
+		t.Error("ValidateFor(explorer) accepted an invalid explorer URL")
+	}
+}
+
+func TestRecordUnmarshalJSONTimestamps(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 30, 45, 123456000, time.UTC)
+	tests := []struct {
+		name      string
+		createdAt string
+	}{
+		{"RFC3339", `"2024-05-01T12:30:45.123456Z"`},
+		{"RFC3339 with offset", `"2024-05-01T14:30:45.123456+02:00"`},
+		{"Postgres without time zone", `"2024-05-01T12:30:45.123456"`},
+		{"Postgres with space", `"2024-05-01 12:30:45.123456"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var record Record
+			data := `{"id": 7, "chain_id": 1, "created_at": ` + tt.createdAt + `, "updated_at": null}`
+			if err := json.Unmarshal([]byte(data), &record); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !record.CreatedAt.Equal(want) {
+				t.Errorf("CreatedAt = %s, want %s", record.CreatedAt, want)
+			}
+			if !record.UpdatedAt.IsZero() {
+				t.Errorf("UpdatedAt = %s, want the zero time for null", record.UpdatedAt)
+			}
+			if record.ID != 7 || record.ChainID != 1 {
+				t.Errorf("record = %+v, want the other fields decoded", record)
+			}
+		})
+	}
+
+	var record Record
+	if err := json.Unmarshal([]byte(`{"created_at": "yesterday"}`), &record); err == nil {
+		t.Error("Unmarshal accepted an unparseable created_at")
+	}
+}
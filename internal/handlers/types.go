@@ -1,41 +1,294 @@
 package handlers
 
 import (
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/docker"
 	"blockscout-vc/internal/env"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 )
 
 // Handler defines the interface for all update handlers
 type Handler interface {
-	Handle(record *Record) HandlerResult
+	Handle(ctx context.Context, record *Record) HandlerResult
 }
 
 // HandlerResult represents the outcome of a handler's processing
 type HandlerResult struct {
+	Handler             string             // Name of the handler that produced the result, set by the caller
 	Error               error              // Any error that occurred during handling
 	ContainersToRestart []docker.Container // List of container names that need to be restarted
+	EnvUpdated          bool               // Whether the handler wrote changes to the env file
+	ChangedKeys         []string           // Variables whose value the handler changed
+}
+
+// LogFields returns the result as flat fields for structured logging
+func (r HandlerResult) LogFields() map[string]any {
+	changedKeys := r.ChangedKeys
+	if changedKeys == nil {
+		changedKeys = []string{}
+	}
+	containers := []string{}
+	for _, container := range r.ContainersToRestart {
+		containers = append(containers, container.Name)
+	}
+
+	fields := map[string]any{
+		"handler":     r.Handler,
+		"envUpdated":  r.EnvUpdated,
+		"changedKeys": changedKeys,
+		"containers":  containers,
+	}
+	if r.Error != nil {
+		fields["error"] = r.Error.Error()
+	}
+	return fields
 }
 
 // Record represents the common data structure for all handlers
 // containing the database record fields
 type Record struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	Coin         string `json:"base_token_symbol"`
-	ChainID      int    `json:"chain_id"`
-	LightLogoURL string `json:"network_logo"`
-	DarkLogoURL  string `json:"network_logo_dark"`
-	FaviconURL   string `json:"favicon"`
-	ExplorerURL  string `json:"explorer_url"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Coin         string    `json:"base_token_symbol"`
+	ChainID      int       `json:"chain_id"`
+	LightLogoURL string    `json:"network_logo"`
+	DarkLogoURL  string    `json:"network_logo_dark"`
+	FaviconURL   string    `json:"favicon"`
+	ExplorerURL  string    `json:"explorer_url"`
+	NetworkType  string    `json:"network_type" column:"optional"` // Not every table has the column
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// WithDefaults returns a copy of the record where empty fields are replaced by the
+// values configured under defaults (name, coin, explorerUrl, lightLogoUrl, darkLogoUrl,
+// faviconUrl), so a cleared field keeps the explorer presentable instead of writing ""
+func (r *Record) WithDefaults() *Record {
+	effective := *r
+	fields := []struct {
+		key   string
+		value *string
+	}{
+		{key: "name", value: &effective.Name},
+		{key: "coin", value: &effective.Coin},
+		{key: "explorerUrl", value: &effective.ExplorerURL},
+		{key: "lightLogoUrl", value: &effective.LightLogoURL},
+		{key: "darkLogoUrl", value: &effective.DarkLogoURL},
+		{key: "faviconUrl", value: &effective.FaviconURL},
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			*field.value = config.GetFieldDefault(field.key)
+		}
+	}
+	return &effective
+}
+
+// recordTimeLayouts are the timestamp formats accepted in realtime payloads,
+// covering RFC3339 strings and Postgres timestamps without a time zone
+var recordTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// UnmarshalJSON decodes a record, parsing created_at/updated_at from any of the
+// recordTimeLayouts. Missing or null timestamps are left as the zero time
+func (r *Record) UnmarshalJSON(data []byte) error {
+	type recordAlias Record
+	aux := struct {
+		*recordAlias
+		CreatedAt *string `json:"created_at"`
+		UpdatedAt *string `json:"updated_at"`
+	}{recordAlias: (*recordAlias)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if r.CreatedAt, err = parseRecordTime(aux.CreatedAt); err != nil {
+		return fmt.Errorf("invalid created_at: %w", err)
+	}
+	if r.UpdatedAt, err = parseRecordTime(aux.UpdatedAt); err != nil {
+		return fmt.Errorf("invalid updated_at: %w", err)
+	}
+	return nil
+}
+
+// parseRecordTime parses a timestamp using the first matching layout
+func parseRecordTime(value *string) (time.Time, error) {
+	if value == nil || *value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range recordTimeLayouts {
+		if t, err := time.Parse(layout, *value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp format: %s", *value)
+}
+
+// Validate checks all record fields up front so an invalid record can be
+// rejected before any handler writes partial state to the env file
+// Image URLs are optional and only checked for format; reachability is left to ImageHandler
+func (r *Record) Validate() error {
+	return r.ValidateFor(nil)
+}
+
+// ValidateFor checks only the record fields used by the named handlers,
+// or all fields when names is empty
+func (r *Record) ValidateFor(names []string) error {
+	if len(names) == 0 {
+		names = HandlerNames
+	}
+
+	var errs []error
+	for _, name := range names {
+		switch name {
+		case "name":
+			if err := (&NameHandler{}).validateName(r.Name); err != nil {
+				errs = append(errs, fmt.Errorf("invalid name: %w", err))
+			}
+		case "coin":
+			if err := (&CoinHandler{}).validateCoin(r.Coin); err != nil {
+				errs = append(errs, fmt.Errorf("invalid coin: %w", err))
+			}
+		case "explorer":
+			if skipEmptyExplorerURL(r.ExplorerURL) {
+				continue
+			}
+			if err := (&ExplorerHandler{}).validateExplorerURL(r.ExplorerURL); err != nil {
+				errs = append(errs, fmt.Errorf("invalid explorer URL: %w", err))
+			}
+		case "image":
+			errs = append(errs, r.validateImageFormats()...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateImageFormats checks the format of every non-empty image URL
+func (r *Record) validateImageFormats() []error {
+	var errs []error
+	images := []struct {
+		field string
+		url   string
+	}{
+		{field: "light logo URL", url: r.LightLogoURL},
+		{field: "dark logo URL", url: r.DarkLogoURL},
+		{field: "favicon URL", url: r.FaviconURL},
+	}
+	for _, image := range images {
+		if image.url == "" {
+			continue
+		}
+		if err := validateImageURLFormat(image.url); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", image.field, err))
+		}
+	}
+	return errs
+}
+
+// HandlerNames lists the handlers available to table configuration, in the order they run by default
+var HandlerNames = []string{"coin", "image", "name", "explorer", "chainId", "networkType"}
+
+// NewHandlers returns the handlers with the given names, or every handler when names is empty
+func NewHandlers(names []string) ([]Handler, error) {
+	if len(names) == 0 {
+		names = HandlerNames
+	}
+
+	handlers := make([]Handler, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "coin":
+			handlers = append(handlers, NewCoinHandler())
+		case "image":
+			handlers = append(handlers, NewImageHandler())
+		case "name":
+			handlers = append(handlers, NewNameHandler())
+		case "explorer":
+			handlers = append(handlers, NewExplorerHandler())
+		case "chainId":
+			handlers = append(handlers, NewChainIDHandler())
+		case "networkType":
+			handlers = append(handlers, NewNetworkTypeHandler())
+		default:
+			return nil, fmt.Errorf("unknown handler: %s", name)
+		}
+	}
+
+	if !config.GetManageContainers() {
+		for i, handler := range handlers {
+			handlers[i] = envOnlyHandler{Handler: handler}
+		}
+	}
+	return handlers, nil
+}
+
+// envOnlyHandler wraps a handler when manageContainers is disabled so its
+// env changes are kept but no container restarts are requested
+type envOnlyHandler struct {
+	Handler
+}
+
+func (h envOnlyHandler) Handle(ctx context.Context, record *Record) HandlerResult {
+	result := h.Handler.Handle(ctx, record)
+	result.ContainersToRestart = nil
+	return result
+}
+
+// ClaimedKeys forwards to the wrapped handler so stale key clearing still sees its keys
+func (h envOnlyHandler) ClaimedKeys() []string {
+	if claimer, ok := h.Handler.(interface{ ClaimedKeys() []string }); ok {
+		return claimer.ClaimedKeys()
+	}
+	return nil
+}
+
+// featuredNetworks renders NEXT_PUBLIC_FEATURED_NETWORKS with Aurora and the given network
+// In staging the network is marked inactive and its title gets a "(staging)" suffix
+func featuredNetworks(title, url string) string {
+	isActive := "true"
+	if config.IsStaging() {
+		title += " (staging)"
+		isActive = "false"
+	}
+	return fmt.Sprintf(`[{'title':'Aurora','url':'https://explorer.aurora.dev/','group':'Mainnets'}, {'title':'%s','url':'%s','group':'Mainnets', 'isActive':%s}]`, title, url, isActive)
 }
 
 // BaseHandler provides common functionality for handlers
 type BaseHandler struct {
-	docker *docker.Docker
-	env    *env.Env
+	docker    *docker.Docker
+	env       *env.Env
+	forceKeys []string // Keys reported as changed and rewritten even when their value is unchanged
+	claimed   []string // Keys this handler wrote or deliberately kept, see ClaimedKeys
+}
+
+// SetForceKeys makes the handler re-assert the given env keys, writing them and
+// restarting their containers even when the computed value matches the current one
+func (h *BaseHandler) SetForceKeys(keys []string) {
+	h.forceKeys = keys
+}
+
+// ClaimKeys marks keys as still owned by the handler without writing them, e.g. when
+// a value is left as it is because it did not change
+func (h *BaseHandler) ClaimKeys(keys ...string) {
+	h.claimed = append(h.claimed, keys...)
+}
+
+// ClaimedKeys returns every key the handler wrote or claimed, so keys no handler
+// claimed during a full pass can be cleared as stale (env.clearPrefixOnUpdate)
+func (h *BaseHandler) ClaimedKeys() []string {
+	return h.claimed
 }
 
 func NewBaseHandler() BaseHandler {
@@ -53,24 +306,133 @@ type EnvUpdate struct {
 }
 
 // UpdateEnvFile updates the environment file with the provided variables
-// Note: This always updates the file specified in pathToEnvFile configuration
-func (h *BaseHandler) UpdateEnvFile(envVars map[string]string) (bool, error) {
-	err := h.env.ReadEnvFile()
+// Note: This always updates the env file of the configured chain
+// Nothing is written once ctx is done
+func (h *BaseHandler) UpdateEnvFile(ctx context.Context, envVars map[string]string) (bool, error) {
+	return updateEnvFile(ctx, h.env, envVars)
+}
+
+// updateEnvFile reads e, applies envVars and writes it back if anything changed
+// Nothing is written once ctx is done
+func updateEnvFile(ctx context.Context, e *env.Env, envVars map[string]string) (bool, error) {
+	err := e.ReadEnvFile()
 	if err != nil {
 		return false, fmt.Errorf("failed to read env file: %w", err)
 	}
-	updated, err := h.env.UpdateEnvVars(envVars)
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("env update cancelled: %w", err)
+	}
+	updated, err := e.UpdateEnvVars(envVars)
 	if err != nil {
 		return false, fmt.Errorf("failed to update env vars: %w", err)
 	}
 	if updated {
-		if err := h.env.WriteEnvFile(); err != nil {
+		if err := e.WriteEnvFile(); err != nil {
 			return false, fmt.Errorf("failed to write env file: %w", err)
 		}
+		if config.GetEnvVerifyAfterWrite() {
+			if err := e.VerifyEnvVars(envVars); err != nil {
+				return false, fmt.Errorf("env file verification failed: %w", err)
+			}
+		}
 	}
 	return updated, nil
 }
 
+// envForChain returns the env file for a record's chain, creating it when
+// pathToEnvFileTemplate points at a file that does not exist yet
+func (h *BaseHandler) envForChain(chainID int) (*env.Env, error) {
+	e := env.NewEnvForChain(chainID)
+	if e.PathToEnvFile == h.env.PathToEnvFile {
+		return h.env, nil
+	}
+	if err := e.EnsureEnvFile(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ApplyServiceUpdates writes the variables for each service using the configured outputMode
+// In env mode all variables go to the chain's env file; in composeOverride mode they are
+// written under each service's environment in the compose override file
+// Nothing is written once ctx is done
+func (h *BaseHandler) ApplyServiceUpdates(ctx context.Context, chainID int, updates map[string]map[string]string) (bool, error) {
+	changed, err := h.ApplyServiceChanges(ctx, chainID, updates)
+	return len(changed) > 0, err
+}
+
+// ApplyServiceChanges is ApplyServiceUpdates returning the sorted keys whose value changed
+func (h *BaseHandler) ApplyServiceChanges(ctx context.Context, chainID int, updates map[string]map[string]string) ([]string, error) {
+	changed := []string{}
+	for serviceName, envVars := range updates {
+		current, err := h.CurrentEnvVars(chainID, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range envVars {
+			if currentValue, exists := current[key]; !exists || currentValue != value || slices.Contains(h.forceKeys, key) {
+				changed = append(changed, key)
+			}
+			h.claimed = append(h.claimed, key)
+		}
+	}
+	slices.Sort(changed)
+	changed = slices.Compact(changed)
+
+	if _, err := h.applyServiceUpdates(ctx, chainID, updates); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// applyServiceUpdates writes updates to the env file or compose override
+func (h *BaseHandler) applyServiceUpdates(ctx context.Context, chainID int, updates map[string]map[string]string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("env update cancelled: %w", err)
+	}
+
+	if config.GetOutputMode() == config.OutputModeComposeOverride {
+		updated, err := env.NewComposeOverride(config.GetComposeOverridePath()).UpdateServiceEnvVars(updates)
+		if err != nil {
+			return false, fmt.Errorf("failed to update compose override: %w", err)
+		}
+		return updated, nil
+	}
+
+	allUpdates := make(map[string]string)
+	for _, envVars := range updates {
+		for key, value := range envVars {
+			allUpdates[key] = value
+		}
+	}
+	e, err := h.envForChain(chainID)
+	if err != nil {
+		return false, err
+	}
+	return updateEnvFile(ctx, e, allUpdates)
+}
+
+// CurrentEnvVars returns the variables currently applied to a service,
+// read from the env file or the compose override depending on outputMode
+func (h *BaseHandler) CurrentEnvVars(chainID int, serviceName string) (map[string]string, error) {
+	if config.GetOutputMode() == config.OutputModeComposeOverride {
+		override := env.NewComposeOverride(config.GetComposeOverridePath())
+		if err := override.ReadOverrideFile(); err != nil {
+			return nil, err
+		}
+		return override.Services[serviceName], nil
+	}
+
+	e, err := h.envForChain(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.ReadEnvFile(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return e.EnvFile, nil
+}
+
 func (h *BaseHandler) SaveFile() error {
 	return h.env.WriteEnvFile()
 }
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/env"
+
+	"github.com/spf13/viper"
+)
+
+func TestFeaturedNetworksStaging(t *testing.T) {
+	tests := []struct {
+		environment string
+		wantEntry   string
+	}{
+		{"", `{'title':'Aurora Testnet','url':'https://explorer.example.com','group':'Mainnets', 'isActive':true}`},
+		{"production", `{'title':'Aurora Testnet','url':'https://explorer.example.com','group':'Mainnets', 'isActive':true}`},
+		{"staging", `{'title':'Aurora Testnet (staging)','url':'https://explorer.example.com','group':'Mainnets', 'isActive':false}`},
+	}
+	for _, tt := range tests {
+		t.Run("environment "+tt.environment, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("environment", tt.environment)
+
+			got := featuredNetworks("Aurora Testnet", "https://explorer.example.com")
+			if !strings.Contains(got, tt.wantEntry) {
+				t.Errorf("featuredNetworks() = %s, want it to contain %s", got, tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestNameHandlerWritesEachChainToItsEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(viper.Reset)
+	viper.Set("pathToEnvFile", filepath.Join(dir, "sidecar-injected.env"))
+	viper.Set("pathToEnvFileTemplate", filepath.Join(dir, "chain-{chainId}.env"))
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+
+	h := NewNameHandler()
+	for chainID, name := range map[int]string{1: "Alpha", 2: "Beta"} {
+		if result := h.Handle(context.Background(), &Record{ChainID: chainID, Name: name}); result.Error != nil {
+			t.Fatalf("Handle chain %d: %v", chainID, result.Error)
+		}
+	}
+
+	for file, want := range map[string]string{"chain-1.env": "Alpha", "chain-2.env": "Beta"} {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("%s was not written: %v", file, err)
+		}
+		other := map[string]string{"Alpha": "Beta", "Beta": "Alpha"}[want]
+		if !strings.Contains(string(content), want) || strings.Contains(string(content), other) {
+			t.Errorf("%s = %q, want only the %s name", file, content, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sidecar-injected.env")); !os.IsNotExist(err) {
+		t.Errorf("pathToEnvFile must not be written when the template is set (stat: %v)", err)
+	}
+}
+
+func TestHandlerResultLogFields(t *testing.T) {
+	result := HandlerResult{
+		Handler:             "name",
+		EnvUpdated:          true,
+		ChangedKeys:         []string{"NEXT_PUBLIC_NETWORK_NAME", "NEXT_PUBLIC_NETWORK_SHORT_NAME"},
+		ContainersToRestart: []docker.Container{{Name: "frontend-1", ServiceName: "frontend"}},
+	}
+	want := map[string]any{
+		"handler":     "name",
+		"envUpdated":  true,
+		"changedKeys": []string{"NEXT_PUBLIC_NETWORK_NAME", "NEXT_PUBLIC_NETWORK_SHORT_NAME"},
+		"containers":  []string{"frontend-1"},
+	}
+	if got := result.LogFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LogFields() = %v, want %v", got, want)
+	}
+
+	failed := HandlerResult{Handler: "image", Error: errors.New("image not found")}
+	want = map[string]any{
+		"handler":     "image",
+		"envUpdated":  false,
+		"changedKeys": []string{},
+		"containers":  []string{},
+		"error":       "image not found",
+	}
+	if got := failed.LogFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LogFields() of a failed result = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateEnvFileVerifiesWriteWhenEnabled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the lost write goes through a symlink to /dev/null")
+	}
+	for _, verify := range []bool{false, true} {
+		t.Run(fmt.Sprintf("verifyAfterWrite %t", verify), func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("env.verifyAfterWrite", verify)
+			// Writes through the symlink succeed but never land, as when a concurrent writer clobbers them
+			path := filepath.Join(t.TempDir(), "sidecar-injected.env")
+			if err := os.Symlink(os.DevNull, path); err != nil {
+				t.Fatal(err)
+			}
+
+			e := &env.Env{PathToEnvFile: path, EnvFile: make(map[string]string)}
+			_, err := updateEnvFile(context.Background(), e, map[string]string{"NEXT_PUBLIC_NETWORK_NAME": "Aurora"})
+			if verify && (err == nil || !strings.Contains(err.Error(), "verification failed")) {
+				t.Errorf("updateEnvFile() error = %v, want the lost write detected", err)
+			}
+			if !verify && err != nil {
+				t.Errorf("updateEnvFile() error = %v, want no verification", err)
+			}
+		})
+	}
+}
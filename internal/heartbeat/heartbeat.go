@@ -7,13 +7,15 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
 )
 
 type HeartbeatService struct {
 	client   *client.Client
 	interval time.Duration
 	stopChan chan struct{}
+
+	maxFailures int    // Consecutive failures after which onDead is called; 0 disables it
+	onDead      func() // Called when the connection is considered dead, e.g. to reconnect
 }
 
 type HeartbeatPayload struct {
@@ -31,26 +33,44 @@ func New(client *client.Client, interval time.Duration) *HeartbeatService {
 	}
 }
 
+// OnDeadConnection makes the service call onDead after maxFailures consecutive failed
+// heartbeats. The count starts over afterwards, so a connection that stays dead is
+// reported again every maxFailures heartbeats. Must be called before Start
+func (h *HeartbeatService) OnDeadConnection(maxFailures int, onDead func()) {
+	h.maxFailures = maxFailures
+	h.onDead = onDead
+}
+
 // sendHeartbeat sends a single heartbeat message through the WebSocket connection
-func sendHeartbeat(conn *websocket.Conn) error {
+func sendHeartbeat(client *client.Client) error {
 	heartbeat := HeartbeatPayload{
 		Event:   "heartbeat",
 		Topic:   "phoenix",
 		Payload: map[string]interface{}{},
 		Ref:     uuid.New().String(),
 	}
-	return conn.WriteJSON(heartbeat)
+	return client.WriteJSON(heartbeat)
 }
 
 // Start begins sending periodic heartbeat messages
 func (h *HeartbeatService) Start() {
 	ticker := time.NewTicker(h.interval)
 	go func() {
+		failures := 0
 		for {
 			select {
 			case <-ticker.C:
-				if err := sendHeartbeat(h.client.Conn); err != nil {
-					log.Printf("Failed to send heartbeat: %v", err)
+				err := sendHeartbeat(h.client)
+				if err == nil {
+					failures = 0
+					continue
+				}
+				failures++
+				log.Printf("Failed to send heartbeat (%d consecutive): %v", failures, err)
+				if h.onDead != nil && h.maxFailures > 0 && failures >= h.maxFailures {
+					log.Printf("Connection considered dead after %d failed heartbeats, requesting reconnect", failures)
+					failures = 0
+					h.onDead()
 				}
 			case <-h.stopChan:
 				ticker.Stop()
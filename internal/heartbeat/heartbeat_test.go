@@ -0,0 +1,43 @@
+package heartbeat
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/client"
+)
+
+func TestOnDeadConnectionFiresAfterConsecutiveFailures(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	// A client that never connected fails every heartbeat
+	h := New(client.New("ws://127.0.0.1:0", "key"), time.Millisecond)
+	// onDead runs on the heartbeat goroutine, which also writes the logs, so it reads them safely
+	dead := make(chan string, 1)
+	h.OnDeadConnection(3, func() {
+		select {
+		case dead <- logs.String():
+		default:
+		}
+	})
+	h.Start()
+	t.Cleanup(h.Stop)
+
+	select {
+	case logged := <-dead:
+		if !strings.Contains(logged, "(3 consecutive)") {
+			t.Errorf("reconnect requested before 3 failed heartbeats:\n%s", logged)
+		}
+		if strings.Contains(logged, "(4 consecutive)") {
+			t.Errorf("reconnect requested after more than 3 failed heartbeats:\n%s", logged)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the dead connection callback never fired")
+	}
+}
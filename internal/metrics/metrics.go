@@ -0,0 +1,120 @@
+// Package metrics provides a minimal registry of counters and gauges
+// exposed in the Prometheus text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metric is implemented by every registered counter and gauge
+type metric interface {
+	write(w io.Writer) error
+}
+
+var (
+	registry    = make(map[string]metric)
+	registryMux sync.Mutex
+)
+
+// register adds a metric to the registry, panicking on duplicate names
+// since that is always a programming error
+func register(name string, m metric) {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("metric %s already registered", name))
+	}
+	registry[name] = m
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	name  string
+	help  string
+	value atomic.Uint64
+}
+
+// NewCounter creates and registers a counter
+func NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	register(name, c)
+	return c
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Value returns the current counter value
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+func (c *Counter) write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.Value())
+	return err
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	name string
+	help string
+	bits atomic.Uint64
+}
+
+// NewGauge creates and registers a gauge
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	register(name, g)
+	return g
+}
+
+// Set sets the gauge to the given value
+func (g *Gauge) Set(value float64) {
+	g.bits.Store(math.Float64bits(value))
+}
+
+// SetToCurrentTime sets the gauge to the current Unix time in seconds
+func (g *Gauge) SetToCurrentTime() {
+	g.Set(float64(time.Now().Unix()))
+}
+
+// Value returns the current gauge value
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", g.name, g.help, g.name, g.name, g.Value())
+	return err
+}
+
+// WriteText writes every registered metric in the Prometheus text format, sorted by name
+func WriteText(w io.Writer) error {
+	registryMux.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	metrics := make([]metric, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, registry[name])
+	}
+	registryMux.Unlock()
+
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
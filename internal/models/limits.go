@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"blockscout-vc/internal/config"
+)
+
+// FieldLengthError reports a token form field longer than its configured limit
+type FieldLengthError struct {
+	Field string
+	Max   int
+}
+
+func (e *FieldLengthError) Error() string {
+	return fmt.Sprintf("%s cannot exceed %d characters", e.Field, e.Max)
+}
+
+// Validate checks the form fields against the limits.* length limits, so oversized
+// values never reach the database or Blockscout
+func (f *TokenInfoForm) Validate() error {
+	fields := []struct {
+		field string
+		value string
+		limit string
+	}{
+		{field: "projectName", value: f.ProjectName, limit: "projectNameMax"},
+		{field: "projectDescription", value: f.ProjectDescription, limit: "descriptionMax"},
+		{field: "projectSector", value: f.ProjectSector, limit: "sectorMax"},
+		{field: "projectEmail", value: f.ProjectEmail, limit: "emailMax"},
+		{field: "projectWebsite", value: f.ProjectWebsite, limit: "urlMax"},
+		{field: "iconUrl", value: f.IconURL, limit: "urlMax"},
+		{field: "docs", value: f.Docs, limit: "urlMax"},
+		{field: "github", value: f.Github, limit: "urlMax"},
+		{field: "telegram", value: f.Telegram, limit: "urlMax"},
+		{field: "linkedin", value: f.Linkedin, limit: "urlMax"},
+		{field: "discord", value: f.Discord, limit: "urlMax"},
+		{field: "slack", value: f.Slack, limit: "urlMax"},
+		{field: "twitter", value: f.Twitter, limit: "urlMax"},
+		{field: "openSea", value: f.OpenSea, limit: "urlMax"},
+		{field: "facebook", value: f.Facebook, limit: "urlMax"},
+		{field: "medium", value: f.Medium, limit: "urlMax"},
+		{field: "reddit", value: f.Reddit, limit: "urlMax"},
+		{field: "support", value: f.Support, limit: "urlMax"},
+		{field: "coinMarketCapTicker", value: f.CoinMarketCapTicker, limit: "tickerMax"},
+		{field: "coinGeckoTicker", value: f.CoinGeckoTicker, limit: "tickerMax"},
+		{field: "defiLlamaTicker", value: f.DefiLlamaTicker, limit: "tickerMax"},
+		{field: "tokenName", value: f.TokenName, limit: "tokenNameMax"},
+		{field: "tokenSymbol", value: f.TokenSymbol, limit: "tokenSymbolMax"},
+	}
+
+	for _, field := range fields {
+		max := config.GetTokenFieldLimit(field.limit)
+		if max > 0 && utf8.RuneCountInString(field.value) > max {
+			return &FieldLengthError{Field: field.field, Max: max}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestTokenInfoFormValidateLengths(t *testing.T) {
+	tests := []struct {
+		name      string
+		form      TokenInfoForm
+		config    map[string]any
+		wantField string
+		wantMax   int
+	}{
+		{"within defaults", TokenInfoForm{ProjectName: "Aurora", ProjectDescription: strings.Repeat("a", 2000)}, nil, "", 0},
+		{"description over default", TokenInfoForm{ProjectDescription: strings.Repeat("a", 2001)}, nil, "projectDescription", 2000},
+		{"project name over default", TokenInfoForm{ProjectName: strings.Repeat("a", 101)}, nil, "projectName", 100},
+		{"url over default", TokenInfoForm{Twitter: "https://x.com/" + strings.Repeat("a", 2048)}, nil, "twitter", 2048},
+		{"symbol over default", TokenInfoForm{TokenSymbol: strings.Repeat("A", 21)}, nil, "tokenSymbol", 20},
+		{"multibyte characters count once", TokenInfoForm{TokenSymbol: strings.Repeat("€", 20)}, nil, "", 0},
+		{"configured limit", TokenInfoForm{ProjectName: "Aurora Labs"}, map[string]any{"limits.projectNameMax": 6}, "projectName", 6},
+		{"limit disabled", TokenInfoForm{ProjectDescription: strings.Repeat("a", 5000)}, map[string]any{"limits.descriptionMax": 0}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			for key, value := range tt.config {
+				viper.Set(key, value)
+			}
+
+			err := tt.form.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var lengthErr *FieldLengthError
+			if !errors.As(err, &lengthErr) || lengthErr.Field != tt.wantField || lengthErr.Max != tt.wantMax {
+				t.Fatalf("Validate() = %v, want %s over %d characters", err, tt.wantField, tt.wantMax)
+			}
+		})
+	}
+}
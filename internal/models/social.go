@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"blockscout-vc/internal/config"
+)
+
+// socialLink describes how a form field is normalized; base is prepended to bare
+// handles, and fields without a base only accept URLs
+type socialLink struct {
+	field string
+	value *string
+	base  string
+}
+
+// handlePattern matches a bare handle, optionally prefixed with @
+var handlePattern = regexp.MustCompile(`^@?[A-Za-z0-9_.-]+$`)
+
+// NormalizeLinks converts bare handles such as "@foo" to canonical URLs and validates
+// the other link fields as http(s) URLs, so tokens are stored in a consistent format
+func (f *TokenInfoForm) NormalizeLinks() error {
+	links := []socialLink{
+		{field: "projectWebsite", value: &f.ProjectWebsite},
+		{field: "docs", value: &f.Docs},
+		{field: "support", value: &f.Support},
+		{field: "slack", value: &f.Slack},
+		{field: "twitter", value: &f.Twitter, base: "https://x.com/"},
+		{field: "telegram", value: &f.Telegram, base: "https://t.me/"},
+		{field: "discord", value: &f.Discord, base: "https://discord.gg/"},
+		{field: "github", value: &f.Github, base: "https://github.com/"},
+		{field: "linkedin", value: &f.Linkedin, base: "https://www.linkedin.com/company/"},
+		{field: "facebook", value: &f.Facebook, base: "https://www.facebook.com/"},
+		{field: "medium", value: &f.Medium, base: "https://medium.com/@"},
+		{field: "reddit", value: &f.Reddit, base: "https://www.reddit.com/r/"},
+		{field: "openSea", value: &f.OpenSea, base: "https://opensea.io/collection/"},
+	}
+
+	for _, link := range links {
+		if config.GetSocialLinkMode(link.field) == config.SocialLinkModeRaw {
+			continue
+		}
+		normalized, err := normalizeLink(*link.value, link.base)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", link.field, err)
+		}
+		*link.value = normalized
+	}
+	return nil
+}
+
+// normalizeLink returns value as an http(s) URL. Scheme-less URLs get https://,
+// and bare handles are appended to base when the field has one
+func normalizeLink(value, base string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	if base != "" && handlePattern.MatchString(value) && !strings.Contains(value, ".") {
+		return base + strings.TrimPrefix(value, "@"), nil
+	}
+
+	candidate := value
+	if !strings.Contains(candidate, "://") {
+		candidate = "https://" + candidate
+	}
+	parsedURL, err := url.Parse(candidate)
+	if err != nil {
+		return "", fmt.Errorf("must be a URL or handle: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return "", fmt.Errorf("URL must start with http:// or https://")
+	}
+	if !strings.Contains(parsedURL.Host, ".") || strings.ContainsAny(parsedURL.Host, " @") {
+		return "", fmt.Errorf("must be a URL or handle")
+	}
+	return parsedURL.String(), nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNormalizeLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		base    string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "  ", "https://x.com/", "", false},
+		{"handle with @", "@aurora_is_near", "https://x.com/", "https://x.com/aurora_is_near", false},
+		{"bare handle", "auroraisnear", "https://t.me/", "https://t.me/auroraisnear", false},
+		{"full URL kept", "https://x.com/aurora", "https://x.com/", "https://x.com/aurora", false},
+		{"scheme-less URL", "aurora.dev/docs", "", "https://aurora.dev/docs", false},
+		{"http URL", "http://aurora.dev", "", "http://aurora.dev", false},
+		{"handle without base", "@aurora", "", "", true},
+		{"unsupported scheme", "ftp://aurora.dev", "", "", true},
+		{"javascript URL", "javascript:alert(1)", "https://x.com/", "", true},
+		{"spaces", "not a link", "https://x.com/", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeLink(tt.value, tt.base)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeLink(%q) error = %v, want error: %t", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeLink(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLinksStoresNormalizedForm(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	form := TokenInfoForm{Twitter: "@aurora", Github: "aurora-is-near", Docs: "doc.aurora.dev", Slack: "@aurora"}
+	viper.Set("socialLinks.slack", "raw")
+
+	if err := form.NormalizeLinks(); err != nil {
+		t.Fatalf("NormalizeLinks: %v", err)
+	}
+	if form.Twitter != "https://x.com/aurora" || form.Github != "https://github.com/aurora-is-near" || form.Docs != "https://doc.aurora.dev" {
+		t.Errorf("form = %+v, want normalized links", form)
+	}
+	if form.Slack != "@aurora" {
+		t.Errorf("raw slack = %q, want it stored unchanged", form.Slack)
+	}
+
+	invalid := TokenInfoForm{Telegram: "ftp://t.me/aurora"}
+	if err := invalid.NormalizeLinks(); err == nil {
+		t.Error("NormalizeLinks accepted an invalid telegram link")
+	}
+}
@@ -0,0 +1,145 @@
+package netguard
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned when a URL resolves to an address that outbound
+// fetches must not reach, such as loopback or cloud metadata endpoints
+var ErrPrivateAddress = errors.New("address is private, loopback or link-local")
+
+// lookupTimeout bounds the DNS resolution done by CheckURL
+const lookupTimeout = 5 * time.Second
+
+// IsPrivateIP reports whether ip is loopback, private (RFC 1918, fc00::/7),
+// link-local (including 169.254.169.254), multicast or unspecified
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
+
+// CheckURL resolves the host of rawURL and returns ErrPrivateAddress if any of its
+// addresses is private, so a record cannot make the sidecar fetch internal services
+// It is only a fast pre-check: the host is resolved again when the request dials, so
+// clients must also use NewTransport, which checks the address actually connected to
+func CheckURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL format: %w", err)
+	}
+	host := parsedURL.Hostname()
+	if host == "" {
+		return fmt.Errorf("URL has no host")
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if IsPrivateIP(ip) {
+			return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if IsPrivateIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrPrivateAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// CheckRedirect is an http.Client CheckRedirect func applying CheckURL to every
+// redirect target, so a public URL cannot redirect into a private range
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return CheckURL(req.URL.String())
+}
+
+// NewTransport returns an http.Transport for outbound requests to URLs the sidecar does not
+// control. Unless allowPrivate is set, every connection is refused when the address actually
+// dialed is private, so a host whose DNS answer changes after CheckURL (DNS rebinding) still
+// cannot reach loopback or metadata endpoints. Connections to the HTTP(S)_PROXY proxy are
+// exempt, since the proxy rather than the sidecar connects to the target
+func NewTransport(allowPrivate bool, tlsConfig *tls.Config) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if allowPrivate {
+		return transport
+	}
+
+	guarded := &net.Dialer{Timeout: dialer.Timeout, KeepAlive: dialer.KeepAlive, Control: refusePrivate}
+	proxies := proxyAddresses()
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if proxies[address] {
+			return dialer.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+	return transport
+}
+
+// refusePrivate is a net.Dialer Control func returning ErrPrivateAddress for a private
+// address. It runs after DNS resolution, on the IP about to be connected to
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("unexpected dial address %s", address)
+	}
+	if IsPrivateIP(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, ip)
+	}
+	return nil
+}
+
+// proxyAddresses returns the host:port of the proxies configured through the environment
+func proxyAddresses() map[string]bool {
+	addresses := map[string]bool{}
+	for _, scheme := range []string{"http", "https"} {
+		proxyURL, err := http.ProxyFromEnvironment(&http.Request{URL: &url.URL{Scheme: scheme, Host: "example.com"}})
+		if err != nil || proxyURL == nil {
+			continue
+		}
+		port := proxyURL.Port()
+		if port == "" {
+			port = map[string]string{"https": "443", "socks5": "1080"}[proxyURL.Scheme]
+			if port == "" {
+				port = "80"
+			}
+		}
+		addresses[net.JoinHostPort(proxyURL.Hostname(), port)] = true
+	}
+	return addresses
+}
@@ -0,0 +1,100 @@
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url         string
+		wantPrivate bool
+	}{
+		{"http://127.0.0.1/logo.png", true},
+		{"http://[::1]:8080/logo.png", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://10.0.0.5/logo.png", true},
+		{"http://192.168.1.10/logo.png", true},
+		{"http://[fd00::1]/logo.png", true},
+		{"http://0.0.0.0/logo.png", true},
+		{"https://93.184.216.34/logo.png", false},
+		{"https://[2606:4700:4700::1111]/logo.png", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := CheckURL(tt.url)
+			if got := errors.Is(err, ErrPrivateAddress); got != tt.wantPrivate {
+				t.Errorf("CheckURL() = %v, want private: %t", err, tt.wantPrivate)
+			}
+			if !tt.wantPrivate && err != nil {
+				t.Errorf("CheckURL() = %v, want a public address allowed", err)
+			}
+		})
+	}
+}
+
+func TestCheckURLResolvesHostnames(t *testing.T) {
+	if err := CheckURL("http://localhost/logo.png"); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("CheckURL(localhost) = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestCheckRedirectRejectsPrivateTarget(t *testing.T) {
+	req := httptest.NewRequest(http.MethodHead, "http://127.0.0.1/internal", nil)
+	if err := CheckRedirect(req, []*http.Request{{}}); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("CheckRedirect() = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestNewTransportChecksDialedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	// localhost is only resolved when dialing, as with a host rebinding its DNS after CheckURL
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		allowPrivate bool
+		wantPrivate  bool
+	}{
+		{false, true},
+		{true, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("allowPrivate %t", tt.allowPrivate), func(t *testing.T) {
+			client := &http.Client{Transport: NewTransport(tt.allowPrivate, nil)}
+			resp, err := client.Get(url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if got := errors.Is(err, ErrPrivateAddress); got != tt.wantPrivate {
+				t.Errorf("GET %s = %v, want refused: %t", url, err, tt.wantPrivate)
+			}
+			if !tt.wantPrivate && err != nil {
+				t.Errorf("GET %s = %v, want it allowed", url, err)
+			}
+		})
+	}
+}
+
+func TestRefusePrivate(t *testing.T) {
+	tests := []struct {
+		address     string
+		wantPrivate bool
+	}{
+		{"169.254.169.254:80", true},
+		{"127.0.0.1:8080", true},
+		{"[::1]:443", true},
+		{"10.1.2.3:443", true},
+		{"93.184.216.34:443", false},
+	}
+	for _, tt := range tests {
+		err := refusePrivate("tcp", tt.address, nil)
+		if got := errors.Is(err, ErrPrivateAddress); got != tt.wantPrivate || (!tt.wantPrivate && err != nil) {
+			t.Errorf("refusePrivate(%s) = %v, want private: %t", tt.address, err, tt.wantPrivate)
+		}
+	}
+}
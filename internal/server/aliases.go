@@ -0,0 +1,67 @@
+package server
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/models"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// tokenFormFields are the canonical JSON field names of models.TokenInfoForm
+var tokenFormFields = jsonFieldNames(reflect.TypeOf(models.TokenInfoForm{}))
+
+// jsonFieldNames returns the JSON names of the fields of a struct type
+func jsonFieldNames(structType reflect.Type) map[string]bool {
+	names := make(map[string]bool, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		name, _, _ := strings.Cut(structType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// applyFieldAliases renames the top-level fields of a JSON object body that match a
+// configured alias (bodyFieldAliases) to their canonical name in fields. A canonical
+// field sent alongside its alias wins. Bodies that are not JSON objects are returned
+// unchanged, so decoding reports the error as usual
+func applyFieldAliases(body []byte, fields map[string]bool) []byte {
+	aliases := config.GetBodyFieldAliases()
+	if len(aliases) == 0 {
+		return body
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return body
+	}
+
+	renamed := false
+	for name, value := range object {
+		canonical, ok := aliases[strings.ToLower(name)]
+		if !ok || canonical == name {
+			continue
+		}
+		if !fields[canonical] {
+			log.Printf("Warning: bodyFieldAliases maps %s to unknown field %s", name, canonical)
+			continue
+		}
+		if _, exists := object[canonical]; !exists {
+			object[canonical] = value
+		}
+		delete(object, name)
+		renamed = true
+	}
+	if !renamed {
+		return body
+	}
+
+	rewritten, err := json.Marshal(object)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func TestApplyFieldAliasesPopulatesFormFields(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("bodyFieldAliases", map[string]string{
+		"website": "projectWebsite",
+		"symbol":  "tokenSymbol",
+		"name":    "projectName",
+		"colour":  "notAField",
+	})
+
+	body := `{"tokenAddress": "0xabc", "Website": "https://aurora.dev", "symbol": "AUR",
+		"name": "Alias", "projectName": "Canonical", "colour": "red"}`
+	var form models.TokenInfoForm
+	if err := decodeJSONBody(applyFieldAliases([]byte(body), tokenFormFields), &form); err != nil {
+		t.Fatalf("decodeJSONBody: %v", err)
+	}
+
+	if form.TokenAddress != "0xabc" {
+		t.Errorf("TokenAddress = %q, want the canonical name to keep working", form.TokenAddress)
+	}
+	if form.ProjectWebsite != "https://aurora.dev" {
+		t.Errorf("ProjectWebsite = %q, want it filled from website", form.ProjectWebsite)
+	}
+	if form.TokenSymbol != "AUR" {
+		t.Errorf("TokenSymbol = %q, want it filled from symbol", form.TokenSymbol)
+	}
+	if form.ProjectName != "Canonical" {
+		t.Errorf("ProjectName = %q, want the canonical field to win over its alias", form.ProjectName)
+	}
+}
+
+func TestApplyFieldAliasesLeavesBodyWithoutAliases(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("bodyFieldAliases", map[string]string{"website": "projectWebsite"})
+
+	for _, body := range []string{`{"projectWebsite": "https://aurora.dev"}`, `["website"]`, `{"website":`} {
+		if got := string(applyFieldAliases([]byte(body), tokenFormFields)); got != body {
+			t.Errorf("applyFieldAliases(%s) = %s, want it unchanged", body, got)
+		}
+	}
+}
+
+func TestUpsertTokenAcceptsAliasedFields(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("bodyFieldAliases", map[string]string{"address": "tokenAddress", "name": "projectName"})
+	viper.Set("limits.projectNameMax", 6)
+
+	// The aliased address passes the required check and the aliased name is validated
+	// as projectName, so the request fails before it reaches the database
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(`{"address": "0xabc", "name": "Aurora Labs"}`))
+	req.Header.Set("Content-Type", "application/json")
+	status, body := serve(t, (&Server{}).upsertToken, req)
+	if status != fiber.StatusBadRequest || body["field"] != "projectName" {
+		t.Errorf("response = %d %v, want 400 naming projectName", status, body)
+	}
+}
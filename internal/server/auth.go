@@ -3,6 +3,7 @@ package server
 import (
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"strings"
 
 	"blockscout-vc/internal/config"
@@ -32,72 +33,99 @@ func authMiddleware() fiber.Handler {
 			})
 		}
 
-		// Get Authorization header
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
+		if err := checkBasicAuth(c.Get("Authorization"), username, password); err != nil {
 			c.Status(fiber.StatusUnauthorized)
 			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
 			return c.JSON(fiber.Map{
-				"error": "Authorization header required",
+				"error": err.Error(),
 			})
 		}
 
-		// Parse Authorization header to extract scheme and credentials
-		// Use case-insensitive scheme comparison as per RFC 7235
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 {
-			c.Status(fiber.StatusUnauthorized)
-			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.JSON(fiber.Map{
-				"error": "Invalid authorization format. Use Basic authentication",
-			})
-		}
+		// Credentials are valid, proceed to next middleware/handler
+		return c.Next()
+	}
+}
 
-		scheme := parts[0]
-		encodedCredentials := parts[1]
+// checkBasicAuth validates a Basic Authorization header against the configured credentials
+func checkBasicAuth(authHeader, username, password string) error {
+	if authHeader == "" {
+		return errors.New("Authorization header required")
+	}
 
-		// Case-insensitive scheme check
-		if !strings.EqualFold(scheme, "Basic") {
-			c.Status(fiber.StatusUnauthorized)
-			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.JSON(fiber.Map{
-				"error": "Invalid authorization scheme. Use Basic authentication",
-			})
-		}
+	// Parse Authorization header to extract scheme and credentials
+	// Use case-insensitive scheme comparison as per RFC 7235
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 {
+		return errors.New("Invalid authorization format. Use Basic authentication")
+	}
 
-		// Extract and decode credentials
-		decodedCredentials, err := base64.StdEncoding.DecodeString(encodedCredentials)
-		if err != nil {
-			c.Status(fiber.StatusUnauthorized)
-			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.JSON(fiber.Map{
-				"error": "Invalid authorization header format",
-			})
-		}
+	scheme := parts[0]
+	encodedCredentials := parts[1]
 
-		// Parse username:password
-		credentials := strings.SplitN(string(decodedCredentials), ":", 2)
-		if len(credentials) != 2 {
-			c.Status(fiber.StatusUnauthorized)
-			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.JSON(fiber.Map{
-				"error": "Invalid credentials format",
-			})
-		}
+	// Case-insensitive scheme check
+	if !strings.EqualFold(scheme, "Basic") {
+		return errors.New("Invalid authorization scheme. Use Basic authentication")
+	}
 
-		// Validate credentials using constant-time comparison to prevent timing attacks
-		usernameMatch := subtle.ConstantTimeCompare([]byte(credentials[0]), []byte(username)) == 1
-		passwordMatch := subtle.ConstantTimeCompare([]byte(credentials[1]), []byte(password)) == 1
+	// Extract and decode credentials
+	decodedCredentials, err := base64.StdEncoding.DecodeString(encodedCredentials)
+	if err != nil {
+		return errors.New("Invalid authorization header format")
+	}
 
-		if !usernameMatch || !passwordMatch {
-			c.Status(fiber.StatusUnauthorized)
-			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.JSON(fiber.Map{
-				"error": "Invalid username or password",
-			})
-		}
+	// Parse username:password
+	credentials := strings.SplitN(string(decodedCredentials), ":", 2)
+	if len(credentials) != 2 {
+		return errors.New("Invalid credentials format")
+	}
 
-		// Credentials are valid, proceed to next middleware/handler
-		return c.Next()
+	// Validate credentials using constant-time comparison to prevent timing attacks
+	usernameMatch := subtle.ConstantTimeCompare([]byte(credentials[0]), []byte(username)) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(credentials[1]), []byte(password)) == 1
+
+	if !usernameMatch || !passwordMatch {
+		return errors.New("Invalid username or password")
+	}
+	return nil
+}
+
+// Auth modes reported by the auth check endpoint
+const (
+	authModeDisabled      = "disabled"
+	authModeBasic         = "basic"
+	authModeMisconfigured = "misconfigured"
+)
+
+// authCheck reports whether authentication is required and whether the request's
+// credentials are accepted, so the dashboard can prompt for login. Credentials are never echoed
+func (s *Server) authCheck(c *fiber.Ctx) error {
+	username := config.GetAuthUsername()
+	password := config.GetAuthPassword()
+
+	switch {
+	case username == "" && password == "":
+		return c.JSON(fiber.Map{
+			"mode":          authModeDisabled,
+			"authRequired":  false,
+			"authenticated": true,
+		})
+	case username == "" || password == "":
+		return c.JSON(fiber.Map{
+			"mode":          authModeMisconfigured,
+			"authRequired":  true,
+			"authenticated": false,
+			"error":         "Authentication is misconfigured: both username and password must be set",
+		})
+	}
+
+	response := fiber.Map{
+		"mode":          authModeBasic,
+		"authRequired":  true,
+		"authenticated": true,
+	}
+	if err := checkBasicAuth(c.Get("Authorization"), username, password); err != nil {
+		response["authenticated"] = false
+		response["error"] = err.Error()
 	}
+	return c.JSON(response)
 }
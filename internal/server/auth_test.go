@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func TestAuthCheck(t *testing.T) {
+	basic := func(credentials string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	tests := []struct {
+		name              string
+		username          string
+		password          string
+		authorization     string
+		wantMode          string
+		wantRequired      bool
+		wantAuthenticated bool
+	}{
+		{"disabled", "", "", "", "disabled", false, true},
+		{"misconfigured", "admin", "", basic("admin:"), "misconfigured", true, false},
+		{"missing credentials", "admin", "s3cret-pass", "", "basic", true, false},
+		{"wrong credentials", "admin", "s3cret-pass", basic("admin:guess"), "basic", true, false},
+		{"valid credentials", "admin", "s3cret-pass", basic("admin:s3cret-pass"), "basic", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("auth.username", tt.username)
+			viper.Set("auth.password", tt.password)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/check", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			status, body := serve(t, (&Server{}).authCheck, req)
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+			}
+			if body["mode"] != tt.wantMode || body["authRequired"] != tt.wantRequired || body["authenticated"] != tt.wantAuthenticated {
+				t.Errorf("response = %v, want mode %s, authRequired %t, authenticated %t",
+					body, tt.wantMode, tt.wantRequired, tt.wantAuthenticated)
+			}
+
+			encoded, _ := json.Marshal(body)
+			for _, secret := range []string{"s3cret-pass", "guess"} {
+				if strings.Contains(string(encoded), secret) {
+					t.Errorf("response %s leaks %q", encoded, secret)
+				}
+			}
+		})
+	}
+}
+
+func TestDashboardRequireAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		requireAuth   bool
+		authorization string
+		wantStatus    int
+	}{
+		{"public by default", false, "", fiber.StatusOK},
+		{"locked without credentials", true, "", fiber.StatusUnauthorized},
+		{"locked with wrong credentials", true, "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:guess")), fiber.StatusUnauthorized},
+		{"unlocked with credentials", true, "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:s3cret-pass")), fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("chainId", "1313161554")
+			viper.Set("auth.username", "admin")
+			viper.Set("auth.password", "s3cret-pass")
+			viper.Set("dashboard.requireAuth", tt.requireAuth)
+
+			app := fiber.New()
+			app.Get("/", (&Server{}).dashboardHandlers()...)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if challenge := resp.Header.Get("WWW-Authenticate"); (tt.wantStatus == fiber.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("WWW-Authenticate = %q for status %d", challenge, resp.StatusCode)
+			}
+		})
+	}
+}
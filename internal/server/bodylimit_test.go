@@ -0,0 +1,55 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func TestBodyLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxBodyBytes int
+		size         int
+		wantStatus   int
+	}{
+		{"default limit", 0, 1 << 20, fiber.StatusOK},
+		{"over default limit", 0, 1<<20 + 1, fiber.StatusRequestEntityTooLarge},
+		{"configured limit", 1024, 1024, fiber.StatusOK},
+		{"over configured limit", 1024, 1025, fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("http.maxBodyBytes", tt.maxBodyBytes)
+
+			app := newApp()
+			app.Post("/api/v1/tokens", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+			// app.Test fails oversized requests before a response is written, so serve over TCP
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			go app.Listener(listener)
+			t.Cleanup(func() { app.Shutdown() })
+
+			url := "http://" + listener.Addr().String() + "/api/v1/tokens"
+			resp, err := http.Post(url, "application/octet-stream", bytes.NewReader(make([]byte, tt.size)))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
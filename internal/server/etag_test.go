@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
+	"github.com/spf13/viper"
+)
+
+func TestTokenInfoConditionalRequest(t *testing.T) {
+	// The token-info route chain with a stub in place of the database lookup
+	app := fiber.New()
+	app.Get("/chains/:chainId/token-infos/:tokenAddress", dbTimeout(time.Second), etag.New(), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"tokenAddress": c.Params("tokenAddress"), "projectName": "Aurora"})
+	})
+	path := "/chains/1313161554/token-infos/0xabc"
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	tag := resp.Header.Get(fiber.HeaderETag)
+	if resp.StatusCode != fiber.StatusOK || tag == "" {
+		t.Fatalf("first response = %d with ETag %q, want 200 with an ETag", resp.StatusCode, tag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, tag)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("conditional request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusNotModified {
+		t.Errorf("conditional response = %d, want %d", resp.StatusCode, fiber.StatusNotModified)
+	}
+}
+
+func TestDashboardConditionalRequest(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("chainId", "1313161554")
+
+	// The dashboard route chain without the optional auth middleware
+	app := fiber.New()
+	app.Get("/", etag.New(), (&Server{}).tokenManagementPage)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	tag := resp.Header.Get(fiber.HeaderETag)
+	if resp.StatusCode != fiber.StatusOK || tag == "" {
+		t.Fatalf("first response = %d with ETag %q, want 200 with an ETag", resp.StatusCode, tag)
+	}
+	if got := resp.Header.Get(fiber.HeaderCacheControl); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, tag)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("conditional request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusNotModified {
+		t.Errorf("conditional response = %d, want %d", resp.StatusCode, fiber.StatusNotModified)
+	}
+}
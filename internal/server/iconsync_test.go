@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"blockscout-vc/internal/client"
+
+	"github.com/spf13/viper"
+)
+
+// fakeBlockscout holds one token's icon in memory
+type fakeBlockscout struct {
+	token   *client.BlockscoutToken
+	updates []string
+}
+
+func (f *fakeBlockscout) GetTokenByAddress(address string) (*client.BlockscoutToken, error) {
+	return f.token, nil
+}
+
+func (f *fakeBlockscout) UpdateTokenIconURL(ctx context.Context, address, iconURL string) error {
+	if f.token == nil {
+		return fmt.Errorf("%w: %s", client.ErrTokenNotFound, address)
+	}
+	f.updates = append(f.updates, iconURL)
+	f.token.IconURL = iconURL
+	return nil
+}
+
+func TestSyncIconURLClearGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowClear bool
+		wantIcon   string
+	}{
+		{"clearing blocked by default", false, "https://example.com/icon.png"},
+		{"clearing allowed by iconSync.allowClear", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("iconSync.allowClear", tt.allowClear)
+			blockscout := &fakeBlockscout{token: &client.BlockscoutToken{Address: "0xabc", IconURL: "https://example.com/icon.png"}}
+
+			if err := syncIconURL(context.Background(), blockscout, "0xabc", ""); err != nil {
+				t.Fatalf("syncIconURL: %v", err)
+			}
+			if blockscout.token.IconURL != tt.wantIcon {
+				t.Errorf("Blockscout icon = %q, want %q", blockscout.token.IconURL, tt.wantIcon)
+			}
+		})
+	}
+}
+
+func TestSyncIconURLUpdatesAndSkipsUnknownTokens(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	blockscout := &fakeBlockscout{token: &client.BlockscoutToken{Address: "0xabc"}}
+	if err := syncIconURL(context.Background(), blockscout, "0xabc", "https://example.com/new.png"); err != nil {
+		t.Fatalf("syncIconURL: %v", err)
+	}
+	if blockscout.token.IconURL != "https://example.com/new.png" {
+		t.Errorf("Blockscout icon = %q, want the new icon", blockscout.token.IconURL)
+	}
+
+	if err := syncIconURL(context.Background(), &fakeBlockscout{}, "0xdef", "https://example.com/new.png"); err != nil {
+		t.Errorf("syncIconURL for a token Blockscout does not know = %v, want nil", err)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/env"
+	"blockscout-vc/internal/handlers"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// maintenanceRequest is the body of POST /maintenance
+type maintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// getMaintenance returns whether maintenance mode is enabled in the frontend env
+func (s *Server) getMaintenance(c *fiber.Ctx) error {
+	chainID := viper.GetInt("chainId")
+	frontendServiceName := config.GetChainString(chainID, "frontendServiceName")
+
+	base := handlers.NewBaseHandler()
+	current, err := base.CurrentEnvVars(chainID, frontendServiceName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read maintenance state",
+		})
+	}
+
+	enabled, _ := strconv.ParseBool(current[config.GetMaintenanceEnvKey()])
+	return c.JSON(fiber.Map{
+		"enabled": enabled,
+	})
+}
+
+// setMaintenance writes the maintenance env key for the frontend and restarts it when the value changed
+func (s *Server) setMaintenance(c *fiber.Ctx) error {
+	var req maintenanceRequest
+	if err := c.BodyParser(&req); err != nil || req.Enabled == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": `Request body must be {"enabled": true|false}`,
+		})
+	}
+	if s.worker == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Container worker is not running",
+		})
+	}
+
+	chainID := viper.GetInt("chainId")
+	frontendServiceName := config.GetChainString(chainID, "frontendServiceName")
+	frontendContainerName := config.GetChainString(chainID, "frontendContainerName")
+
+	base := handlers.NewBaseHandler()
+	updated, err := base.ApplyServiceUpdates(s.ctx, chainID, map[string]map[string]string{
+		frontendServiceName: {
+			config.GetMaintenanceEnvKey(): strconv.FormatBool(*req.Enabled),
+		},
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update maintenance state",
+		})
+	}
+
+	restarted := false
+	if updated {
+		message := fmt.Sprintf("Set maintenance mode to %t", *req.Enabled)
+		if err := env.NewEnvForChain(chainID).CommitEnvFile(message); err != nil {
+			log.Printf("Warning: failed to commit env changes: %v", err)
+		}
+		restarted = s.worker.AddJob([]docker.Container{{
+			Name:        frontendContainerName,
+			ServiceName: frontendServiceName,
+			ChainID:     chainID,
+		}})
+	}
+
+	return c.JSON(fiber.Map{
+		"enabled":   *req.Enabled,
+		"restarted": restarted,
+	})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/worker"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func TestMaintenanceToggle(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	if err := os.WriteFile(envFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(viper.Reset)
+	viper.Set("pathToEnvFile", envFile)
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+
+	s := &Server{ctx: context.Background()}
+	set := func(body string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/maintenance", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		status, response := serve(t, s.setMaintenance, req)
+		if status != fiber.StatusOK {
+			t.Fatalf("POST %s = %d: %v", body, status, response)
+		}
+		return response
+	}
+	get := func() any {
+		t.Helper()
+		_, response := serve(t, s.getMaintenance, httptest.NewRequest(http.MethodGet, "/api/v1/maintenance", nil))
+		return response["enabled"]
+	}
+
+	for _, tt := range []struct {
+		body    string
+		enabled bool
+		line    string
+	}{
+		{`{"enabled":true}`, true, "NEXT_PUBLIC_MAINTENANCE=true"},
+		{`{"enabled":false}`, false, "NEXT_PUBLIC_MAINTENANCE=false"},
+	} {
+		s.worker = worker.New()
+		if response := set(tt.body); response["enabled"] != tt.enabled || response["restarted"] != true {
+			t.Errorf("POST %s = %v, want the frontend restarted", tt.body, response)
+		}
+		if content, _ := os.ReadFile(envFile); !strings.Contains(string(content), tt.line) {
+			t.Errorf("env file = %q, want %s", content, tt.line)
+		}
+		if enabled := get(); enabled != tt.enabled {
+			t.Errorf("GET enabled = %v, want %t", enabled, tt.enabled)
+		}
+
+		// Setting the same state again changes nothing, so nothing restarts
+		s.worker = worker.New()
+		if response := set(tt.body); response["restarted"] != false {
+			t.Errorf("repeated POST %s = %v, want no restart", tt.body, response)
+		}
+	}
+}
+
+func TestMaintenanceRejectsMissingEnabled(t *testing.T) {
+	s := &Server{ctx: context.Background(), worker: worker.New()}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/maintenance", strings.NewReader(`{}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if status, _ := serve(t, s.setMaintenance, req); status != fiber.StatusBadRequest {
+		t.Errorf("status = %d, want %d", status, fiber.StatusBadRequest)
+	}
+}
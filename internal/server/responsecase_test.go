@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"blockscout-vc/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+func TestApplyResponseCase(t *testing.T) {
+	response := map[string]interface{}{
+		"tokenAddress":        "0xabc",
+		"projectName":         "Aurora",
+		"coinMarketCapTicker": "AURORA",
+		"docs":                "",
+	}
+	tests := []struct {
+		responseCase string
+		want         map[string]interface{}
+	}{
+		{"", response},
+		{"camel", response},
+		{"snake", map[string]interface{}{
+			"token_address":          "0xabc",
+			"project_name":           "Aurora",
+			"coin_market_cap_ticker": "AURORA",
+			"docs":                   "",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run("case "+tt.responseCase, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("responseCase", tt.responseCase)
+			if got := applyResponseCase(response); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyResponseCase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAddress(t *testing.T) {
+	const stored = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	tests := []struct {
+		addressFormat string
+		want          string
+	}{
+		{"", stored},
+		{"lowercase", stored},
+		{"checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+	}
+	for _, tt := range tests {
+		t.Run("format "+tt.addressFormat, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("response.addressFormat", tt.addressFormat)
+			if got := formatAddress(stored); got != tt.want {
+				t.Errorf("formatAddress() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedTokenResponsesFollowResponseConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("responseCase", "snake")
+	viper.Set("response.addressFormat", "checksum")
+	token := models.UnifiedTokenInfo{
+		TokenAddress: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		ChainID:      "1313161554",
+		TokenSymbol:  "AURORA",
+		HasLocalData: true,
+	}
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	// The single token endpoint
+	response := unifiedTokenResponse(&token)
+	if response["token_address"] != checksummed || response["token_symbol"] != "AURORA" || response["has_local_data"] != true {
+		t.Errorf("unifiedTokenResponse() = %v, want snake_case keys and a checksummed address", response)
+	}
+	if _, ok := response["tokenAddress"]; ok {
+		t.Error("unifiedTokenResponse() kept the camelCase key")
+	}
+
+	// The token list
+	var buf bytes.Buffer
+	if err := writeUnifiedTokens(bufio.NewWriter(&buf), []models.UnifiedTokenInfo{token}); err != nil {
+		t.Fatalf("writeUnifiedTokens: %v", err)
+	}
+	var list struct {
+		Tokens []map[string]any `json:"tokens"`
+		Total  int              `json:"total"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &list); err != nil {
+		t.Fatalf("token list is not JSON: %v", err)
+	}
+	if list.Total != 1 || len(list.Tokens) != 1 || list.Tokens[0]["token_address"] != checksummed || list.Tokens[0]["chain_id"] != "1313161554" {
+		t.Errorf("token list = %s, want the token with snake_case keys and a checksummed address", buf.String())
+	}
+}
+
+func TestUnifiedTokenResponseDefaultsToStoredFormat(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	token := models.UnifiedTokenInfo{TokenAddress: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+	if response := unifiedTokenResponse(&token); response["tokenAddress"] != token.TokenAddress {
+		t.Errorf("unifiedTokenResponse() = %v, want the lowercase address under tokenAddress", response)
+	}
+}
@@ -3,16 +3,34 @@ package server
 import (
 	"blockscout-vc/internal/client"
 	"blockscout-vc/internal/database"
+	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/eip55"
+	"blockscout-vc/internal/handlers"
+	"blockscout-vc/internal/metrics"
 	"blockscout-vc/internal/models"
+	"blockscout-vc/internal/subscription"
+	"blockscout-vc/internal/worker"
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/spf13/viper"
 
 	"blockscout-vc/internal/config"
 )
@@ -21,11 +39,25 @@ type Server struct {
 	app              *fiber.App
 	database         *database.Database
 	blockscoutClient *client.BlockscoutClient
+	worker           *worker.Worker
+	subscription     atomic.Pointer[subscription.Subscription] // Used by reconcile; replaced by the running one via SetSubscription
+	ctx              context.Context                           // Ends at shutdown; bounds handler runs and env writes started by requests
+	buildInfo        BuildInfo
 }
 
-func NewServer() (*Server, error) {
+// BuildInfo identifies the running binary, reported by the version endpoint
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+}
+
+// newApp creates the fiber app with the middleware shared by every route
+func newApp() *fiber.App {
 	app := fiber.New(fiber.Config{
 		AppName: "Blockscout VC API",
+		// Larger bodies are rejected with 413 Request Entity Too Large
+		BodyLimit: config.GetHTTPMaxBodyBytes(),
 	})
 
 	// Middleware
@@ -36,6 +68,16 @@ func NewServer() (*Server, error) {
 		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
 		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
 	}))
+	if config.GetCompressionEnabled() {
+		app.Use(compression())
+	}
+	return app
+}
+
+// NewServer creates the HTTP server. Handler runs and env writes started by its endpoints
+// are cancelled once ctx is done, e.g. when the sidecar shuts down
+func NewServer(ctx context.Context, worker *worker.Worker) (*Server, error) {
+	app := newApp()
 
 	// Initialize database
 	db, err := database.NewDatabase()
@@ -53,29 +95,88 @@ func NewServer() (*Server, error) {
 		app:              app,
 		database:         db,
 		blockscoutClient: blockscoutClient,
+		worker:           worker,
+		ctx:              ctx,
 	}
+	server.subscription.Store(subscription.New(nil))
 
-	// Root route - Token Management Dashboard (public, so HTML loads)
-	app.Get("/", server.tokenManagementPage)
+	// Root route - Token Management Dashboard
+	app.Get("/", server.dashboardHandlers()...)
+
+	// Prometheus metrics (public, for scrapers)
+	app.Get("/metrics", server.metrics)
 
 	// API routes
 	api := app.Group("/api/v1")
 
+	// Public endpoint - Auth check so the dashboard knows whether to prompt for credentials
+	api.Get("/auth/check", server.authCheck)
+
 	// Public endpoint - Token info (no authentication required)
-	api.Get("/chains/:chainId/token-infos/:tokenAddress", server.getTokenInfo)
+	// The ETag is a hash of the response body, so If-None-Match gets a 304 until the token changes
+	// Its database queries are bounded by dbQueryTimeout.public so it fails fast under load
+	api.Get("/chains/:chainId/token-infos/:tokenAddress", dbTimeout(config.GetDBQueryTimeoutPublic()), etag.New(), server.getTokenInfo)
 
 	// Protected endpoints - Token management (authentication required)
 	protected := api.Group("")
 	protected.Use(authMiddleware())
+	protected.Use(dbTimeout(config.GetDBQueryTimeoutAdmin()))
 	{
 		protected.Get("/tokens", server.getUnifiedTokens)
 		protected.Post("/tokens", server.upsertToken)
 		protected.Get("/tokens/:tokenAddress", server.getUnifiedTokenByAddress)
+		protected.Delete("/tokens/:chainId/:tokenAddress", server.deleteToken)
+		protected.Post("/tokens/:chainId/:tokenAddress/restore", server.restoreToken)
+
+		// Maintenance endpoints
+		protected.Post("/containers/recreate-all", server.recreateAllContainers)
+		protected.Get("/containers/last-log", server.lastRecreationLog)
+		protected.Get("/maintenance", server.getMaintenance)
+		protected.Get("/env/featured-networks", server.previewFeaturedNetworks)
+		protected.Post("/maintenance", server.setMaintenance)
+		protected.Post("/reconcile", server.reconcile)
+		protected.Post("/handlers/:name/run", server.runHandler)
+		protected.Get("/version", server.version)
+		protected.Get("/config", server.effectiveConfig)
+		protected.Get("/snapshot", server.getSnapshot)
+		protected.Post("/snapshot/restore", server.restoreSnapshot)
+
+		// Status of the change subscription (subscribed, pending, error)
+		protected.Get("/status", server.status)
 	}
 
 	return server, nil
 }
 
+// dbTimeout bounds the database queries of a request by giving it a context with the timeout;
+// handlers pass c.UserContext() to the database. A zero timeout leaves requests unbounded
+func dbTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// compression compresses responses at http.compressionLevel for clients that accept it
+func compression() fiber.Handler {
+	return compress.New(compress.Config{
+		Next:  skipCompression,
+		Level: compress.Level(config.GetCompressionLevel()),
+	})
+}
+
+// skipCompression excludes responses that must not be buffered or compressed,
+// such as metrics scrapes and server-sent event streams
+func skipCompression(c *fiber.Ctx) bool {
+	return strings.HasPrefix(c.Path(), "/metrics") ||
+		strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream")
+}
+
 func (s *Server) Start(port string) error {
 	return s.app.Listen(":" + port)
 }
@@ -118,7 +219,12 @@ func (s *Server) getTokenInfo(c *fiber.Ctx) error {
 	tokenAddress = strings.ToLower(tokenAddress)
 
 	// Try to get token from database
-	token, err := s.database.GetTokenInfo(tokenAddress, chainId)
+	token, err := s.database.GetTokenInfo(c.UserContext(), tokenAddress, chainId, false)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Token info lookup timed out",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve token info",
@@ -154,7 +260,7 @@ func (s *Server) getTokenInfo(c *fiber.Ctx) error {
 			"tokenName":           token.TokenName,
 			"tokenSymbol":         token.TokenSymbol,
 		}
-		return c.JSON(response)
+		return c.JSON(tokenResponse(response))
 	}
 
 	// Return empty structure if token not found in sidecar database
@@ -186,13 +292,63 @@ func (s *Server) getTokenInfo(c *fiber.Ctx) error {
 		"tokenSymbol":         "",
 	}
 
-	return c.JSON(emptyToken)
+	return c.JSON(tokenResponse(emptyToken))
+}
+
+// tokenResponse formats a token response as configured: the tokenAddress as set by
+// response.addressFormat and the keys as set by responseCase. Every token endpoint
+// responds through it
+func tokenResponse(response map[string]interface{}) map[string]interface{} {
+	if address, ok := response["tokenAddress"].(string); ok {
+		response["tokenAddress"] = formatAddress(address)
+	}
+	return applyResponseCase(response)
+}
+
+// formatAddress renders a stored lowercase address as configured by response.addressFormat
+func formatAddress(address string) string {
+	if config.GetResponseAddressFormat() == config.AddressFormatChecksum {
+		return eip55.Checksum(address)
+	}
+	return address
+}
+
+// applyResponseCase renames the keys of a token response to snake_case when
+// responseCase is "snake"; camelCase responses are returned unchanged
+func applyResponseCase(response map[string]interface{}) map[string]interface{} {
+	if config.GetResponseCase() != config.ResponseCaseSnake {
+		return response
+	}
+	converted := make(map[string]interface{}, len(response))
+	for key, value := range response {
+		converted[toSnakeCase(key)] = value
+	}
+	return converted
+}
+
+// toSnakeCase converts a camelCase key such as "coinMarketCapTicker" to "coin_market_cap_ticker"
+func toSnakeCase(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
 
 // upsertToken creates or updates token information using PostgreSQL upsert
 func (s *Server) upsertToken(c *fiber.Ctx) error {
 	var form models.TokenInfoForm
-	if err := c.BodyParser(&form); err != nil {
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		if err := decodeJSONBody(applyFieldAliases(c.Body(), tokenFormFields), &form); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(err)
+		}
+	} else if err := c.BodyParser(&form); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
@@ -209,13 +365,34 @@ func (s *Server) upsertToken(c *fiber.Ctx) error {
 	form.TokenAddress = strings.ToLower(form.TokenAddress)
 	form.ChainID = config.GetChainID()
 
+	// Store links in a consistent format: handles become URLs, anything else must be a URL
+	if err := form.NormalizeLinks(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Enforce the configured field lengths, reporting the offending field
+	if err := form.Validate(); err != nil {
+		var lengthErr *models.FieldLengthError
+		if errors.As(err, &lengthErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+				"field": lengthErr.Field,
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Create callback function to sync icon_url changes to Blockscout
 	onIconURLUpdate := func(tokenAddress, iconURL string) error {
-		return s.blockscoutClient.UpdateTokenIconURL(tokenAddress, iconURL)
+		return syncIconURL(c.UserContext(), s.blockscoutClient, tokenAddress, iconURL)
 	}
 
 	// Use the database upsert function with callback
-	err := s.database.UpsertTokenInfo(&form, onIconURLUpdate)
+	err := s.database.UpsertTokenInfo(c.UserContext(), &form, onIconURLUpdate)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to save/update token info",
@@ -228,6 +405,86 @@ func (s *Server) upsertToken(c *fiber.Ctx) error {
 	})
 }
 
+// iconSyncer is the part of the Blockscout client used to sync token icons
+type iconSyncer interface {
+	GetTokenByAddress(address string) (*client.BlockscoutToken, error)
+	UpdateTokenIconURL(ctx context.Context, address, iconURL string) error
+}
+
+// syncIconURL writes a token's new icon_url to Blockscout. An empty icon never wipes a
+// Blockscout icon that is set unless iconSync.allowClear is enabled, and tokens Blockscout
+// has not indexed yet are skipped
+func syncIconURL(ctx context.Context, blockscout iconSyncer, tokenAddress, iconURL string) error {
+	if iconURL == "" && !config.GetIconSyncAllowClear() {
+		existing, err := blockscout.GetTokenByAddress(tokenAddress)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.IconURL != "" {
+			log.Printf("Skipping icon_url clear for %s: Blockscout icon is set and iconSync.allowClear is disabled", tokenAddress)
+			return nil
+		}
+	}
+	err := blockscout.UpdateTokenIconURL(ctx, tokenAddress, iconURL)
+	if errors.Is(err, client.ErrTokenNotFound) {
+		// Blockscout has not indexed the token yet; nothing to sync
+		log.Printf("Skipping icon_url sync for %s: token not found in Blockscout", tokenAddress)
+		return nil
+	}
+	return err
+}
+
+// decodeJSONBody strictly decodes a JSON request body into dst
+// On failure it returns an error response that tells malformed JSON apart from
+// type mismatches and, when strictBody is set, unknown fields
+func decodeJSONBody(body []byte, dst interface{}) fiber.Map {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if config.GetStrictBody() {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(dst)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return fiber.Map{"error": "Request body is empty"}
+	case errors.As(err, &syntaxErr):
+		return fiber.Map{"error": fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fiber.Map{"error": "malformed JSON"}
+	case errors.As(err, &typeErr):
+		return fiber.Map{
+			"error": fmt.Sprintf("field %s must be of type %s", typeErr.Field, typeErr.Type),
+			"field": typeErr.Field,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return fiber.Map{
+			"error": fmt.Sprintf("unknown field %s", field),
+			"field": field,
+		}
+	default:
+		return fiber.Map{"error": "Invalid request body"}
+	}
+}
+
+// dashboardHandlers returns the handler chain of the dashboard route. The dashboard is public so
+// the HTML loads, unless dashboard.requireAuth locks it down; the 401 carries WWW-Authenticate so
+// browsers prompt for credentials. The page only changes on redeploy, so browsers revalidate it
+// against its ETag and get a 304
+func (s *Server) dashboardHandlers() []fiber.Handler {
+	dashboard := []fiber.Handler{}
+	if config.GetDashboardRequireAuth() {
+		dashboard = append(dashboard, authMiddleware())
+	}
+	return append(dashboard, etag.New(), s.tokenManagementPage)
+}
+
 // tokenManagementPage serves the HTML page for token management
 func (s *Server) tokenManagementPage(c *fiber.Ctx) error {
 	// Get the configured chain ID
@@ -246,10 +503,16 @@ func (s *Server) tokenManagementPage(c *fiber.Ctx) error {
 	htmlContent = strings.ReplaceAll(htmlContent, "{{.ChainID}}", chainID)
 
 	c.Set("Content-Type", "text/html")
+	// Cache, but revalidate on every load so a redeploy is picked up immediately
+	c.Set(fiber.HeaderCacheControl, "no-cache")
 	return c.SendString(htmlContent)
 }
 
-// getUnifiedTokens returns all tokens with merged data from both local and Blockscout databases
+// maxUnifiedTokenAddresses bounds the ?addresses= filter of the unified token list
+const maxUnifiedTokenAddresses = 500
+
+// getUnifiedTokens returns all tokens with merged data from both local and Blockscout databases,
+// or only those listed in ?addresses= (comma-separated), which queries just them from both databases
 func (s *Server) getUnifiedTokens(c *fiber.Ctx) error {
 	// Get the configured chain ID
 	chainID := config.GetChainID()
@@ -257,52 +520,73 @@ func (s *Server) getUnifiedTokens(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Chain ID not configured")
 	}
 
+	var addresses []string
+	for _, address := range strings.Split(c.Query("addresses"), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, strings.ToLower(address))
+		}
+	}
+	if len(addresses) > maxUnifiedTokenAddresses {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Too many addresses, at most %d are allowed", maxUnifiedTokenAddresses),
+		})
+	}
+
 	// Create callback functions for the database methods
 	getBlockscoutTokens := func() ([]client.BlockscoutToken, error) {
+		if len(addresses) > 0 {
+			return s.blockscoutClient.GetTokensByAddresses(addresses)
+		}
 		return s.blockscoutClient.GetTokens()
 	}
 
-	tokens, err := s.database.GetUnifiedTokens(chainID, getBlockscoutTokens)
+	tokens, err := s.database.GetUnifiedTokens(c.UserContext(), chainID, c.QueryBool("includeDeleted"), addresses, getBlockscoutTokens)
+	if errors.Is(err, models.ErrTooManyTokens) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "Too many tokens to list, raise maxTokensInMemory",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve unified tokens",
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"tokens": tokens,
-		"total":  len(tokens),
+	// Stream the list one token at a time instead of marshaling it into a single buffer
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := writeUnifiedTokens(w, tokens); err != nil {
+			log.Printf("Warning: failed to stream unified tokens: %v", err)
+		}
 	})
+	return nil
 }
 
-// getUnifiedTokenByAddress returns a single token with merged data from both local and Blockscout databases
-func (s *Server) getUnifiedTokenByAddress(c *fiber.Ctx) error {
-	tokenAddress := c.Params("tokenAddress")
-	chainId := config.GetChainID()
-
-	// Normalize address to lowercase to match stored format
-	tokenAddress = strings.ToLower(tokenAddress)
-
-	// Create callback function for the database method
-	getBlockscoutToken := func(address string) (*client.BlockscoutToken, error) {
-		return s.blockscoutClient.GetTokenByAddress(address)
+// writeUnifiedTokens encodes {"tokens": [...], "total": n} to w token by token
+func writeUnifiedTokens(w *bufio.Writer, tokens []models.UnifiedTokenInfo) error {
+	if _, err := w.WriteString(`{"tokens":[`); err != nil {
+		return err
 	}
-
-	// Get unified token
-	token, err := s.database.GetUnifiedTokenByAddress(tokenAddress, chainId, getBlockscoutToken)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve unified token info",
-		})
+	encoder := json.NewEncoder(w)
+	for i := range tokens {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if err := encoder.Encode(unifiedTokenResponse(&tokens[i])); err != nil {
+			return err
+		}
 	}
-
-	if token == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Token not found",
-		})
+	if _, err := fmt.Fprintf(w, `],"total":%d}`, len(tokens)); err != nil {
+		return err
 	}
+	return w.Flush()
+}
 
-	// Create a clean response structure that handles null values properly
+// unifiedTokenResponse builds the response for a unified token, as returned by the token list
+// and the single token endpoint
+func unifiedTokenResponse(token *models.UnifiedTokenInfo) map[string]interface{} {
 	response := map[string]interface{}{
 		"tokenAddress":        token.TokenAddress,
 		"chainId":             token.ChainID,
@@ -332,6 +616,325 @@ func (s *Server) getUnifiedTokenByAddress(c *fiber.Ctx) error {
 		"hasLocalData":        token.HasLocalData,
 		"hasBlockscoutData":   token.HasBlockscoutData,
 	}
+	if token.DeletedAt != nil {
+		response["deletedAt"] = token.DeletedAt
+	}
+
+	return tokenResponse(response)
+}
+
+// getUnifiedTokenByAddress returns a single token with merged data from both local and Blockscout databases
+func (s *Server) getUnifiedTokenByAddress(c *fiber.Ctx) error {
+	tokenAddress := c.Params("tokenAddress")
+	chainId := config.GetChainID()
+
+	// Normalize address to lowercase to match stored format
+	tokenAddress = strings.ToLower(tokenAddress)
+
+	// Create callback function for the database method
+	getBlockscoutToken := func(address string) (*client.BlockscoutToken, error) {
+		return s.blockscoutClient.GetTokenByAddress(address)
+	}
+
+	// Get unified token
+	token, err := s.database.GetUnifiedTokenByAddress(c.UserContext(), tokenAddress, chainId, c.QueryBool("includeDeleted"), getBlockscoutToken)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve unified token info",
+		})
+	}
+
+	if token == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Token not found",
+		})
+	}
+
+	return c.JSON(unifiedTokenResponse(token))
+}
+
+// deleteToken deletes the local info of a token, soft or hard depending on delete.mode
+func (s *Server) deleteToken(c *fiber.Ctx) error {
+	tokenAddress := strings.ToLower(c.Params("tokenAddress"))
+	chainID := c.Params("chainId")
+	soft := config.GetDeleteMode() == config.DeleteModeSoft
+
+	deleted, err := s.database.DeleteTokenInfo(c.UserContext(), tokenAddress, chainID, soft)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete token info",
+		})
+	}
+	if !deleted {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Token not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"mode":    config.GetDeleteMode(),
+		"message": "Token deleted successfully",
+	})
+}
+
+// restoreToken brings back a soft-deleted token
+func (s *Server) restoreToken(c *fiber.Ctx) error {
+	tokenAddress := strings.ToLower(c.Params("tokenAddress"))
+	chainID := c.Params("chainId")
+
+	restored, err := s.database.RestoreTokenInfo(c.UserContext(), tokenAddress, chainID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore token info",
+		})
+	}
+	if !restored {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No deleted token found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Token restored successfully",
+	})
+}
+
+// recreateAllContainers enqueues a job recreating every configured container
+func (s *Server) recreateAllContainers(c *fiber.Ctx) error {
+	if s.worker == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Container worker is not running",
+		})
+	}
+
+	if !config.GetManageContainers() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Container management is disabled (manageContainers: false)",
+		})
+	}
+
+	containers := docker.ConfiguredContainers()
+	if len(containers) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No containers configured",
+		})
+	}
+
+	// An explicit recreate-all retries jobs that stopped after repeated failures
+	s.worker.ResetFailures()
+
+	// AddJob deduplicates, so an identical job already in the queue is reported rather than re-added
+	added := s.worker.AddJob(containers)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"jobKey":        s.worker.JobKey(containers),
+		"alreadyQueued": !added,
+	})
+}
+
+// previewFeaturedNetworks renders NEXT_PUBLIC_FEATURED_NETWORKS for the newest record of a
+// chain (?chainId=, defaulting to the configured chain) without writing anything
+func (s *Server) previewFeaturedNetworks(c *fiber.Ctx) error {
+	chainID := c.QueryInt("chainId", viper.GetInt("chainId"))
+
+	// Use the first table whose handlers render the featured networks
+	var table *config.TableConfig
+	for _, tableConfig := range config.GetTables() {
+		if len(tableConfig.Handlers) == 0 || slices.Contains(tableConfig.Handlers, "name") || slices.Contains(tableConfig.Handlers, "explorer") {
+			table = &tableConfig
+			break
+		}
+	}
+	if table == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No monitored table runs the name or explorer handler",
+		})
+	}
+
+	record, err := subscription.LatestRecord(table.Name, chainID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load record",
+		})
+	}
+	if record == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("No record found for chain %d", chainID),
+		})
+	}
+
+	preview, err := handlers.PreviewFeaturedNetworks(record, table.Handlers)
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"chainId":  chainID,
+		"table":    table.Name,
+		"recordId": record.ID,
+		"preview":  preview,
+	})
+}
+
+// SetBuildInfo sets the build information reported by the version endpoint
+func (s *Server) SetBuildInfo(info BuildInfo) {
+	s.buildInfo = info
+}
+
+// version returns the build information and the sidecar database migration version
+func (s *Server) version(c *fiber.Ctx) error {
+	return writeVersion(c, s.buildInfo, s.database)
+}
+
+// migrationVersioner is the part of the sidecar database reporting its migration version
+type migrationVersioner interface {
+	MigrationVersion() (int64, error)
+}
+
+// writeVersion responds with info and the migration version of migrations
+func writeVersion(c *fiber.Ctx, info BuildInfo, migrations migrationVersioner) error {
+	migrationVersion, err := migrations.MigrationVersion()
+	if err != nil {
+		log.Printf("Failed to read migration version: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read migration version",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"version":            info.Version,
+		"commit":             info.Commit,
+		"buildTime":          info.BuildTime,
+		"dbMigrationVersion": migrationVersion,
+	})
+}
+
+// effectiveConfig returns the configuration the process loaded, merged from the config file,
+// environment and --set overrides, with secrets redacted
+func (s *Server) effectiveConfig(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"config": config.EffectiveSettings(),
+	})
+}
+
+// SetSubscription makes reconcile share the running subscription, so its handler passes
+// are serialized with realtime changes
+func (s *Server) SetSubscription(sub *subscription.Subscription) {
+	s.subscription.Store(sub)
+}
+
+// reconcile re-applies the newest records of a chain (?chainId=) or of every allowed chain,
+// and reports which handlers fired and which containers were queued for recreation
+func (s *Server) reconcile(c *fiber.Ctx) error {
+	if s.worker == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Container worker is not running",
+		})
+	}
+
+	var chainIDs []int
+	if c.Query("chainId") != "" {
+		chainID := c.QueryInt("chainId")
+		if chainID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid chainId",
+			})
+		}
+		chainIDs = []int{chainID}
+	}
+
+	outcomes, err := s.subscription.Load().Reconcile(s.ctx, s.worker, chainIDs)
+	if err != nil {
+		log.Printf("Reconcile failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":    "Failed to reconcile",
+			"outcomes": outcomes,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"outcomes": outcomes,
+	})
+}
+
+// runHandler re-applies the newest record of a chain (?chainId=, the configured chain by default)
+// with a single handler, e.g. the image handler after a CDN outage, and reports what it did
+func (s *Server) runHandler(c *fiber.Ctx) error {
+	if s.worker == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Container worker is not running",
+		})
+	}
+
+	name := c.Params("name")
+	if !slices.Contains(handlers.HandlerNames, name) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":    "Unknown handler",
+			"handlers": handlers.HandlerNames,
+		})
+	}
+
+	chainID := viper.GetInt("chainId")
+	if c.Query("chainId") != "" {
+		chainID = c.QueryInt("chainId")
+		if chainID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid chainId",
+			})
+		}
+	}
+
+	outcomes, err := s.subscription.Load().RunHandler(s.ctx, s.worker, name, chainID)
+	if err != nil {
+		log.Printf("Running handler %s failed: %v", name, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":    "Failed to run handler",
+			"outcomes": outcomes,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"outcomes": outcomes,
+	})
+}
+
+// status reports the state of the change subscription, so a rejected Realtime
+// subscription is visible without reading the logs, and the recreation jobs that are failing
+func (s *Server) status(c *fiber.Ctx) error {
+	failedJobs := []worker.FailedJob{}
+	if s.worker != nil {
+		failedJobs = s.worker.FailedJobs()
+	}
+	return c.JSON(fiber.Map{
+		"subscription": subscription.CurrentStatus(),
+		"failedJobs":   failedJobs,
+	})
+}
+
+// lastRecreationLog returns the output of the most recent container recreation
+func (s *Server) lastRecreationLog(c *fiber.Ctx) error {
+	if s.worker == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Container worker is not running",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"lines": s.worker.LastRecreationLog(),
+	})
+}
+
+// metrics serves the registered metrics in the Prometheus text format
+func (s *Server) metrics(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	if err := metrics.WriteText(&buf); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to render metrics")
+	}
 
-	return c.JSON(response)
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.Send(buf.Bytes())
 }
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/worker"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// serve runs handler for a single request and decodes its JSON response
+func serve(t *testing.T, handler fiber.Handler, req *http.Request) (int, map[string]any) {
+	t.Helper()
+	app := fiber.New()
+	app.All("/*", handler)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestRecreateAllContainersQueuesConfiguredContainers(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("frontendContainerName", "frontend-1")
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("backendContainerName", "backend-1")
+	viper.Set("backendServiceName", "backend")
+	viper.Set("statsContainerName", "stats-1")
+	viper.Set("statsServiceName", "stats")
+	viper.Set("proxyServiceName", "proxy") // no proxy container, so it is not recreated
+	viper.Set("chainId", 1)
+
+	s := &Server{worker: worker.New()}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/containers/recreate-all", nil)
+
+	status, body := serve(t, s.recreateAllContainers, req)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %v", status, fiber.StatusAccepted, body)
+	}
+	if body["jobKey"] != "backend-1/backend/1,frontend-1/frontend/1,stats-1/stats/1" || body["alreadyQueued"] != false {
+		t.Errorf("response = %v, want a new job for exactly the configured containers", body)
+	}
+
+	// A second request finds the job already queued
+	if _, body := serve(t, s.recreateAllContainers, req); body["alreadyQueued"] != true {
+		t.Errorf("second response = %v, want alreadyQueued", body)
+	}
+}
+
+func TestRecreateAllContainersWithoutContainerManagement(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("manageContainers", false)
+
+	s := &Server{worker: worker.New()}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/containers/recreate-all", nil)
+	if status, _ := serve(t, s.recreateAllContainers, req); status != fiber.StatusConflict {
+		t.Errorf("status = %d, want %d", status, fiber.StatusConflict)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	app := fiber.New()
+	app.Use(compression())
+	body := strings.Repeat(`{"tokenAddress":"0xabc","projectName":"Aurora"},`, 200)
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendString(body) })
+
+	tests := []struct {
+		name         string
+		path         string
+		header       map[string]string
+		wantEncoding string
+	}{
+		{"gzip when accepted", "/api/v1/tokens", map[string]string{"Accept-Encoding": "gzip"}, "gzip"},
+		{"plain without Accept-Encoding", "/api/v1/tokens", nil, ""},
+		{"metrics never compressed", "/metrics", map[string]string{"Accept-Encoding": "gzip"}, ""},
+		{"event streams never compressed", "/api/v1/events", map[string]string{"Accept-Encoding": "gzip", "Accept": "text/event-stream"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+		})
+	}
+}
+
+func TestUpsertTokenBodyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		strictBody bool
+		body       string
+		wantError  string
+		wantField  any
+	}{
+		{"malformed JSON", false, `{"tokenAddress": "0xabc",`, "malformed JSON", nil},
+		{"wrong field type", false, `{"tokenAddress": 42}`, "field tokenAddress must be of type string", "tokenAddress"},
+		{"unknown field rejected when strict", true, `{"tokenAddress": "0xabc", "colour": "red"}`, "unknown field colour", "colour"},
+		{"empty body", false, ``, "Request body is empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("strictBody", tt.strictBody)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			status, body := serve(t, (&Server{}).upsertToken, req)
+
+			if status != fiber.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", status, fiber.StatusBadRequest)
+			}
+			if message, _ := body["error"].(string); !strings.HasPrefix(message, tt.wantError) {
+				t.Errorf("error = %q, want it to start with %q", message, tt.wantError)
+			}
+			if body["field"] != tt.wantField {
+				t.Errorf("field = %v, want %v", body["field"], tt.wantField)
+			}
+		})
+	}
+}
+
+func TestUpsertTokenUnknownFieldAllowedWhenNotStrict(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("strictBody", false)
+
+	// The body decodes, so the request only fails validation for the missing address
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(`{"colour": "red"}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, body := serve(t, (&Server{}).upsertToken, req)
+	if body["error"] != "Token address is required" {
+		t.Errorf("error = %v, want the unknown field ignored", body["error"])
+	}
+}
+
+func TestReconcileRejectsInvalidRequests(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/reconcile?chainId=1", nil)
+	if status, _ := serve(t, (&Server{}).reconcile, req); status != fiber.StatusServiceUnavailable {
+		t.Errorf("status without a worker = %d, want %d", status, fiber.StatusServiceUnavailable)
+	}
+
+	s := &Server{worker: worker.New()}
+	for _, chainID := range []string{"0", "-1", "abc"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/reconcile?chainId="+chainID, nil)
+		if status, _ := serve(t, s.reconcile, req); status != fiber.StatusBadRequest {
+			t.Errorf("status for chainId %s = %d, want %d", chainID, status, fiber.StatusBadRequest)
+		}
+	}
+}
+
+func TestUpsertTokenRejectsOverLengthField(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("limits.projectNameMax", 6)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(`{"tokenAddress": "0xabc", "projectName": "Aurora Labs"}`))
+	req.Header.Set("Content-Type", "application/json")
+	status, body := serve(t, (&Server{}).upsertToken, req)
+	if status != fiber.StatusBadRequest || body["field"] != "projectName" {
+		t.Errorf("response = %d %v, want 400 naming projectName", status, body)
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/env"
+	"blockscout-vc/internal/handlers"
+	"blockscout-vc/internal/subscription"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// Snapshot is the managed state captured by GET /snapshot and re-applied by POST /snapshot/restore
+type Snapshot struct {
+	TakenAt    time.Time                    `json:"takenAt"`
+	OutputMode string                       `json:"outputMode"`
+	Override   map[string]map[string]string `json:"composeOverride,omitempty"` // Service environments, in composeOverride mode
+	Chains     []ChainSnapshot              `json:"chains"`
+}
+
+// ChainSnapshot is the state of a single chain
+type ChainSnapshot struct {
+	ChainID      int                         `json:"chainId"`
+	Env          map[string]string           `json:"env,omitempty"` // The chain's env file, in env mode
+	Containers   []docker.Container          `json:"containers"`
+	Records      map[string]*handlers.Record `json:"records"` // Newest (active) record per monitored table, nil when there is none
+	RecordsError string                      `json:"recordsError,omitempty"`
+}
+
+// RestoredChain reports what restoring a snapshot did for a chain
+type RestoredChain struct {
+	ChainID   int      `json:"chainId"`
+	Changed   bool     `json:"changed"`
+	Restarted []string `json:"restarted"`
+	JobQueued bool     `json:"jobQueued"`
+}
+
+// snapshotChainIDs returns the configured chain followed by every chain in allowedChainIds
+func snapshotChainIDs() []int {
+	chainIDs := []int{viper.GetInt("chainId")}
+	for _, chainID := range config.GetAllowedChainIDs() {
+		if !slices.Contains(chainIDs, chainID) {
+			chainIDs = append(chainIDs, chainID)
+		}
+	}
+	return chainIDs
+}
+
+// getSnapshot captures the env state, configured containers and newest records of every chain,
+// for backup or to roll back a bad change with restoreSnapshot
+func (s *Server) getSnapshot(c *fiber.Ctx) error {
+	snapshot := Snapshot{
+		TakenAt:    time.Now().UTC(),
+		OutputMode: config.GetOutputMode(),
+		Chains:     []ChainSnapshot{},
+	}
+
+	if snapshot.OutputMode == config.OutputModeComposeOverride {
+		override := env.NewComposeOverride(config.GetComposeOverridePath())
+		if err := override.ReadOverrideFile(); err != nil {
+			log.Printf("Snapshot failed: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to read compose override",
+			})
+		}
+		snapshot.Override = override.Services
+	}
+
+	for _, chainID := range snapshotChainIDs() {
+		chain := ChainSnapshot{
+			ChainID:    chainID,
+			Containers: docker.ChainContainers(chainID),
+			Records:    map[string]*handlers.Record{},
+		}
+		if snapshot.OutputMode == config.OutputModeEnv {
+			chainEnv := env.NewEnvForChain(chainID)
+			if err := chainEnv.ReadEnvFile(); err != nil {
+				log.Printf("Snapshot failed for chain %d: %v", chainID, err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   "Failed to read env file",
+					"chainId": chainID,
+				})
+			}
+			chain.Env = chainEnv.EnvFile
+		}
+
+		// Records are informational, so a database outage does not prevent capturing the env state
+		for _, table := range config.GetTables() {
+			record, err := subscription.LatestRecord(table.Name, chainID)
+			if err != nil {
+				chain.RecordsError = err.Error()
+				break
+			}
+			chain.Records[table.Name] = record
+		}
+		snapshot.Chains = append(snapshot.Chains, chain)
+	}
+
+	return c.JSON(snapshot)
+}
+
+// restoreSnapshot re-applies the env state of a snapshot taken with getSnapshot, replacing the
+// current env files (or compose override) exactly, and restarts the containers of what changed
+func (s *Server) restoreSnapshot(c *fiber.Ctx) error {
+	if s.worker == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Container worker is not running",
+		})
+	}
+
+	var snapshot Snapshot
+	if errResponse := decodeJSONBody(c.Body(), &snapshot); errResponse != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errResponse)
+	}
+	if snapshot.OutputMode != config.GetOutputMode() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Snapshot was taken with outputMode %q, the sidecar runs with %q", snapshot.OutputMode, config.GetOutputMode()),
+		})
+	}
+	known := snapshotChainIDs()
+	for _, chain := range snapshot.Chains {
+		if !slices.Contains(known, chain.ChainID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Snapshot contains a chain that is not configured or allowed",
+				"chainId": chain.ChainID,
+			})
+		}
+	}
+
+	message := fmt.Sprintf("Restore snapshot taken at %s", snapshot.TakenAt.Format(time.RFC3339))
+	restored := []RestoredChain{}
+	if snapshot.OutputMode == config.OutputModeComposeOverride {
+		changedServices, err := env.NewComposeOverride(config.GetComposeOverridePath()).ReplaceServices(snapshot.Override)
+		if err != nil {
+			log.Printf("Snapshot restore failed: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to restore compose override",
+			})
+		}
+		for _, chain := range snapshot.Chains {
+			containers := []docker.Container{}
+			for _, container := range docker.ChainContainers(chain.ChainID) {
+				if slices.Contains(changedServices, container.ServiceName) {
+					containers = append(containers, container)
+				}
+			}
+			restored = append(restored, s.restartRestored(chain.ChainID, len(containers) > 0, containers))
+		}
+	} else {
+		for _, chain := range snapshot.Chains {
+			if chain.Env == nil {
+				continue
+			}
+			chainEnv := env.NewEnvForChain(chain.ChainID)
+			changed, err := chainEnv.ReplaceEnvVars(chain.Env)
+			if err != nil {
+				log.Printf("Snapshot restore failed for chain %d: %v", chain.ChainID, err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":    "Failed to restore env file",
+					"chainId":  chain.ChainID,
+					"restored": restored,
+				})
+			}
+			if changed {
+				if err := chainEnv.CommitEnvFile(message); err != nil {
+					log.Printf("Warning: failed to commit env changes: %v", err)
+				}
+			}
+			restored = append(restored, s.restartRestored(chain.ChainID, changed, docker.ChainContainers(chain.ChainID)))
+		}
+	}
+
+	log.Printf("%s: %+v", message, restored)
+	return c.JSON(fiber.Map{
+		"restored": restored,
+	})
+}
+
+// restartRestored queues the recreation of a chain's containers after its state changed
+func (s *Server) restartRestored(chainID int, changed bool, containers []docker.Container) RestoredChain {
+	result := RestoredChain{ChainID: chainID, Changed: changed, Restarted: []string{}}
+	if !changed || len(containers) == 0 {
+		return result
+	}
+	for _, container := range containers {
+		result.Restarted = append(result.Restarted, container.Name)
+	}
+	result.JobQueued = s.worker.AddJob(containers)
+	return result
+}
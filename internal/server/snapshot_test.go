@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/env"
+	"blockscout-vc/internal/worker"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// useSnapshotEnv configures an env file holding content and a frontend container for chain 1
+func useSnapshotEnv(t *testing.T, content string) string {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(viper.Reset)
+	viper.Set("pathToEnvFile", envFile)
+	viper.Set("chainId", 1)
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+	return envFile
+}
+
+// restore posts a snapshot to restoreSnapshot
+func restore(t *testing.T, s *Server, snapshot []byte) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/snapshot/restore", bytes.NewReader(snapshot))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return serve(t, s.restoreSnapshot, req)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	envFile := useSnapshotEnv(t, "NEXT_PUBLIC_NETWORK_NAME=Aurora\nNEXT_PUBLIC_NETWORK_CURRENCY_SYMBOL=ETH\n")
+	s := &Server{worker: worker.New()}
+
+	status, snapshot := serve(t, s.getSnapshot, httptest.NewRequest(http.MethodGet, "/api/v1/snapshot", nil))
+	if status != fiber.StatusOK {
+		t.Fatalf("GET snapshot = %d: %v", status, snapshot)
+	}
+	chains, _ := snapshot["chains"].([]any)
+	if snapshot["outputMode"] != "env" || len(chains) != 1 {
+		t.Fatalf("snapshot = %v, want one chain in env mode", snapshot)
+	}
+	chain := chains[0].(map[string]any)
+	if chain["chainId"] != float64(1) || len(chain["containers"].([]any)) != 1 {
+		t.Errorf("chain = %v, want chain 1 with its frontend container", chain)
+	}
+	if chain["env"].(map[string]any)["NEXT_PUBLIC_NETWORK_NAME"] != "Aurora" {
+		t.Errorf("snapshot env = %v, want the env file", chain["env"])
+	}
+
+	// A bad change edits one key and adds another
+	if err := os.WriteFile(envFile, []byte("NEXT_PUBLIC_NETWORK_NAME=Broken\nNEXT_PUBLIC_NETWORK_CURRENCY_SYMBOL=ETH\nEXTRA=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, response := restore(t, s, body)
+	if status != fiber.StatusOK {
+		t.Fatalf("POST restore = %d: %v", status, response)
+	}
+	restored := response["restored"].([]any)[0].(map[string]any)
+	if restored["changed"] != true || restored["jobQueued"] != true || !reflect.DeepEqual(restored["restarted"], []any{"frontend-1"}) {
+		t.Errorf("restored = %v, want chain 1 changed and its frontend restarted", restored)
+	}
+	onDisk := env.NewEnvForChain(1)
+	if err := onDisk.ReadEnvFile(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"NEXT_PUBLIC_NETWORK_NAME": "Aurora", "NEXT_PUBLIC_NETWORK_CURRENCY_SYMBOL": "ETH"}
+	if !reflect.DeepEqual(onDisk.EnvFile, want) {
+		t.Errorf("env file after restore = %v, want %v", onDisk.EnvFile, want)
+	}
+
+	// Restoring the same snapshot again changes nothing, so nothing restarts
+	s.worker = worker.New()
+	if _, response := restore(t, s, body); response["restored"].([]any)[0].(map[string]any)["changed"] != false {
+		t.Errorf("repeated restore = %v, want no change", response)
+	}
+}
+
+func TestRestoreSnapshotRejectsInvalidSnapshots(t *testing.T) {
+	envFile := useSnapshotEnv(t, "NEXT_PUBLIC_NETWORK_NAME=Aurora\n")
+	tests := []struct {
+		name      string
+		snapshot  string
+		wantError string
+	}{
+		{"other output mode", `{"outputMode": "composeOverride", "chains": []}`, "Snapshot was taken with outputMode"},
+		{"unknown chain", `{"outputMode": "env", "chains": [{"chainId": 2, "env": {"A": "1"}}]}`, "Snapshot contains a chain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := restore(t, &Server{worker: worker.New()}, []byte(tt.snapshot))
+			if message, _ := response["error"].(string); status != fiber.StatusBadRequest || !strings.HasPrefix(message, tt.wantError) {
+				t.Errorf("response = %d %v, want 400 %q", status, response, tt.wantError)
+			}
+		})
+	}
+	if content, _ := os.ReadFile(envFile); string(content) != "NEXT_PUBLIC_NETWORK_NAME=Aurora\n" {
+		t.Errorf("rejected snapshots changed the env file to %q", content)
+	}
+
+	if status, _ := restore(t, &Server{}, []byte(`{"outputMode": "env", "chains": []}`)); status != fiber.StatusServiceUnavailable {
+		t.Errorf("status without a worker = %d, want %d", status, fiber.StatusServiceUnavailable)
+	}
+}
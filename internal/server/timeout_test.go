@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// slowQuery stands in for a database lookup that only ends with the request's context
+func slowQuery(c *fiber.Ctx) error {
+	<-c.UserContext().Done()
+	if errors.Is(c.UserContext().Err(), context.DeadlineExceeded) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "timed out"})
+	}
+	return c.SendStatus(fiber.StatusInternalServerError)
+}
+
+func TestPublicDBTimeoutAbortsBeforeAdmin(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("dbQueryTimeout.public", "50ms")
+	viper.Set("dbQueryTimeout.admin", "10s")
+
+	app := fiber.New()
+	app.Get("/public", dbTimeout(config.GetDBQueryTimeoutPublic()), slowQuery)
+	app.Get("/admin", dbTimeout(config.GetDBQueryTimeoutAdmin()), func(c *fiber.Ctx) error {
+		deadline, ok := c.UserContext().Deadline()
+		if !ok || time.Until(deadline) < 5*time.Second {
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	start := time.Now()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/public", nil), -1)
+	if err != nil {
+		t.Fatalf("public request failed: %v", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("public request took %s, want it aborted near 50ms", elapsed)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("public status = %d, want %d", resp.StatusCode, fiber.StatusServiceUnavailable)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/admin", nil), -1)
+	if err != nil {
+		t.Fatalf("admin request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("admin status = %d, want its longer deadline", resp.StatusCode)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fixedMigrations reports a fixed migration version or error
+type fixedMigrations struct {
+	version int64
+	err     error
+}
+
+func (f fixedMigrations) MigrationVersion() (int64, error) {
+	return f.version, f.err
+}
+
+func TestVersionReturnsBuildInfoAndMigrationVersion(t *testing.T) {
+	info := BuildInfo{Version: "v1.2.3", Commit: "abc1234", BuildTime: "2024-01-02T03:04:05Z"}
+	handler := func(c *fiber.Ctx) error {
+		return writeVersion(c, info, fixedMigrations{version: 7})
+	}
+
+	status, body := serve(t, handler, httptest.NewRequest("GET", "/version", nil))
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+	want := map[string]any{
+		"version":            "v1.2.3",
+		"commit":             "abc1234",
+		"buildTime":          "2024-01-02T03:04:05Z",
+		"dbMigrationVersion": float64(7),
+	}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("%s = %v, want %v", key, body[key], value)
+		}
+	}
+}
+
+func TestVersionReportsMigrationVersionFailure(t *testing.T) {
+	handler := func(c *fiber.Ctx) error {
+		return writeVersion(c, BuildInfo{}, fixedMigrations{err: errors.New("connection refused")})
+	}
+
+	status, body := serve(t, handler, httptest.NewRequest("GET", "/version", nil))
+	if status != fiber.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusInternalServerError)
+	}
+	if body["error"] == nil {
+		t.Error("expected an error message")
+	}
+}
@@ -0,0 +1,47 @@
+package subscription
+
+import (
+	"blockscout-vc/internal/config"
+	"encoding/json"
+	"fmt"
+)
+
+// markActive sets Inactive on changes whose record is not the active row, according to
+// monitoredActiveColumn. record holds the raw columns of the changed row
+// Realtime allows a single filter per subscription, taken by chain_id, so the active
+// column is checked here rather than by the server
+func markActive(changes *PostgresChanges, record map[string]json.RawMessage) error {
+	column, err := config.GetMonitoredActiveColumn()
+	if err != nil || column == "" {
+		return err
+	}
+	changes.Inactive = !isActiveValue(record[column])
+	return nil
+}
+
+// isActiveValue reports whether a JSON column value is true; Postgres booleans may arrive
+// as strings in hand-written NOTIFY payloads
+func isActiveValue(value json.RawMessage) bool {
+	var active any
+	if err := json.Unmarshal(value, &active); err != nil {
+		return false
+	}
+	switch active := active.(type) {
+	case bool:
+		return active
+	case string:
+		return active == "t" || active == "true"
+	default:
+		return false
+	}
+}
+
+// activeCondition returns the SQL condition selecting only active rows, or "" when
+// monitoredActiveColumn is not set
+func activeCondition() (string, error) {
+	column, err := config.GetMonitoredActiveColumn()
+	if err != nil || column == "" {
+		return "", err
+	}
+	return fmt.Sprintf(" AND %s IS TRUE", column), nil
+}
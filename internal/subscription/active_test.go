@@ -0,0 +1,99 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/worker"
+
+	"github.com/spf13/viper"
+)
+
+func TestInitialCheckAppliesOnlyActiveRecord(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("monitoredActiveColumn", "active")
+	now := time.Now()
+	db := sql.OpenDB(versionedTable{rows: []map[string]driver.Value{
+		{"id": int64(1), "chain_id": int64(1), "name": "Newest", "updated_at": now, "active": false},
+		{"id": int64(2), "chain_id": int64(1), "name": "Active", "updated_at": now.Add(-time.Hour), "active": true},
+		{"id": int64(3), "chain_id": int64(1), "name": "Older", "updated_at": now.Add(-2 * time.Hour), "active": false},
+	}})
+	t.Cleanup(func() { db.Close() })
+
+	s := New(nil)
+	table := config.TableConfig{Name: "silos", Handlers: []string{"name"}}
+	outcome, err := s.initialCheckTable(context.Background(), db, table, 1, worker.New(), nil)
+	if err != nil {
+		t.Fatalf("initialCheckTable: %v", err)
+	}
+	if outcome == nil || outcome.RecordID != 2 {
+		t.Fatalf("outcome = %+v, want the active record 2 applied", outcome)
+	}
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Active") || strings.Contains(string(content), "Newest") {
+		t.Errorf("env file = %q, want only the active name", content)
+	}
+}
+
+func TestRouteSkipsInactiveRecord(t *testing.T) {
+	envFile := useTwoTables(t)
+	viper.Set("monitoredActiveColumn", "active")
+	monitored := tablesByName(config.GetTables())
+	s := New(nil)
+
+	for _, payload := range []string{
+		`{"table":"branding","type":"UPDATE","record":{"id":1,"chain_id":1,"name":"Inactive","active":false}}`,
+		`{"table":"branding","type":"UPDATE","record":{"id":2,"chain_id":1,"name":"Hand-written","active":"f"}}`,
+		`{"table":"branding","type":"UPDATE","record":{"id":3,"chain_id":1,"name":"Missing"}}`,
+	} {
+		changes, err := NewNotifyChanges([]byte(payload), nil)
+		if err != nil {
+			t.Fatalf("NewNotifyChanges: %v", err)
+		}
+		if !changes.Inactive {
+			t.Errorf("record %d was not marked inactive", changes.Payload.Data.Record.ID)
+		}
+		s.route(context.Background(), changes, monitored)
+	}
+	if content, _ := os.ReadFile(envFile); len(content) != 0 {
+		t.Fatalf("inactive records wrote %q", content)
+	}
+
+	changes, err := NewNotifyChanges([]byte(`{"table":"branding","type":"UPDATE","record":{"id":4,"chain_id":1,"name":"Aurora","active":"t"}}`), nil)
+	if err != nil {
+		t.Fatalf("NewNotifyChanges: %v", err)
+	}
+	s.route(context.Background(), changes, monitored)
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_NAME=Aurora") {
+		t.Errorf("active record was not applied: %q", content)
+	}
+}
+
+func TestNewPostgresChangesMarksInactiveRecord(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("monitoredActiveColumn", "active")
+	message := `{"event":"postgres_changes","payload":{"data":{"table":"silos","type":"UPDATE","record":{"id":1,"chain_id":1,"active":%s}}}}`
+
+	for value, wantInactive := range map[string]bool{"true": false, "false": true} {
+		changes, err := NewPostgresChanges([]byte(strings.Replace(message, "%s", value, 1)), nil)
+		if err != nil {
+			t.Fatalf("NewPostgresChanges: %v", err)
+		}
+		if changes.Inactive != wantInactive {
+			t.Errorf("active = %s: Inactive = %t, want %t", value, changes.Inactive, wantInactive)
+		}
+	}
+}
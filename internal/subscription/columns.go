@@ -0,0 +1,150 @@
+package subscription
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/handlers"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// recordColumn maps a handlers.Record field to the table column named by its json tag
+type recordColumn struct {
+	name     string
+	field    int  // Index of the field in handlers.Record
+	optional bool // Tagged column:"optional"; tables without the column read its zero value
+}
+
+// recordColumns lists the monitored table columns in Record field order, so adding a
+// field to Record (with its column as json tag) is enough to select and scan it
+var recordColumns = buildRecordColumns()
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// buildRecordColumns derives the column list from the json tags of handlers.Record
+func buildRecordColumns() []recordColumn {
+	recordType := reflect.TypeOf(handlers.Record{})
+	columns := make([]recordColumn, 0, recordType.NumField())
+	for i := 0; i < recordType.NumField(); i++ {
+		name, _, _ := strings.Cut(recordType.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		optional := recordType.Field(i).Tag.Get("column") == "optional"
+		columns = append(columns, recordColumn{name: name, field: i, optional: optional})
+	}
+	return columns
+}
+
+// recordSelectList returns the select list for recordColumns
+// Text columns are wrapped in COALESCE so NULLs scan as empty strings, and optional
+// text columns missing from the table (not in existing) are selected as ”
+func recordSelectList(existing map[string]bool) string {
+	recordType := reflect.TypeOf(handlers.Record{})
+	expressions := make([]string, 0, len(recordColumns))
+	for _, column := range recordColumns {
+		isText := recordType.Field(column.field).Type.Kind() == reflect.String
+		if column.optional && !existing[column.name] && isText {
+			expressions = append(expressions, fmt.Sprintf("'' AS %s", column.name))
+			continue
+		}
+		if isText {
+			expressions = append(expressions, fmt.Sprintf("COALESCE(%s, '') AS %s", column.name, column.name))
+			continue
+		}
+		expressions = append(expressions, column.name)
+	}
+	return strings.Join(expressions, ", ")
+}
+
+// hasOptionalColumns reports whether any record column is optional
+func hasOptionalColumns() bool {
+	for _, column := range recordColumns {
+		if column.optional {
+			return true
+		}
+	}
+	return false
+}
+
+// createTableStatement returns the CREATE TABLE statement for a monitored table holding
+// every record column. The table name must already be validated with config.SafeIdentifier
+func createTableStatement(table string) string {
+	recordType := reflect.TypeOf(handlers.Record{})
+	definitions := make([]string, 0, len(recordColumns))
+	for _, column := range recordColumns {
+		var definition string
+		switch fieldType := recordType.Field(column.field).Type; {
+		case column.name == "id":
+			definition = "id BIGSERIAL PRIMARY KEY"
+		case fieldType == timeType:
+			definition = column.name + " TIMESTAMPTZ NOT NULL DEFAULT now()"
+		case fieldType.Kind() == reflect.Int:
+			definition = column.name + " BIGINT NOT NULL"
+		default:
+			definition = column.name + " TEXT"
+		}
+		definitions = append(definitions, definition)
+	}
+	if column, err := config.GetMonitoredActiveColumn(); err == nil && column != "" {
+		definitions = append(definitions, column+" BOOLEAN NOT NULL DEFAULT true")
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n)", table, strings.Join(definitions, ",\n\t"))
+}
+
+// tableColumns returns the columns of a table, so optional record columns are only
+// selected from tables that have them
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT column_name FROM information_schema.columns WHERE table_name = $1 AND table_schema = ANY(current_schemas(false))`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("Warning: failed to close rows: %v", closeErr)
+		}
+	}()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// scanRecord scans a row selected with recordSelectList into a Record
+// NULL timestamps become the zero time
+func scanRecord(rows *sql.Rows) (handlers.Record, error) {
+	var record handlers.Record
+	value := reflect.ValueOf(&record).Elem()
+
+	destinations := make([]any, len(recordColumns))
+	nullTimes := make(map[int]*sql.NullTime)
+	for i, column := range recordColumns {
+		field := value.Field(column.field)
+		if field.Type() == timeType {
+			nullTime := &sql.NullTime{}
+			nullTimes[column.field] = nullTime
+			destinations[i] = nullTime
+			continue
+		}
+		destinations[i] = field.Addr().Interface()
+	}
+
+	if err := rows.Scan(destinations...); err != nil {
+		return record, err
+	}
+	for field, nullTime := range nullTimes {
+		value.Field(field).Set(reflect.ValueOf(nullTime.Time))
+	}
+	return record, nil
+}
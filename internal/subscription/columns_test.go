@@ -0,0 +1,139 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rowDriver answers every query with a single row holding rowValues, keyed by column
+// name, in recordColumns order as a query built with recordSelectList returns them
+type rowDriver struct{}
+
+var (
+	rowValues   map[string]driver.Value
+	registerRow sync.Once
+)
+
+func (rowDriver) Open(string) (driver.Conn, error) { return rowConn{}, nil }
+
+type rowConn struct{}
+
+func (rowConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (rowConn) Close() error                        { return nil }
+func (rowConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (rowConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &singleRow{}, nil
+}
+
+type singleRow struct{ done bool }
+
+func (r *singleRow) Columns() []string {
+	names := make([]string, len(recordColumns))
+	for i, column := range recordColumns {
+		names[i] = column.name
+	}
+	return names
+}
+
+func (r *singleRow) Close() error { return nil }
+
+func (r *singleRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	for i, column := range recordColumns {
+		value, ok := rowValues[column.name]
+		if !ok {
+			// Text columns are selected with COALESCE, so they are never NULL
+			value = ""
+		}
+		dest[i] = value
+	}
+	return nil
+}
+
+// queryRow returns rows holding the single row values
+func queryRow(t *testing.T, values map[string]driver.Value) *sql.Rows {
+	t.Helper()
+	registerRow.Do(func() { sql.Register("row", rowDriver{}) })
+	rowValues = values
+
+	db, err := sql.Open("row", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rows.Close() })
+	if !rows.Next() {
+		t.Fatal("no row returned")
+	}
+	return rows
+}
+
+func TestScanRecordTimestamps(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+	rows := queryRow(t, map[string]driver.Value{
+		"id":         int64(7),
+		"name":       "Aurora",
+		"chain_id":   int64(1313161555),
+		"created_at": createdAt,
+		"updated_at": nil, // NULL
+	})
+
+	record, err := scanRecord(rows)
+	if err != nil {
+		t.Fatalf("scanRecord: %v", err)
+	}
+	if !record.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreatedAt = %s, want %s", record.CreatedAt, createdAt)
+	}
+	if !record.UpdatedAt.IsZero() {
+		t.Errorf("UpdatedAt = %s, want the zero time for NULL", record.UpdatedAt)
+	}
+	if record.ID != 7 || record.Name != "Aurora" || record.ChainID != 1313161555 {
+		t.Errorf("record = %+v, want the other columns scanned", record)
+	}
+}
+
+func TestRecordSelectListMatchesRecordColumns(t *testing.T) {
+	names := []string{}
+	for _, column := range recordColumns {
+		names = append(names, column.name)
+	}
+	wantNames := []string{"id", "name", "base_token_symbol", "chain_id", "network_logo", "network_logo_dark",
+		"favicon", "explorer_url", "network_type", "created_at", "updated_at"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("recordColumns = %v, want %v", names, wantNames)
+	}
+
+	common := "id, COALESCE(name, '') AS name, COALESCE(base_token_symbol, '') AS base_token_symbol, chain_id, " +
+		"COALESCE(network_logo, '') AS network_logo, COALESCE(network_logo_dark, '') AS network_logo_dark, " +
+		"COALESCE(favicon, '') AS favicon, COALESCE(explorer_url, '') AS explorer_url, "
+	tests := []struct {
+		name     string
+		existing map[string]bool
+		want     string
+	}{
+		{"optional column present", map[string]bool{"network_type": true}, common + "COALESCE(network_type, '') AS network_type, created_at, updated_at"},
+		{"optional column missing", map[string]bool{}, common + "'' AS network_type, created_at, updated_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recordSelectList(tt.existing); got != tt.want {
+				t.Errorf("recordSelectList() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
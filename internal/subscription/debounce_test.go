@@ -0,0 +1,72 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/handlers"
+
+	"github.com/spf13/viper"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of debounce timers
+type syncBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.String()
+}
+
+func TestDispatchCoalescesUpdatesWithinDebounce(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("recordDebounce", 50*time.Millisecond)
+	viper.Set("manageContainers", false)
+
+	logs := &syncBuffer{}
+	log.SetOutput(logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s := New(nil)
+	for _, name := range []string{"Aurora", "Aurora Testnet", "Aurora Mainnet"} {
+		changes := change("silos", handlers.Record{ID: 1, ChainID: 1, Name: name})
+		changes.TableHandlers = []string{"name"}
+		s.dispatch(context.Background(), changes)
+	}
+
+	// Each handler pass logs the record once; wait for the debounced pass
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(logs.String(), "Record 1 (silos, chain 1)") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	// Let the pass finish before the test resets the config it reads
+	s.handleMux.Lock()
+	s.handleMux.Unlock()
+
+	if passes := strings.Count(logs.String(), "Record 1 (silos, chain 1)"); passes != 1 {
+		t.Fatalf("handlers ran %d times, want once:\n%s", passes, logs.String())
+	}
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Aurora Mainnet") || strings.Contains(string(content), "Aurora Testnet") {
+		t.Errorf("env file = %q, want only the final name", content)
+	}
+}
@@ -0,0 +1,56 @@
+package subscription
+
+import (
+	"blockscout-vc/internal/config"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/spf13/viper"
+)
+
+// createMissingTables creates the monitored tables that do not exist yet, so a developer can
+// run the sidecar against a fresh database (createMonitoredTableIfMissing). It is refused
+// outside environment: development, where a missing table means a misconfiguration
+func createMissingTables(ctx context.Context, tables []config.TableConfig) error {
+	if !config.IsDevelopment() {
+		return fmt.Errorf("createMonitoredTableIfMissing is only allowed with environment: %s, not %q",
+			config.EnvironmentDevelopment, viper.GetString("environment"))
+	}
+	for _, table := range tables {
+		if err := config.SafeIdentifier(table.Name); err != nil {
+			return fmt.Errorf("table validation failed: %w", err)
+		}
+	}
+
+	db, err := sql.Open("postgres", viper.GetString("supabaseUrl"))
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Warning: failed to close database connection: %v", closeErr)
+		}
+	}()
+
+	return createTablesIn(ctx, db, tables)
+}
+
+// createTablesIn creates the tables missing from db
+func createTablesIn(ctx context.Context, db *sql.DB, tables []config.TableConfig) error {
+	for _, table := range tables {
+		var exists bool
+		if err := db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, table.Name).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check whether table %s exists: %w", table.Name, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, createTableStatement(table.Name)); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table.Name, err)
+		}
+		log.Printf("Created missing monitored table %s (createMonitoredTableIfMissing)", table.Name)
+	}
+	return nil
+}
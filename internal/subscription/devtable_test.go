@@ -0,0 +1,92 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+
+	"blockscout-vc/internal/config"
+
+	"github.com/spf13/viper"
+)
+
+// schemaDB is a database holding the named tables, recording the statements it executes
+type schemaDB struct {
+	mu       sync.Mutex
+	tables   map[string]bool
+	executed []string
+}
+
+func (s *schemaDB) Connect(context.Context) (driver.Conn, error) { return schemaConn{s}, nil }
+func (s *schemaDB) Driver() driver.Driver                        { return nil }
+
+type schemaConn struct{ db *schemaDB }
+
+func (c schemaConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c schemaConn) Close() error                        { return nil }
+func (c schemaConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c schemaConn) QueryContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return &versionedRows{columns: []string{"exists"}, values: [][]driver.Value{{c.db.tables[args[0].Value.(string)]}}}, nil
+}
+
+func (c schemaConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.executed = append(c.db.executed, query)
+	return driver.RowsAffected(0), nil
+}
+
+func TestCreateTablesInCreatesOnlyMissingTables(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	schema := &schemaDB{tables: map[string]bool{"silos": true}}
+	db := sql.OpenDB(schema)
+	t.Cleanup(func() { db.Close() })
+
+	tables := []config.TableConfig{{Name: "silos"}, {Name: "branding"}}
+	if err := createTablesIn(context.Background(), db, tables); err != nil {
+		t.Fatalf("createTablesIn: %v", err)
+	}
+
+	if len(schema.executed) != 1 {
+		t.Fatalf("executed %q, want one CREATE TABLE", schema.executed)
+	}
+	statement := schema.executed[0]
+	if !strings.HasPrefix(statement, "CREATE TABLE IF NOT EXISTS branding (") {
+		t.Errorf("statement = %q, want the missing branding table created", statement)
+	}
+	for _, column := range []string{"id BIGSERIAL PRIMARY KEY", "chain_id BIGINT NOT NULL", "name TEXT", "updated_at TIMESTAMPTZ NOT NULL DEFAULT now()"} {
+		if !strings.Contains(statement, column) {
+			t.Errorf("statement %q is missing %q", statement, column)
+		}
+	}
+}
+
+func TestCreateMissingTablesRefusedOutsideDevelopment(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		table       string
+		wantErr     string
+	}{
+		{"production", "production", "silos", "only allowed with environment: development"},
+		{"unset environment", "", "silos", "only allowed with environment: development"},
+		{"unsafe table name", "development", "silos; DROP TABLE silos", "table validation failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("environment", tt.environment)
+
+			err := createMissingTables(context.Background(), []config.TableConfig{{Name: tt.table}})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("createMissingTables() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package subscription
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/handlers"
+)
+
+func TestDiffRecordsReportsChangedFieldsOnly(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	previous := handlers.Record{ID: 1, ChainID: 1, Name: "Aurora", Coin: "ETH", CreatedAt: createdAt, UpdatedAt: createdAt}
+	current := previous
+	current.Name = "Aurora Mainnet"
+	current.FaviconURL = "https://example.com/favicon.ico"
+	current.CreatedAt = createdAt.In(time.FixedZone("UTC+2", 2*60*60)) // same instant
+	current.UpdatedAt = createdAt.Add(time.Hour)
+
+	want := []string{
+		`name: "Aurora" -> "Aurora Mainnet"`,
+		`favicon: "" -> "https://example.com/favicon.ico"`,
+	}
+	if got := diffRecords(previous, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffRecords() = %v, want %v", got, want)
+	}
+	if got := diffRecords(previous, previous); len(got) != 0 {
+		t.Errorf("diffRecords() of identical records = %v, want none", got)
+	}
+}
+
+func TestLogRecordDiffComparesWithPreviousRecord(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s := New(nil)
+	s.logRecordDiff("silos", handlers.Record{ID: 1, ChainID: 1, Name: "Aurora", Coin: "ETH"})
+	if !strings.Contains(logs.String(), "first record seen") {
+		t.Errorf("first record logged %q, want no diff", logs.String())
+	}
+
+	// Another chain has its own previous record
+	s.logRecordDiff("silos", handlers.Record{ID: 2, ChainID: 2, Name: "Other"})
+
+	logs.Reset()
+	s.logRecordDiff("silos", handlers.Record{ID: 1, ChainID: 1, Name: "Aurora", Coin: "AURORA"})
+	line := logs.String()
+	if !strings.Contains(line, `base_token_symbol: "ETH" -> "AURORA"`) {
+		t.Errorf("diff %q is missing the coin change", line)
+	}
+	if strings.Contains(line, "name:") || strings.Contains(line, "Other") {
+		t.Errorf("diff %q contains unchanged fields", line)
+	}
+}
@@ -0,0 +1,51 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"blockscout-vc/internal/events"
+	"blockscout-vc/internal/handlers"
+	"blockscout-vc/internal/worker"
+)
+
+// memorySink keeps published events in memory
+type memorySink struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (s *memorySink) Publish(ctx context.Context, event events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestProcessPublishesHandledRecord(t *testing.T) {
+	useEnvFile(t, "")
+	sink := &memorySink{}
+	events.SetSink(sink)
+	t.Cleanup(func() { events.SetSink(events.NoopSink{}) })
+
+	p := change("silos", handlers.Record{ID: 1, ChainID: 1, Name: "Aurora"})
+	p.TableHandlers = []string{"name"}
+	p.Worker = worker.New()
+	if _, err := p.Process(context.Background()); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if len(sink.events) != 1 {
+		t.Fatalf("sink received %d events, want 1", len(sink.events))
+	}
+	event := sink.events[0]
+	if event.Table != "silos" || event.Record.ID != 1 || event.Record.Name != "Aurora" {
+		t.Errorf("event = %+v, want record 1 from silos", event)
+	}
+	if len(event.Changes) != 1 || event.Changes[0]["handler"] != "name" || event.Changes[0]["envUpdated"] != true {
+		t.Errorf("changes = %v, want the name handler's env update", event.Changes)
+	}
+}
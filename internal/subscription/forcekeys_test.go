@@ -0,0 +1,72 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/worker"
+
+	"github.com/spf13/viper"
+)
+
+func TestInitialCheckReassertsForcedKeys(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+	db := sql.OpenDB(versionedTable{rows: []map[string]driver.Value{
+		{"id": int64(1), "chain_id": int64(1), "name": "Aurora", "updated_at": time.Now()},
+	}})
+	t.Cleanup(func() { db.Close() })
+	table := config.TableConfig{Name: "silos", Handlers: []string{"name"}}
+	forceKeys := []string{"NEXT_PUBLIC_NETWORK_NAME"}
+
+	check := func(forceKeys []string) *HandleOutcome {
+		t.Helper()
+		outcome, err := New(nil).initialCheckTable(context.Background(), db, table, 1, worker.New(), forceKeys)
+		if err != nil {
+			t.Fatalf("initialCheckTable: %v", err)
+		}
+		return outcome
+	}
+
+	// The first startup writes the record's values
+	check(nil)
+
+	// Without forceAssertKeys an unchanged env file restarts nothing
+	if outcome := check(nil); len(outcome.Restarted) != 0 {
+		t.Fatalf("restarted = %v without changes, want nothing", outcome.Restarted)
+	}
+
+	// A forced key is written and restarts its container even when unchanged
+	outcome := check(forceKeys)
+	if !reflect.DeepEqual(outcome.Restarted, []string{"frontend-1"}) {
+		t.Errorf("restarted = %v, want frontend-1 for the forced key", outcome.Restarted)
+	}
+
+	// A tampered value is corrected
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(content), "NEXT_PUBLIC_NETWORK_NAME=Aurora", "NEXT_PUBLIC_NETWORK_NAME=Tampered", 1)
+	if tampered == string(content) {
+		t.Fatalf("env file = %q, want NEXT_PUBLIC_NETWORK_NAME written", content)
+	}
+	if err := os.WriteFile(envFile, []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outcome = check(forceKeys)
+	if !reflect.DeepEqual(outcome.Restarted, []string{"frontend-1"}) {
+		t.Errorf("restarted = %v, want frontend-1 for the corrected key", outcome.Restarted)
+	}
+	if content, _ := os.ReadFile(envFile); !strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_NAME=Aurora") {
+		t.Errorf("env file = %q, want the tampered key corrected", content)
+	}
+}
@@ -0,0 +1,146 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/worker"
+
+	"github.com/spf13/viper"
+)
+
+// versionedTable holds several rows for one chain and answers the record query in
+// the order its ORDER BY clause asks for, as Postgres would. A row's "active" value
+// is honoured when the query selects only active rows
+type versionedTable struct{ rows []map[string]driver.Value }
+
+func (v versionedTable) Connect(context.Context) (driver.Conn, error) { return versionedConn(v), nil }
+func (v versionedTable) Driver() driver.Driver                        { return nil }
+
+type versionedConn versionedTable
+
+func (c versionedConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c versionedConn) Close() error                        { return nil }
+func (c versionedConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c versionedConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "information_schema.columns") {
+		// Every record column exists
+		rows := &versionedRows{columns: []string{"column_name"}}
+		for _, column := range recordColumns {
+			rows.values = append(rows.values, []driver.Value{column.name})
+		}
+		return rows, nil
+	}
+
+	records := []map[string]driver.Value{}
+	for _, record := range c.rows {
+		if !strings.Contains(query, "active IS TRUE") || record["active"] == true {
+			records = append(records, record)
+		}
+	}
+	if strings.Contains(query, "ORDER BY updated_at DESC") {
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i]["updated_at"].(time.Time).After(records[j]["updated_at"].(time.Time))
+		})
+	}
+	rows := &versionedRows{}
+	for _, column := range recordColumns {
+		rows.columns = append(rows.columns, column.name)
+	}
+	for _, record := range records {
+		values := make([]driver.Value, len(recordColumns))
+		for i, column := range recordColumns {
+			value, ok := record[column.name]
+			if !ok && !strings.HasSuffix(column.name, "_at") {
+				// Text columns are selected with COALESCE, so they are never NULL
+				value = ""
+			}
+			values[i] = value
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+type versionedRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *versionedRows) Columns() []string { return r.columns }
+func (r *versionedRows) Close() error      { return nil }
+
+func (r *versionedRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestInitialCheckAppliesNewestRecord(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	now := time.Now()
+	db := sql.OpenDB(versionedTable{rows: []map[string]driver.Value{
+		{"id": int64(2), "chain_id": int64(1), "name": "Oldest", "updated_at": now.Add(-time.Hour)},
+		{"id": int64(1), "chain_id": int64(1), "name": "Newest", "updated_at": now},
+		{"id": int64(3), "chain_id": int64(1), "name": "Older", "updated_at": now.Add(-time.Minute)},
+	}})
+	t.Cleanup(func() { db.Close() })
+
+	s := New(nil)
+	table := config.TableConfig{Name: "silos", Handlers: []string{"name"}}
+	outcome, err := s.initialCheckTable(context.Background(), db, table, 1, worker.New(), nil)
+	if err != nil {
+		t.Fatalf("initialCheckTable: %v", err)
+	}
+	if outcome == nil || outcome.RecordID != 1 {
+		t.Fatalf("outcome = %+v, want record 1 applied", outcome)
+	}
+	if applied := s.lastRecords["silos:1"]; applied.Name != "Newest" {
+		t.Errorf("remembered record %q, want Newest", applied.Name)
+	}
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Newest") || strings.Contains(string(content), "Older") {
+		t.Errorf("env file = %q, want only the newest name", content)
+	}
+}
+
+func TestReconcileRecordQueuesRestart(t *testing.T) {
+	useEnvFile(t, "")
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+	db := sql.OpenDB(versionedTable{rows: []map[string]driver.Value{
+		{"id": int64(1), "chain_id": int64(1), "name": "Aurora", "updated_at": time.Now()},
+	}})
+	t.Cleanup(func() { db.Close() })
+
+	w := worker.New()
+	table := config.TableConfig{Name: "silos", Handlers: []string{"name"}}
+	outcome, err := New(nil).initialCheckTable(context.Background(), db, table, 1, w, nil)
+	if err != nil {
+		t.Fatalf("initialCheckTable: %v", err)
+	}
+	if !outcome.JobQueued || !reflect.DeepEqual(outcome.Restarted, []string{"frontend-1"}) {
+		t.Fatalf("outcome = %+v, want a queued restart of frontend-1", outcome)
+	}
+	frontend := []docker.Container{{Name: "frontend-1", ServiceName: "frontend", ChainID: 1}}
+	if w.AddJob(frontend) {
+		t.Error("the restart job was not in the queue")
+	}
+}
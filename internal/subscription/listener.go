@@ -0,0 +1,108 @@
+package subscription
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/worker"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/spf13/viper"
+)
+
+// DefaultNotifyChannel is the Postgres channel listened on when notifyChannel is not set
+const DefaultNotifyChannel = "blockscout_vc_changes"
+
+// Listen starts receiving changes through Postgres LISTEN/NOTIFY instead of Supabase realtime
+// Each notification payload must be a JSON object shaped like the realtime change data:
+// {"table": "...", "type": "UPDATE", "record": {...}}
+func (s *Subscription) Listen(ctx context.Context, worker *worker.Worker) error {
+	// Run initial check first to handle existing records, once the stack has started
+	if err := s.runInitialCheck(ctx, config.ChangeSourcePostgres, worker); err != nil {
+		return err
+	}
+
+	channel := viper.GetString("notifyChannel")
+	if channel == "" {
+		channel = DefaultNotifyChannel
+	}
+
+	s.listener = pq.NewListener(viper.GetString("supabaseUrl"), 10*time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("Postgres listener event %d: %v", event, err)
+			}
+		})
+	if err := s.listener.Listen(channel); err != nil {
+		setStatus(config.ChangeSourcePostgres, StateError, err)
+		return fmt.Errorf("failed to listen on channel %s: %w", channel, err)
+	}
+	setStatus(config.ChangeSourcePostgres, StateSubscribed, nil)
+
+	monitored := tablesByName(config.GetTables())
+	chainId := viper.GetInt("chainId")
+
+	go func() {
+		for {
+			select {
+			case notification, ok := <-s.listener.Notify:
+				if !ok {
+					return
+				}
+				// A nil notification means the connection was re-established;
+				// notifications sent while disconnected are lost
+				if notification == nil {
+					log.Printf("Postgres listener reconnected, changes made while disconnected were missed")
+					continue
+				}
+
+				changes, err := NewNotifyChanges([]byte(notification.Extra), worker)
+				if err != nil {
+					log.Printf("Failed to handle notification: %v", err)
+					continue
+				}
+				// Mirror the chain_id filter applied to realtime subscriptions
+				if changes.Payload.Data.Record.ChainID != chainId {
+					continue
+				}
+
+				fmt.Printf("Received notification: %s on %s\n", changes.Payload.Data.Type, changes.Payload.Data.Table)
+				s.route(ctx, changes, monitored)
+			case <-time.After(90 * time.Second):
+				// Check the connection so a dead one is noticed and reconnected
+				go func() {
+					if err := s.listener.Ping(); err != nil {
+						log.Printf("Postgres listener ping failed: %v", err)
+					}
+				}()
+			}
+		}
+	}()
+
+	fmt.Printf("Listening for changes on Postgres channel %s.\n", channel)
+	return nil
+}
+
+// NewNotifyChanges creates a PostgresChanges instance from a NOTIFY payload
+func NewNotifyChanges(payload []byte, worker *worker.Worker) (*PostgresChanges, error) {
+	changes := &PostgresChanges{
+		Event:  "postgres_changes",
+		Worker: worker,
+	}
+	if err := json.Unmarshal(payload, &changes.Payload.Data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+	var raw struct {
+		Record map[string]json.RawMessage `json:"record"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+	if err := markActive(changes, raw.Record); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,39 @@
+package subscription
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/config"
+)
+
+func TestNotifyPayloadIsDecodedAndHandled(t *testing.T) {
+	envFile := useTwoTables(t)
+	payload := `{"table":"branding","type":"UPDATE","record":{"id":7,"chain_id":1,"name":"Aurora","base_token_symbol":"ETH"}}`
+
+	changes, err := NewNotifyChanges([]byte(payload), nil)
+	if err != nil {
+		t.Fatalf("NewNotifyChanges: %v", err)
+	}
+	data := changes.Payload.Data
+	if data.Table != "branding" || data.Type != "UPDATE" || data.Record.ID != 7 || data.Record.ChainID != 1 || data.Record.Name != "Aurora" {
+		t.Fatalf("decoded %+v, want the notified change", data)
+	}
+
+	New(nil).route(context.Background(), changes, tablesByName(config.GetTables()))
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_NAME=Aurora") {
+		t.Errorf("notification was not applied: %q", content)
+	}
+}
+
+func TestNotifyPayloadRejectsInvalidJSON(t *testing.T) {
+	if _, err := NewNotifyChanges([]byte(`{"table":`), nil); err == nil {
+		t.Error("NewNotifyChanges accepted a truncated payload")
+	}
+}
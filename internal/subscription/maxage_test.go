@@ -0,0 +1,59 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/worker"
+
+	"github.com/spf13/viper"
+)
+
+func TestInitialCheckSkipsRecordsOlderThanMaxRecordAge(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		age         time.Duration
+		wantApplied bool
+	}{
+		{"old record skipped", time.Hour, 24 * time.Hour, false},
+		{"recent record applied", time.Hour, time.Minute, true},
+		{"unset applies regardless", 0, 24 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envFile := useEnvFile(t, "")
+			viper.Set("maxRecordAge", tt.maxAge)
+			db := sql.OpenDB(versionedTable{rows: []map[string]driver.Value{
+				{"id": int64(1), "chain_id": int64(1), "name": "Aurora", "updated_at": time.Now().Add(-tt.age)},
+			}})
+			t.Cleanup(func() { db.Close() })
+
+			s := New(nil)
+			table := config.TableConfig{Name: "silos", Handlers: []string{"name"}}
+			outcome, err := s.initialCheckTable(context.Background(), db, table, 1, worker.New(), nil)
+			if err != nil {
+				t.Fatalf("initialCheckTable: %v", err)
+			}
+			if outcome == nil || outcome.RecordID != 1 {
+				t.Fatalf("outcome = %+v, want record 1 checked", outcome)
+			}
+			if skipped := outcome.Skipped != ""; skipped == tt.wantApplied {
+				t.Errorf("outcome.Skipped = %q, want applied: %t", outcome.Skipped, tt.wantApplied)
+			}
+			content, err := os.ReadFile(envFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if applied := strings.Contains(string(content), "Aurora"); applied != tt.wantApplied {
+				t.Errorf("env file = %q, want applied: %t", content, tt.wantApplied)
+			}
+		})
+	}
+}
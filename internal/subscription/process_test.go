@@ -0,0 +1,143 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/handlers"
+	"blockscout-vc/internal/worker"
+
+	"github.com/spf13/viper"
+)
+
+// useEnvFile points pathToEnvFile at a temporary file holding content
+func useEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), "sidecar-injected.env")
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("pathToEnvFile", envFile)
+	t.Cleanup(viper.Reset)
+	return envFile
+}
+
+// change returns a change event for record from the given table
+func change(table string, record handlers.Record) *PostgresChanges {
+	p := &PostgresChanges{}
+	p.Payload.Data.Table = table
+	p.Payload.Data.Type = "UPDATE"
+	p.Payload.Data.Record = record
+	return p
+}
+
+func TestProcessStrictValidationSkipsEnvWrites(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("strictRecordValidation", true)
+
+	// Valid except for the explorer URL: the coin and name handlers must not run either
+	p := change("silos", handlers.Record{ID: 1, ChainID: 1, Name: "Aurora", Coin: "ETH", ExplorerURL: "https://"})
+	outcome, err := p.Process(context.Background())
+	if err == nil {
+		t.Fatal("Process accepted an invalid record")
+	}
+	if outcome.Skipped == "" || len(outcome.Handlers) != 0 {
+		t.Errorf("outcome = %+v, want the handlers skipped", outcome)
+	}
+	if content, _ := os.ReadFile(envFile); len(content) != 0 {
+		t.Errorf("env file written: %q", content)
+	}
+}
+
+func TestProcessSkipsDisallowedChain(t *testing.T) {
+	envFile := useEnvFile(t, "A=1\n")
+	viper.Set("allowedChainIds", "1313161554, 1313161555")
+	viper.Set("frontendContainerName", "frontend-1")
+
+	p := change("silos", handlers.Record{ID: 1, ChainID: 1, Name: "Aurora", Coin: "ETH"})
+	p.Worker = worker.New()
+	outcome, err := p.Process(context.Background())
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if outcome.Skipped == "" || len(outcome.Handlers) != 0 || len(outcome.Restarted) != 0 || outcome.JobQueued {
+		t.Errorf("outcome = %+v, want the record skipped without handlers or restarts", outcome)
+	}
+	if content, _ := os.ReadFile(envFile); string(content) != "A=1\n" {
+		t.Errorf("env file changed to %q", content)
+	}
+
+	// The same record is applied once its chain is allowed
+	viper.Set("allowedChainIds", "1")
+	if outcome, _ := p.Process(context.Background()); outcome.Skipped != "" || len(outcome.Handlers) == 0 {
+		t.Errorf("outcome for an allowed chain = %+v, want the handlers run", outcome)
+	}
+}
+
+func TestProcessClearedNameFallsBackToDefault(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("strictRecordValidation", true)
+	viper.Set("defaults.name", "Aurora Explorer")
+
+	// Without the default the empty name would fail strict validation
+	p := change("silos", handlers.Record{ID: 1, ChainID: 1, Name: ""})
+	p.TableHandlers = []string{"name"}
+	p.Worker = worker.New()
+	outcome, err := p.Process(context.Background())
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if outcome.Skipped != "" {
+		t.Fatalf("outcome = %+v, want the defaulted record applied", outcome)
+	}
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `NEXT_PUBLIC_NETWORK_NAME="Aurora Explorer"`) {
+		t.Errorf("env file = %q, want the default name", content)
+	}
+	if p.Payload.Data.Record.Name != "" {
+		t.Errorf("record name changed to %q, defaults must not modify the received record", p.Payload.Data.Record.Name)
+	}
+}
+
+func TestProcessEmptyExplorerURLSkipsOnlyExplorer(t *testing.T) {
+	tests := []struct {
+		requireURL        bool
+		wantExplorerError bool
+	}{
+		{false, false},
+		{true, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("requireURL %t", tt.requireURL), func(t *testing.T) {
+			envFile := useEnvFile(t, "")
+			viper.Set("explorer.requireURL", tt.requireURL)
+
+			p := change("silos", handlers.Record{ID: 1, ChainID: 1, Name: "Aurora", ExplorerURL: ""})
+			p.TableHandlers = []string{"explorer", "name"}
+			p.Worker = worker.New()
+			outcome, err := p.Process(context.Background())
+			if (err != nil) != tt.wantExplorerError {
+				t.Fatalf("Process() error = %v, want error: %t", err, tt.wantExplorerError)
+			}
+
+			for _, handler := range outcome.Handlers {
+				if handler.Name == "explorer" && (handler.Error != "") != tt.wantExplorerError {
+					t.Errorf("explorer outcome = %+v, want error: %t", handler, tt.wantExplorerError)
+				}
+				if handler.Name == "name" && (handler.Error != "" || !handler.EnvUpdated) {
+					t.Errorf("name outcome = %+v, want it applied", handler)
+				}
+			}
+			if content, _ := os.ReadFile(envFile); !strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_NAME=Aurora") {
+				t.Errorf("env file = %q, want the name handler to proceed", content)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/worker"
+
+	"github.com/spf13/viper"
+)
+
+func TestHandlerTablesKeepsTablesRunningTheHandler(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("tables", []map[string]any{
+		{"name": "silos"},
+		{"name": "branding", "handlers": []string{"image", "name"}},
+		{"name": "currencies", "handlers": []string{"coin"}},
+	})
+
+	want := []config.TableConfig{
+		{Name: "silos", Handlers: []string{"name"}},
+		{Name: "branding", Handlers: []string{"name"}},
+	}
+	if got := handlerTables("name"); !reflect.DeepEqual(got, want) {
+		t.Errorf("handlerTables(name) = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunHandlerRestartsOnlyItsServices(t *testing.T) {
+	useEnvFile(t, "")
+	viper.Set("table", "silos")
+	for _, service := range []string{"frontend", "backend", "stats"} {
+		viper.Set(service+"ServiceName", service)
+		viper.Set(service+"ContainerName", service+"-1")
+	}
+	// The coin changed too, but only the name handler may run
+	db := sql.OpenDB(versionedTable{rows: []map[string]driver.Value{
+		{"id": int64(1), "chain_id": int64(1), "name": "Aurora", "coin": "ETH", "updated_at": time.Now()},
+	}})
+	t.Cleanup(func() { db.Close() })
+
+	outcomes, err := New(nil).applyTables(context.Background(), db, worker.New(), handlerTables("name"), []int{1}, nil)
+	if err != nil {
+		t.Fatalf("applyTables: %v", err)
+	}
+	if len(outcomes) != 1 {
+		t.Fatalf("outcomes = %+v, want one", outcomes)
+	}
+	outcome := outcomes[0]
+	if len(outcome.Handlers) != 1 || outcome.Handlers[0].Name != "name" {
+		t.Errorf("handlers = %+v, want only the name handler", outcome.Handlers)
+	}
+	if !reflect.DeepEqual(outcome.Restarted, []string{"frontend-1"}) {
+		t.Errorf("restarted = %v, want only frontend-1", outcome.Restarted)
+	}
+}
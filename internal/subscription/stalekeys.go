@@ -0,0 +1,61 @@
+package subscription
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/env"
+	"blockscout-vc/internal/handlers"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ranAllHandlers reports whether names covers every available handler, the only case in
+// which the keys written during a pass are the complete current set
+func ranAllHandlers(names []string) bool {
+	for _, name := range handlers.HandlerNames {
+		if !slices.Contains(names, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// clearStaleKeys removes env file keys matching env.clearPrefixes that none of the handlers
+// claimed, so values left behind by earlier records or renamed settings do not linger.
+// Keys outside the prefixes and keys in env.clearPrefixExclude are never touched, which
+// keeps keys owned by other processes intact. Only env outputMode is supported
+func clearStaleKeys(chainID int, hs []handlers.Handler) ([]string, error) {
+	if !config.GetEnvClearPrefixOnUpdate() || config.GetOutputMode() == config.OutputModeComposeOverride {
+		return nil, nil
+	}
+	prefixes := config.GetEnvClearPrefixes()
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+
+	keep := config.GetEnvClearPrefixExclude()
+	for _, handler := range hs {
+		claimer, ok := handler.(interface{ ClaimedKeys() []string })
+		if !ok {
+			// Without knowing what this handler owns nothing can safely be cleared
+			return nil, nil
+		}
+		keep = append(keep, claimer.ClaimedKeys()...)
+	}
+
+	removed, err := env.NewEnvForChain(chainID).RemoveKeys(func(key string) bool {
+		if slices.Contains(keep, key) {
+			return false
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear stale env keys: %w", err)
+	}
+	return removed, nil
+}
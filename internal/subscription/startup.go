@@ -0,0 +1,72 @@
+package subscription
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/worker"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// runInitialCheck waits for the stack to start and runs the initial check for the change source
+// A check that exceeds initialCheck.timeout is logged and does not prevent subscribing
+func (s *Subscription) runInitialCheck(ctx context.Context, source string, worker *worker.Worker) error {
+	waitForStartup()
+	err := s.InitialCheck(ctx, worker)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("Warning: %v after %s, continuing; use reconcile to re-apply existing records", err, config.GetInitialCheckTimeout())
+		return nil
+	}
+	if err != nil {
+		setStatus(source, StateError, err)
+		return fmt.Errorf("failed initial check: %w", err)
+	}
+	return nil
+}
+
+// startupPollInterval is how often container health is checked while waiting for startup
+const startupPollInterval = 2 * time.Second
+
+// waitForStartup delays the initial check so services still booting with the stack are not
+// recreated: first for startupWait.delay, then, with startupWait.waitForHealthy, until the
+// configured containers are running and healthy or startupWait.timeout expires
+func waitForStartup() {
+	if delay := config.GetStartupWaitDelay(); delay > 0 {
+		log.Printf("Waiting %s before the initial check...", delay)
+		time.Sleep(delay)
+	}
+	if !config.GetStartupWaitForHealthy() {
+		return
+	}
+
+	names := []string{}
+	for _, container := range docker.ConfiguredContainers() {
+		names = append(names, container.Name)
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	timeout := config.GetStartupWaitTimeout()
+	deadline := time.Now().Add(timeout)
+	log.Printf("Waiting up to %s for containers %v to be healthy before the initial check...", timeout, names)
+	for {
+		ready, err := docker.NewDocker().ContainersReady(names)
+		if err != nil {
+			log.Printf("Warning: failed to check container health, running the initial check: %v", err)
+			return
+		}
+		if ready {
+			log.Printf("Containers %v are healthy", names)
+			return
+		}
+		if time.Now().After(deadline) {
+			log.Printf("Warning: containers %v not healthy after %s, running the initial check anyway", names, timeout)
+			return
+		}
+		time.Sleep(startupPollInterval)
+	}
+}
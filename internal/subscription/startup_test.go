@@ -0,0 +1,91 @@
+package subscription
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// useInspectStub puts a docker binary on PATH whose inspect reports the frontend container
+// as starting for the first unhealthy calls and healthy afterwards, and returns the file
+// recording one line per call
+func useInspectStub(t *testing.T, unhealthy int) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the docker stub is a shell script")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" >> " + calls + "\n" +
+		"if [ \"$(wc -l < " + calls + ")\" -le " + strconv.Itoa(unhealthy) + " ]; then\n" +
+		"\techo '/frontend-1 running starting'\n" +
+		"else\n" +
+		"\techo '/frontend-1 running healthy'\n" +
+		"fi\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	viper.Set("frontendContainerName", "frontend-1")
+	viper.Set("frontendServiceName", "frontend")
+	return calls
+}
+
+// inspectCalls returns the number of docker calls recorded in calls
+func inspectCalls(t *testing.T, calls string) int {
+	t.Helper()
+	data, err := os.ReadFile(calls)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func TestWaitForStartupDelay(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	calls := useInspectStub(t, 0)
+	viper.Set("startupWait.delay", 100*time.Millisecond)
+
+	start := time.Now()
+	waitForStartup()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("returned after %s, want at least the configured delay", elapsed)
+	}
+	if n := inspectCalls(t, calls); n != 0 {
+		t.Errorf("docker called %d times without startupWait.waitForHealthy, want 0", n)
+	}
+}
+
+func TestWaitForStartupUntilHealthy(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	calls := useInspectStub(t, 1)
+	viper.Set("startupWait.waitForHealthy", true)
+	viper.Set("startupWait.timeout", time.Minute)
+
+	waitForStartup()
+	if n := inspectCalls(t, calls); n != 2 {
+		t.Errorf("docker inspect called %d times, want the wait to last until the second, healthy check", n)
+	}
+}
+
+func TestWaitForStartupTimeout(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	calls := useInspectStub(t, 100)
+	viper.Set("startupWait.waitForHealthy", true)
+	viper.Set("startupWait.timeout", time.Millisecond)
+
+	waitForStartup()
+	if n := inspectCalls(t, calls); n != 1 {
+		t.Errorf("docker inspect called %d times, want the expired timeout to end the wait after the first check", n)
+	}
+}
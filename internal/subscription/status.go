@@ -0,0 +1,45 @@
+package subscription
+
+import (
+	"sync"
+	"time"
+)
+
+// Subscription states reported by CurrentStatus
+const (
+	StateDisabled   = "disabled"   // No change source was started
+	StatePending    = "pending"    // Join sent, waiting for the server to confirm it
+	StateSubscribed = "subscribed" // The server accepted the subscription
+	StateError      = "error"      // The subscription was rejected or failed
+)
+
+// Status describes the state of the change subscription, exposed by the status endpoint
+type Status struct {
+	State  string    `json:"state"`
+	Source string    `json:"source,omitempty"` // "supabase" or "postgres"
+	Error  string    `json:"error,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+var (
+	statusMux sync.Mutex
+	status    = Status{State: StateDisabled, Since: time.Now()}
+)
+
+// setStatus records a state change; err is only kept for StateError
+func setStatus(source, state string, err error) {
+	statusMux.Lock()
+	defer statusMux.Unlock()
+
+	status = Status{State: state, Source: source, Since: time.Now()}
+	if err != nil {
+		status.Error = err.Error()
+	}
+}
+
+// CurrentStatus returns the state of the change subscription
+func CurrentStatus() Status {
+	statusMux.Lock()
+	defer statusMux.Unlock()
+	return status
+}
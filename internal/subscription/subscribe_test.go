@@ -0,0 +1,126 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/client"
+	"blockscout-vc/internal/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+)
+
+// realtimeServer starts a fake Realtime server answering the n-th join (from 1) with
+// the phx_reply payload returned by reply, and a subscription connected to it whose
+// read loop is running. It returns the subscription and the number of joins received
+func realtimeServer(t *testing.T, reply func(n int) string) (*Subscription, *atomic.Int32, chan joinReply) {
+	t.Helper()
+	t.Cleanup(viper.Reset)
+	viper.Set("realtimeSubscribe.retryBackoff", time.Millisecond)
+	viper.Set("realtimeSubscribe.joinTimeout", time.Second)
+
+	var joins atomic.Int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var join struct {
+				Ref string `json:"ref"`
+			}
+			if err := conn.ReadJSON(&join); err != nil {
+				return
+			}
+			n := int(joins.Add(1))
+			message := `{"event":"phx_reply","ref":"` + join.Ref + `","payload":` + reply(n) + `}`
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c := client.New("ws"+strings.TrimPrefix(server.URL, "http"), "key")
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s := New(c)
+	replies := make(chan joinReply, 1)
+	go s.readLoop(ctx, c.CurrentConn(), nil, tablesByName(config.GetTables()), replies)
+	return s, &joins, replies
+}
+
+func TestSubscribeRetriesFailedJoin(t *testing.T) {
+	s, joins, replies := realtimeServer(t, func(n int) string {
+		if n == 1 {
+			return `{"status":"error","response":{"reason":"temporarily unavailable"}}`
+		}
+		return `{"status":"ok","response":{}}`
+	})
+	viper.Set("realtimeSubscribe.retryAttempts", 3)
+
+	if err := s.subscribeWithRetry([]string{"silos"}, replies); err != nil {
+		t.Fatalf("subscribeWithRetry: %v", err)
+	}
+	if got := joins.Load(); got != 2 {
+		t.Errorf("joins = %d, want a failed join and a successful retry", got)
+	}
+}
+
+func TestSubscribeGivesUpAfterRetryAttempts(t *testing.T) {
+	s, joins, replies := realtimeServer(t, func(int) string {
+		return `{"status":"error","response":{"reason":"unauthorized"}}`
+	})
+	viper.Set("realtimeSubscribe.retryAttempts", 2)
+
+	err := s.subscribeWithRetry([]string{"silos"}, replies)
+	if err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("subscribeWithRetry() = %v, want the rejection returned", err)
+	}
+	if got := joins.Load(); got != 2 {
+		t.Errorf("joins = %d, want 2", got)
+	}
+}
+
+func TestSubscribeSurfacesRejectedSubscription(t *testing.T) {
+	// The join is accepted, but without postgres_changes for one of the tables
+	s, _, replies := realtimeServer(t, func(int) string {
+		return `{"status":"ok","response":{"postgres_changes":[{"event":"*","schema":"public","table":"silos"}]}}`
+	})
+	viper.Set("realtimeSubscribe.retryAttempts", 1)
+
+	err := s.subscribeWithRetry([]string{"silos", "branding"}, replies)
+	if err == nil || !strings.Contains(err.Error(), "branding") {
+		t.Fatalf("subscribeWithRetry() = %v, want the missing table reported", err)
+	}
+}
+
+func TestSystemErrorEventSetsErrorStatus(t *testing.T) {
+	t.Cleanup(func() { setStatus("", StateDisabled, nil) })
+	setStatus(config.ChangeSourceSupabase, StateSubscribed, nil)
+
+	message := []byte(`{"event":"system","topic":"realtime:public:silos","payload":{"status":"error","extension":"postgres_changes","message":"permission denied for table silos"}}`)
+	event, ok := parseSystemEvent(message)
+	if !ok {
+		t.Fatal("system event was not recognised")
+	}
+	handleSystemEvent(event)
+
+	status := CurrentStatus()
+	if status.State != StateError || !strings.Contains(status.Error, "permission denied for table silos") {
+		t.Errorf("status = %+v, want the realtime error surfaced", status)
+	}
+}
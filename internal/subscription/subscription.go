@@ -1,30 +1,54 @@
 package subscription
 
 import (
+	"blockscout-vc/internal/alerts"
 	"blockscout-vc/internal/client"
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/docker"
+	"blockscout-vc/internal/env"
+	"blockscout-vc/internal/events"
 	"blockscout-vc/internal/handlers"
+	"blockscout-vc/internal/metrics"
 	"blockscout-vc/internal/worker"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"regexp"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/gorilla/websocket"
+	"github.com/lib/pq"
 	"github.com/spf13/viper"
 )
 
 // Package subscription handles real-time database changes and container updates
 type Subscription struct {
-	client *client.Client
+	client      *client.Client              // Supabase realtime connection; nil with changeSource postgres
+	listener    *pq.Listener                // LISTEN/NOTIFY connection; nil with changeSource supabase
+	handleMux   sync.Mutex                  // Serializes handler passes so env writes never interleave
+	debounceMux sync.Mutex                  // Protects pending and timers
+	pending     map[string]*PostgresChanges // Latest change per table and chain waiting for its debounce window
+	timers      map[string]*time.Timer      // Debounce timer per table and chain
+	unhandled   map[string]int              // Unhandled events seen per table, only touched by the read loop
+	recordsMux  sync.Mutex                  // Protects lastRecords
+	lastRecords map[string]handlers.Record  // Last record seen per table and chain, for change diffs
+	reconnect   chan struct{}               // Signals that the Realtime connection is dead, see RequestReconnect
 }
 
+var unhandledTableEvents = metrics.NewCounter(
+	"blockscout_vc_unhandled_table_events_total",
+	"Total number of realtime changes received for tables that are not monitored",
+)
+
 // PostgresChange represents a single database change subscription configuration
 type PostgresChange struct {
 	Event  string `json:"event"`
@@ -58,84 +82,470 @@ type PostgresChanges struct {
 			Record handlers.Record `json:"record"`
 		} `json:"data"`
 	} `json:"payload"`
-	Worker *worker.Worker
+	Worker        *worker.Worker
+	TableHandlers []string `json:"-"` // Handlers configured for the source table; all handlers when empty
+	ForceKeys     []string `json:"-"` // Env keys written and restarted for even when unchanged (forceAssertKeys)
+	Inactive      bool     `json:"-"` // Not the active row per monitoredActiveColumn, so it is not applied
 }
 
 // New creates a new Subscription instance
 func New(client *client.Client) *Subscription {
 	return &Subscription{
-		client: client,
+		client:      client,
+		pending:     make(map[string]*PostgresChanges),
+		timers:      make(map[string]*time.Timer),
+		unhandled:   make(map[string]int),
+		lastRecords: make(map[string]handlers.Record),
+		reconnect:   make(chan struct{}, 1),
 	}
 }
 
 // Subscribe starts listening for database changes and handles container updates
-func (s *Subscription) Subscribe(worker *worker.Worker) error {
-	// Run initial check first to handle existing records
-	if err := s.InitialCheck(worker); err != nil {
-		return fmt.Errorf("failed initial check: %w", err)
+func (s *Subscription) Subscribe(ctx context.Context, worker *worker.Worker) error {
+	// Run initial check first to handle existing records, once the stack has started
+	if err := s.runInitialCheck(ctx, config.ChangeSourceSupabase, worker); err != nil {
+		return err
 	}
 
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
-	// Start listening for WebSocket messages
-	go func() {
+	tables := config.GetTables()
+	monitored := tablesByName(tables)
+	tableNames := make([]string, 0, len(tables))
+	for _, table := range tables {
+		tableNames = append(tableNames, table.Name)
+	}
+
+	// Join replies are handed from the read loop to subscribeWithRetry
+	replies := make(chan joinReply, 1)
+
+	// Start listening for WebSocket messages, and reconnect when the connection dies
+	go s.readLoop(ctx, s.client.CurrentConn(), worker, monitored, replies)
+	go s.superviseConnection(ctx, worker, tableNames, monitored, replies)
+
+	setStatus(config.ChangeSourceSupabase, StatePending, nil)
+	if err := s.subscribeWithRetry(tableNames, replies); err != nil {
+		log.Printf("ERROR: Realtime subscription failed: %v", err)
+		setStatus(config.ChangeSourceSupabase, StateError, err)
+		return err
+	}
+	setStatus(config.ChangeSourceSupabase, StateSubscribed, nil)
+	fmt.Println("Subscribed to table changes.")
+	return nil
+}
+
+// readLoop handles the messages of one Realtime connection until it fails, then requests
+// a reconnect. A loop whose connection was already replaced by a reconnect just exits
+func (s *Subscription) readLoop(ctx context.Context, conn *websocket.Conn, worker *worker.Worker, monitored map[string]config.TableConfig, replies chan<- joinReply) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if s.client.CurrentConn() != conn {
+				return
+			}
+			log.Printf("Read error: %v", err)
+			s.RequestReconnect()
+			return
+		}
+		if reply, ok := parseJoinReply(message); ok {
+			select {
+			case replies <- reply:
+			default:
+				// Nobody is waiting for this reply, e.g. it arrived after its join timed out
+			}
+			continue
+		}
+		if event, ok := parseSystemEvent(message); ok {
+			handleSystemEvent(event)
+			continue
+		}
+		record, err := NewPostgresChanges(message, worker)
+		if err != nil {
+			log.Printf("Failed to handle payload: %v", err)
+			continue
+		}
+
+		fmt.Printf("Received event: %s\n", record.Event)
+		if record.Event == "postgres_changes" {
+			s.route(ctx, record, monitored)
+		}
+	}
+}
+
+// RequestReconnect signals that the Realtime connection is dead, e.g. after failed heartbeats
+// or a read error. Requests made while a reconnect is already pending are merged
+func (s *Subscription) RequestReconnect() {
+	select {
+	case s.reconnect <- struct{}{}:
+	default:
+	}
+}
+
+// maxReconnectBackoff caps the delay between reconnect attempts
+const maxReconnectBackoff = time.Minute
+
+// superviseConnection re-establishes the Realtime connection whenever a reconnect is
+// requested: it redials until it succeeds, joins the channel again and re-applies the
+// newest records, since changes made while disconnected were never received
+func (s *Subscription) superviseConnection(ctx context.Context, worker *worker.Worker, tableNames []string, monitored map[string]config.TableConfig, replies chan joinReply) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.reconnect:
+		}
+
+		setStatus(config.ChangeSourceSupabase, StateError, fmt.Errorf("connection lost, reconnecting"))
+		backoff := config.GetSubscribeRetryBackoff()
 		for {
-			_, message, err := s.client.Conn.ReadMessage()
-			if err != nil {
-				log.Printf("Read error: %v", err)
-				os.Exit(1)
+			err := s.client.Reconnect()
+			if err == nil {
+				break
 			}
-			record, err := NewPostgresChanges(message, worker)
-			if err != nil {
-				log.Printf("Failed to handle payload: %v", err)
-				continue
+			log.Printf("Reconnect failed, retrying in %s: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
 			}
+			backoff = min(backoff*2, maxReconnectBackoff)
+		}
+
+		// Requests about the old connection are stale now
+		select {
+		case <-s.reconnect:
+		default:
+		}
+		go s.readLoop(ctx, s.client.CurrentConn(), worker, monitored, replies)
+
+		setStatus(config.ChangeSourceSupabase, StatePending, nil)
+		if err := s.subscribeWithRetry(tableNames, replies); err != nil {
+			log.Printf("ERROR: Realtime subscription failed after reconnect: %v", err)
+			setStatus(config.ChangeSourceSupabase, StateError, err)
+			s.RequestReconnect()
+			continue
+		}
+		setStatus(config.ChangeSourceSupabase, StateSubscribed, nil)
+		log.Printf("Resubscribed to table changes, re-applying records that may have changed while disconnected")
+		if _, err := s.Reconcile(ctx, worker, nil); err != nil {
+			log.Printf("Warning: failed to re-apply records after reconnect: %v", err)
+		}
+	}
+}
+
+// joinReply is the phx_reply Realtime sends in response to a phx_join
+type joinReply struct {
+	Ref     string `json:"ref"`
+	Payload struct {
+		Status   string          `json:"status"`
+		Response json.RawMessage `json:"response"`
+	} `json:"payload"`
+}
+
+// joinResponse is the response of an accepted join, listing the postgres_changes
+// subscriptions the server registered
+type joinResponse struct {
+	PostgresChanges []PostgresChange `json:"postgres_changes"`
+}
+
+// checkJoinResponse verifies that the server registered postgres_changes for every table
+func checkJoinResponse(response json.RawMessage, tableNames []string) error {
+	var accepted joinResponse
+	if len(response) > 0 {
+		if err := json.Unmarshal(response, &accepted); err != nil {
+			return fmt.Errorf("failed to decode join response: %w", err)
+		}
+	}
+
+	// Older Realtime versions do not echo the subscriptions; errors then arrive as system events
+	if accepted.PostgresChanges == nil {
+		return nil
+	}
+
+	registered := make(map[string]bool, len(accepted.PostgresChanges))
+	for _, change := range accepted.PostgresChanges {
+		registered[change.Table] = true
+	}
+	var missing []string
+	for _, table := range tableNames {
+		if !registered[table] {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("join accepted without postgres_changes for tables %v", missing)
+	}
+	return nil
+}
+
+// systemEvent is a "system" message Realtime sends once it has set up (or failed to set up)
+// the postgres_changes subscription of a channel
+type systemEvent struct {
+	Payload struct {
+		Status    string `json:"status"`
+		Message   string `json:"message"`
+		Extension string `json:"extension"`
+	} `json:"payload"`
+}
+
+// parseSystemEvent decodes message if it is a system event
+func parseSystemEvent(message []byte) (systemEvent, bool) {
+	var envelope struct {
+		Event string `json:"event"`
+		systemEvent
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil || envelope.Event != "system" {
+		return systemEvent{}, false
+	}
+	return envelope.systemEvent, true
+}
+
+// handleSystemEvent surfaces errors Realtime reports after the join was acknowledged,
+// e.g. when it cannot subscribe to the table in Postgres
+func handleSystemEvent(event systemEvent) {
+	if event.Payload.Status != "error" {
+		log.Printf("Realtime %s: %s", event.Payload.Extension, event.Payload.Message)
+		return
+	}
+	err := fmt.Errorf("realtime %s error: %s", event.Payload.Extension, event.Payload.Message)
+	log.Printf("ERROR: %v", err)
+	setStatus(config.ChangeSourceSupabase, StateError, err)
+}
+
+// parseJoinReply decodes message if it is a phx_reply
+func parseJoinReply(message []byte) (joinReply, bool) {
+	var envelope struct {
+		Event string `json:"event"`
+		joinReply
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil || envelope.Event != "phx_reply" {
+		return joinReply{}, false
+	}
+	return envelope.joinReply, true
+}
+
+// subscribeWithRetry sends the join request and waits for Realtime to accept it, retrying
+// failed joins up to realtimeSubscribe.retryAttempts times with doubling backoff
+func (s *Subscription) subscribeWithRetry(tableNames []string, replies <-chan joinReply) error {
+	attempts := config.GetSubscribeRetryAttempts()
+	backoff := config.GetSubscribeRetryBackoff()
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = s.join(tableNames, replies); err == nil {
+			return nil
+		}
+		if attempt < attempts {
+			log.Printf("Subscribe failed (attempt %d/%d), retrying in %s: %v", attempt, attempts, backoff, err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return fmt.Errorf("failed to subscribe after %d attempts: %w", attempts, err)
+}
+
+// join sends a single join request and waits up to realtimeSubscribe.joinTimeout
+// for a phx_reply with status ok
+func (s *Subscription) join(tableNames []string, replies <-chan joinReply) error {
+	payload := NewJoinPayload(tableNames)
+	if err := s.client.WriteJSON(payload); err != nil {
+		return fmt.Errorf("failed to send join: %w", err)
+	}
 
-			fmt.Printf("Received event: %s\n", record.Event)
-			if record.Event == "postgres_changes" {
-				table := viper.GetString("table")
-				if record.Payload.Data.Table == table {
-					if err := record.HandleMessage(); err != nil {
-						log.Printf("Failed to handle message: %v", err)
-					}
-				} else {
-					log.Printf("Unhandled table: %s", record.Payload.Data.Table)
-				}
+	timeout := time.After(config.GetSubscribeJoinTimeout())
+	for {
+		select {
+		case reply := <-replies:
+			if reply.Ref != payload.Ref {
+				// Reply to an earlier join or another message
+				continue
+			}
+			if reply.Payload.Status != "ok" {
+				return fmt.Errorf("join rejected with status %q: %s", reply.Payload.Status, reply.Payload.Response)
 			}
+			return checkJoinResponse(reply.Payload.Response, tableNames)
+		case <-timeout:
+			return fmt.Errorf("no join reply within %s", config.GetSubscribeJoinTimeout())
 		}
-	}()
+	}
+}
+
+// route dispatches a change to the handler set of its source table
+func (s *Subscription) route(ctx context.Context, changes *PostgresChanges, tablesByName map[string]config.TableConfig) {
+	tableConfig, ok := tablesByName[changes.Payload.Data.Table]
+	if !ok {
+		s.logUnhandledTable(changes.Payload.Data.Table)
+		return
+	}
+	if changes.Inactive {
+		log.Printf("Skipping record %d of %s: not the active row", changes.Payload.Data.Record.ID, changes.Payload.Data.Table)
+		return
+	}
+	changes.TableHandlers = tableConfig.Handlers
+	s.dispatch(ctx, changes)
+}
 
-	table := viper.GetString("table")
-	// Create subscription payload
+// tablesByName indexes the monitored tables by name
+func tablesByName(tables []config.TableConfig) map[string]config.TableConfig {
+	byName := make(map[string]config.TableConfig, len(tables))
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+	return byName
+}
+
+// logUnhandledTable counts a change from a table that is not monitored and logs it
+// until unhandledTableLogLimit events have been seen for that table (0 logs every event)
+func (s *Subscription) logUnhandledTable(table string) {
+	unhandledTableEvents.Inc()
+	s.unhandled[table]++
+
+	limit := viper.GetInt("unhandledTableLogLimit")
+	count := s.unhandled[table]
+	switch {
+	case limit <= 0 || count < limit:
+		log.Printf("Unhandled table: %s", table)
+	case count == limit:
+		log.Printf("Unhandled table: %s (suppressing further messages for this table)", table)
+	}
+}
+
+// NewJoinPayload builds the phx_join message subscribing to all changes of the given
+// tables for the configured chain
+func NewJoinPayload(tableNames []string) SubscriptionPayload {
 	payload := SubscriptionPayload{
 		Event: "phx_join",
-		Topic: fmt.Sprintf("realtime:public:%s", table),
+		Topic: fmt.Sprintf("realtime:public:%s", strings.Join(tableNames, ",")),
 		Ref:   uuid.New().String(),
 	}
 	payload.Payload.Config.Broadcast.Self = true
 	chainId := viper.GetInt("chainId")
-	payload.Payload.Config.PostgresChanges = []PostgresChange{
-		{
+	for _, table := range tableNames {
+		payload.Payload.Config.PostgresChanges = append(payload.Payload.Config.PostgresChanges, PostgresChange{
 			Event:  "*",      // Listen to all events (INSERT, UPDATE, DELETE)
 			Schema: "public", // Database schema
 			Table:  table,    // Table name
 			Filter: fmt.Sprintf("chain_id=eq.%d", chainId),
-		},
+		})
 	}
+	return payload
+}
 
-	// Send subscription request
-	if err := s.client.Conn.WriteJSON(payload); err != nil {
-		log.Fatalf("Failed to subscribe: %v", err)
+// dispatch handles a change immediately or, when recordDebounce is set, coalesces
+// changes for the same table and chain arriving within the window into a single
+// handler pass using the latest record
+func (s *Subscription) dispatch(ctx context.Context, changes *PostgresChanges) {
+	debounce := viper.GetDuration("recordDebounce")
+	if debounce <= 0 {
+		s.handle(ctx, changes)
+		return
 	}
-	fmt.Println("Subscribed to table changes.")
-	return nil
+
+	// Changes from different tables carry different fields, so they are never coalesced together
+	key := fmt.Sprintf("%s:%d", changes.Payload.Data.Table, changes.Payload.Data.Record.ChainID)
+
+	s.debounceMux.Lock()
+	defer s.debounceMux.Unlock()
+
+	s.pending[key] = changes
+	if timer, exists := s.timers[key]; exists {
+		// Restart the window so the pass runs once updates settle
+		timer.Reset(debounce)
+		return
+	}
+	s.timers[key] = time.AfterFunc(debounce, func() {
+		s.flush(ctx, key)
+	})
+}
+
+// flush handles the latest pending change for a table and chain once its debounce window elapses
+func (s *Subscription) flush(ctx context.Context, key string) {
+	s.debounceMux.Lock()
+	changes := s.pending[key]
+	delete(s.pending, key)
+	delete(s.timers, key)
+	s.debounceMux.Unlock()
+
+	if changes != nil {
+		s.handle(ctx, changes)
+	}
+}
+
+// handle runs the handlers for a change, one change at a time
+func (s *Subscription) handle(ctx context.Context, changes *PostgresChanges) {
+	s.handleMux.Lock()
+	defer s.handleMux.Unlock()
+
+	s.logRecordDiff(changes.Payload.Data.Table, changes.Payload.Data.Record)
+	if err := changes.HandleMessage(ctx); err != nil {
+		log.Printf("Failed to handle message: %v", err)
+	}
+}
+
+// logRecordDiff logs the fields that changed since the last record seen for the same
+// table and chain, then remembers the record for the next change
+func (s *Subscription) logRecordDiff(table string, record handlers.Record) {
+	key := fmt.Sprintf("%s:%d", table, record.ChainID)
+
+	s.recordsMux.Lock()
+	previous, seen := s.lastRecords[key]
+	s.lastRecords[key] = record
+	s.recordsMux.Unlock()
+
+	if !seen {
+		log.Printf("Record %d (%s, chain %d): first record seen, no previous state to diff", record.ID, table, record.ChainID)
+		return
+	}
+
+	changes := diffRecords(previous, record)
+	if len(changes) == 0 {
+		log.Printf("Record %d (%s, chain %d): no field changes", record.ID, table, record.ChainID)
+		return
+	}
+	log.Printf("Record %d (%s, chain %d) changed: %s", record.ID, table, record.ChainID, strings.Join(changes, ", "))
+}
+
+// diffRecords returns "field: old -> new" for every record field that differs, named by
+// their JSON tags. updated_at is skipped since it changes on every update
+func diffRecords(previous, current handlers.Record) []string {
+	changes := []string{}
+	previousValue := reflect.ValueOf(previous)
+	currentValue := reflect.ValueOf(current)
+	recordType := previousValue.Type()
+
+	for i := 0; i < recordType.NumField(); i++ {
+		field := recordType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || name == "updated_at" {
+			continue
+		}
+
+		oldField := previousValue.Field(i).Interface()
+		newField := currentValue.Field(i).Interface()
+		if oldTime, ok := oldField.(time.Time); ok {
+			if oldTime.Equal(newField.(time.Time)) {
+				continue
+			}
+		} else if oldField == newField {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", name, fmt.Sprint(oldField), fmt.Sprint(newField)))
+	}
+	return changes
 }
 
 // Stop closes the subscription connection
 func (s *Subscription) Stop() {
-	if err := s.client.Close(); err != nil {
-		log.Printf("Warning: failed to close subscription client: %v", err)
+	if s.client != nil {
+		if err := s.client.Close(); err != nil {
+			log.Printf("Warning: failed to close subscription client: %v", err)
+		}
+	}
+	if s.listener != nil {
+		if err := s.listener.Close(); err != nil {
+			log.Printf("Warning: failed to close postgres listener: %v", err)
+		}
 	}
 }
 
@@ -145,60 +555,293 @@ func NewPostgresChanges(message []byte, worker *worker.Worker) (*PostgresChanges
 	if err := json.Unmarshal(message, &changes); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
+	var raw struct {
+		Payload struct {
+			Data struct {
+				Record map[string]json.RawMessage `json:"record"`
+			} `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(message, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if err := markActive(&changes, raw.Payload.Data.Record); err != nil {
+		return nil, err
+	}
 	changes.Worker = worker
 	return &changes, nil
 }
 
+// HandleOutcome summarizes what a handler pass did for a record
+type HandleOutcome struct {
+	Table     string           `json:"table"`
+	RecordID  int              `json:"recordId"`
+	ChainID   int              `json:"chainId"`
+	Skipped   string           `json:"skipped,omitempty"` // Why no handler ran, if none did
+	Handlers  []HandlerOutcome `json:"handlers"`
+	Restarted []string         `json:"restarted"` // Containers queued for recreation
+	JobQueued bool             `json:"jobQueued"` // False when the job was not queued, e.g. an identical job was already queued
+}
+
+// HandlerOutcome is the result of a single handler within a HandleOutcome
+type HandlerOutcome struct {
+	Name       string   `json:"name"`
+	EnvUpdated bool     `json:"envUpdated"`
+	Restarts   []string `json:"restarts,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
 // HandleMessage processes a database change event and updates containers if needed
-func (p *PostgresChanges) HandleMessage() error {
-	handlers := []handlers.Handler{
-		handlers.NewCoinHandler(),
-		handlers.NewImageHandler(),
-		handlers.NewNameHandler(),
-		handlers.NewExplorerHandler(),
+// Handlers stop their network calls and skip env writes once ctx is done
+func (p *PostgresChanges) HandleMessage(ctx context.Context) error {
+	_, err := p.Process(ctx)
+	return err
+}
+
+// Process runs the handlers for the change like HandleMessage and reports what they did
+func (p *PostgresChanges) Process(ctx context.Context) (*HandleOutcome, error) {
+	// Handlers and validation see the effective record, with configured defaults for empty fields
+	record := p.Payload.Data.Record.WithDefaults()
+	outcome := &HandleOutcome{
+		Table:     p.Payload.Data.Table,
+		RecordID:  record.ID,
+		ChainID:   record.ChainID,
+		Handlers:  []HandlerOutcome{},
+		Restarted: []string{},
 	}
 
-	var errors []error
+	// Never act on chains outside allowedChainIds, whether the record came from
+	// realtime or from InitialCheck
+	if !config.IsChainAllowed(record.ChainID) {
+		log.Printf("Warning: skipping record %d from %s: chain %d is not in allowedChainIds",
+			record.ID, p.Payload.Data.Table, record.ChainID)
+		outcome.Skipped = "chain is not in allowedChainIds"
+		return outcome, nil
+	}
+
+	// Validate the whole record before any handler writes to the env file
+	if err := record.ValidateFor(p.TableHandlers); err != nil {
+		if viper.GetBool("strictRecordValidation") {
+			outcome.Skipped = "record failed validation"
+			return outcome, fmt.Errorf("record %d failed validation, skipping handlers: %w", record.ID, err)
+		}
+		log.Printf("Warning: record %d failed validation: %v", record.ID, err)
+	}
+
+	handlerNames := p.TableHandlers
+	if len(handlerNames) == 0 {
+		handlerNames = handlers.HandlerNames
+	}
+	hs, err := handlers.NewHandlers(handlerNames)
+	if err != nil {
+		return outcome, fmt.Errorf("invalid handlers for table %s: %w", p.Payload.Data.Table, err)
+	}
+	if len(p.ForceKeys) > 0 {
+		for _, handler := range hs {
+			if forcer, ok := handler.(interface{ SetForceKeys([]string) }); ok {
+				forcer.SetForceKeys(p.ForceKeys)
+			}
+		}
+	}
+
+	var handlerErrs []error
 	containersToRestart := []docker.Container{}
 
-	for _, handler := range handlers {
-		result := handler.Handle(&p.Payload.Data.Record)
+	envUpdated := false
+	changes := []map[string]any{}
+
+	for i, handler := range hs {
+		result := runHandler(ctx, handler, record)
+		result.Handler = handlerNames[i]
+		logHandlerResult(p.Payload.Data.Table, record, result)
+		changes = append(changes, result.LogFields())
+		envUpdated = envUpdated || result.EnvUpdated
+
+		handlerOutcome := HandlerOutcome{Name: handlerNames[i], EnvUpdated: result.EnvUpdated}
 		if result.Error != nil {
-			errors = append(errors, fmt.Errorf("handler %T error: %w", handler, result.Error))
+			handlerOutcome.Error = result.Error.Error()
+			outcome.Handlers = append(outcome.Handlers, handlerOutcome)
+			handlerErrs = append(handlerErrs, fmt.Errorf("handler %T error: %w", handler, result.Error))
+			alerts.HandlerError(handlerNames[i], p.Payload.Data.Table, record.ID, record.ChainID, result.Error)
 			continue
 		}
+		for _, container := range result.ContainersToRestart {
+			handlerOutcome.Restarts = append(handlerOutcome.Restarts, container.Name)
+		}
+		outcome.Handlers = append(outcome.Handlers, handlerOutcome)
 		containersToRestart = append(containersToRestart, result.ContainersToRestart...)
 	}
 
-	if len(containersToRestart) > 0 {
-		added := p.Worker.AddJob(containersToRestart)
-		if !added {
-			log.Printf("Job for containers %v already in queue", containersToRestart)
+	// After a clean pass of every handler, drop prefixed keys none of them wrote anymore
+	if len(handlerErrs) == 0 && ranAllHandlers(handlerNames) {
+		removed, err := clearStaleKeys(record.ChainID, hs)
+		if err != nil {
+			handlerErrs = append(handlerErrs, err)
+		} else if len(removed) > 0 {
+			log.Printf("Cleared stale env keys for chain %d: %v", record.ChainID, removed)
+			envUpdated = true
+			if config.GetManageContainers() {
+				containersToRestart = append(containersToRestart, docker.ChainContainers(record.ChainID)...)
+			}
 		}
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("multiple handler errors: %v", errors)
+	// Record the env changes in git for auditability; failures never block the update
+	if envUpdated {
+		message := fmt.Sprintf("Update env from %s record %d (chain %d, name %q)",
+			p.Payload.Data.Table, record.ID, record.ChainID, record.Name)
+		if err := env.NewEnvForChain(record.ChainID).CommitEnvFile(message); err != nil {
+			log.Printf("Warning: failed to commit env changes: %v", err)
+		}
 	}
-	return nil
+
+	if len(containersToRestart) > 0 {
+		// AddJob logs why a job is not queued
+		outcome.JobQueued = p.Worker.AddJob(containersToRestart)
+		d := &docker.Docker{}
+		outcome.Restarted = d.GetContainerNames(d.UniqueContainers(containersToRestart))
+	}
+
+	events.Publish(events.Event{
+		Table:     p.Payload.Data.Table,
+		Record:    *record,
+		Changes:   changes,
+		HandledAt: time.Now().UTC(),
+	})
+
+	if len(handlerErrs) > 0 {
+		return outcome, fmt.Errorf("multiple handler errors: %v", handlerErrs)
+	}
+	return outcome, nil
 }
 
-// InitialCheck queries the database for existing record and processes it
+// runHandler runs a handler with the handlerTimeout deadline, so a slow handler (e.g. image
+// validation) fails on its own instead of holding up the remaining handlers
+func runHandler(ctx context.Context, handler handlers.Handler, record *handlers.Record) handlers.HandlerResult {
+	timeout := config.GetHandlerTimeout()
+	if timeout <= 0 {
+		return handler.Handle(ctx, record)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result := handler.Handle(ctx, record)
+	if result.Error != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Error = fmt.Errorf("handler timed out after %s: %w", timeout, result.Error)
+	}
+	return result
+}
+
+// logHandlerResult emits one JSON log line per handler run, for log pipelines and telemetry
+func logHandlerResult(table string, record *handlers.Record, result handlers.HandlerResult) {
+	fields := result.LogFields()
+	fields["table"] = table
+	fields["recordId"] = record.ID
+	fields["chainId"] = record.ChainID
+	line, err := json.Marshal(fields)
+	if err != nil {
+		log.Printf("Warning: failed to encode handler result: %v", err)
+		return
+	}
+	log.Printf("Handler result: %s", line)
+}
+
+// InitialCheck queries the database for existing records in every monitored table and processes them
 // This ensures containers are properly configured on service startup
-func (s *Subscription) InitialCheck(worker *worker.Worker) error {
+// It gives up after initialCheck.timeout or when ctx is cancelled; handlers still running
+// then have their network calls cancelled and skip their env writes, and no further records are processed
+func (s *Subscription) InitialCheck(ctx context.Context, worker *worker.Worker) error {
+	return runWithin(ctx, config.GetInitialCheckTimeout(), func(ctx context.Context) error {
+		if config.GetCreateMonitoredTableIfMissing() {
+			if err := createMissingTables(ctx, config.GetTables()); err != nil {
+				return err
+			}
+		}
+		_, err := s.check(ctx, worker, []int{viper.GetInt("chainId")}, config.GetForceAssertKeys())
+		return err
+	})
+}
+
+// runWithin runs check with a context ending after timeout or with ctx, and returns as soon as
+// that context ends even when check has not returned yet
+func runWithin(ctx context.Context, timeout time.Duration, check func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("initial check aborted: %w", ctx.Err())
+	}
+}
+
+// Reconcile runs the initial check on demand for the given chains, so changes made directly
+// in the database are applied without waiting for a realtime event. With no chains it covers
+// the configured chain and every chain in allowedChainIds. Handler passes are serialized with
+// realtime changes and stop once ctx is done. It returns what was done for each table and chain that has a record
+func (s *Subscription) Reconcile(ctx context.Context, worker *worker.Worker, chainIDs []int) ([]HandleOutcome, error) {
+	if len(chainIDs) == 0 {
+		chainIDs = []int{viper.GetInt("chainId")}
+		for _, chainID := range config.GetAllowedChainIDs() {
+			if !slices.Contains(chainIDs, chainID) {
+				chainIDs = append(chainIDs, chainID)
+			}
+		}
+	}
+
+	s.handleMux.Lock()
+	defer s.handleMux.Unlock()
+	return s.check(ctx, worker, chainIDs, nil)
+}
+
+// RunHandler re-applies the newest record of the chain with only the named handler, for every
+// monitored table that runs it. Like Reconcile, it is serialized with realtime changes
+func (s *Subscription) RunHandler(ctx context.Context, worker *worker.Worker, name string, chainID int) ([]HandleOutcome, error) {
+	s.handleMux.Lock()
+	defer s.handleMux.Unlock()
+	return s.checkTables(ctx, worker, handlerTables(name), []int{chainID}, nil)
+}
+
+// handlerTables returns the monitored tables running the named handler, configured to run only it
+func handlerTables(name string) []config.TableConfig {
+	tables := []config.TableConfig{}
+	for _, table := range config.GetTables() {
+		if len(table.Handlers) > 0 && !slices.Contains(table.Handlers, name) {
+			continue
+		}
+		table.Handlers = []string{name}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// check applies the newest record of every monitored table for each chain
+// forceKeys are re-asserted even when their value did not change
+func (s *Subscription) check(ctx context.Context, worker *worker.Worker, chainIDs []int, forceKeys []string) ([]HandleOutcome, error) {
+	return s.checkTables(ctx, worker, config.GetTables(), chainIDs, forceKeys)
+}
+
+// checkTables applies the newest record of each of the tables for each chain
+func (s *Subscription) checkTables(ctx context.Context, worker *worker.Worker, tables []config.TableConfig, chainIDs []int, forceKeys []string) ([]HandleOutcome, error) {
 	dbURL := viper.GetString("supabaseUrl")
-	chainId := viper.GetInt("chainId")
-	table := viper.GetString("table")
 
-	// Validate table identifier to prevent SQL injection
-	if err := safeIdentifier(table); err != nil {
-		return fmt.Errorf("table validation failed: %w", err)
+	// Validate table identifiers to prevent SQL injection
+	for _, table := range tables {
+		if err := config.SafeIdentifier(table.Name); err != nil {
+			return nil, fmt.Errorf("table validation failed: %w", err)
+		}
 	}
 
 	// Connect to the database
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer func() {
 		if closeErr := db.Close(); closeErr != nil {
@@ -206,28 +849,133 @@ func (s *Subscription) InitialCheck(worker *worker.Worker) error {
 		}
 	}()
 
-	// Create context with timeout for the query
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	return s.applyTables(ctx, db, worker, tables, chainIDs, forceKeys)
+}
+
+// applyTables applies the newest record of each of the tables in db for each chain
+func (s *Subscription) applyTables(ctx context.Context, db *sql.DB, worker *worker.Worker, tables []config.TableConfig, chainIDs []int, forceKeys []string) ([]HandleOutcome, error) {
+	outcomes := []HandleOutcome{}
+	for _, chainID := range chainIDs {
+		for _, table := range tables {
+			if err := ctx.Err(); err != nil {
+				return outcomes, err
+			}
+			outcome, err := s.initialCheckTable(ctx, db, table, chainID, worker, forceKeys)
+			if err != nil {
+				return outcomes, fmt.Errorf("table %s: %w", table.Name, err)
+			}
+			if outcome != nil {
+				outcomes = append(outcomes, *outcome)
+			}
+		}
+	}
+
+	return outcomes, nil
+}
+
+// initialCheckTable queries the existing records of a single table and processes the newest one
+// with the handlers configured for that table. When several rows match the chain (e.g. versioned
+// config), the row with the latest updated_at wins and the older ones are skipped
+// It returns nil when the chain has no record in the table
+func (s *Subscription) initialCheckTable(ctx context.Context, db *sql.DB, tableConfig config.TableConfig, chainId int, worker *worker.Worker, forceKeys []string) (*HandleOutcome, error) {
+	table := tableConfig.Name
+
+	// Create context with timeout for the query; handlers get the caller's ctx
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	// Query the current state - limit 1 since there should be only one record
-	// Use COALESCE to handle NULL values for string fields to prevent scan errors
-	// Table name is now safely validated before use
+	records, err := queryRecords(queryCtx, db, table, chainId)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *handlers.Record
+	skipped := []int{}
+	for i := range records {
+		if latest == nil {
+			latest = &records[i]
+			continue
+		}
+		skipped = append(skipped, records[i].ID)
+	}
+
+	if latest == nil {
+		return nil, nil
+	}
+	if len(skipped) > 0 {
+		log.Printf("Found %d records for chain %d in %s, applying the newest (%d) and skipping %v",
+			len(skipped)+1, chainId, table, latest.ID, skipped)
+	}
+
+	// Ignore stale records, e.g. a test row that was never cleaned up
+	if maxAge := config.GetMaxRecordAge(); maxAge > 0 && !latest.UpdatedAt.IsZero() && time.Since(latest.UpdatedAt) > maxAge {
+		log.Printf("Warning: skipping record %d for chain %d in %s: updated_at %s is older than maxRecordAge %s",
+			latest.ID, chainId, table, latest.UpdatedAt.Format(time.RFC3339), maxAge)
+		return &HandleOutcome{
+			Table:     table,
+			RecordID:  latest.ID,
+			ChainID:   latest.ChainID,
+			Handlers:  []HandlerOutcome{},
+			Restarted: []string{},
+			Skipped:   "record is older than maxRecordAge",
+		}, nil
+	}
+
+	// Don't start handlers once the check has been aborted
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Remember the applied record so the first realtime update is diffed against it
+	s.recordsMux.Lock()
+	s.lastRecords[fmt.Sprintf("%s:%d", table, latest.ChainID)] = *latest
+	s.recordsMux.Unlock()
+
+	// Create a PostgresChanges instance to reuse existing handler logic
+	changes := &PostgresChanges{
+		Event:         "postgres_changes",
+		Worker:        worker,
+		TableHandlers: tableConfig.Handlers,
+		ForceKeys:     forceKeys,
+	}
+	changes.Payload.Data.Record = *latest
+	changes.Payload.Data.Table = table
+
+	// Handle the record using the same handlers as real-time updates
+	outcome, err := changes.Process(ctx)
+	if err != nil {
+		log.Printf("Failed to handle initial record %d: %v", latest.ID, err)
+	}
+
+	return outcome, nil
+}
+
+// queryRecords returns every row of a table for the chain, newest first, limited to the
+// active rows when monitoredActiveColumn is set
+// The table name must already be validated with config.SafeIdentifier
+func queryRecords(ctx context.Context, db *sql.DB, table string, chainId int) ([]handlers.Record, error) {
+	existing := map[string]bool{}
+	if hasOptionalColumns() {
+		var err error
+		if existing, err = tableColumns(ctx, db, table); err != nil {
+			return nil, err
+		}
+	}
+
+	active, err := activeCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	// Columns come from the Record fields (see recordColumns)
+	// id breaks updated_at ties so the order is deterministic
 	query := fmt.Sprintf(`
-		SELECT id, 
-		       COALESCE(name, '') as name, 
-		       COALESCE(base_token_symbol, '') as base_token_symbol, 
-		       chain_id, 
-		       COALESCE(network_logo, '') as network_logo, 
-		       COALESCE(network_logo_dark, '') as network_logo_dark, 
-		       COALESCE(favicon, '') as favicon, 
-		       COALESCE(explorer_url, '') as explorer_url, 
-		       created_at, 
-		       updated_at 
-		FROM %s WHERE chain_id = $1 LIMIT 1`, table)
+		SELECT %s
+		FROM %s WHERE chain_id = $1%s
+		ORDER BY updated_at DESC NULLS LAST, id DESC`, recordSelectList(existing), table, active)
 	rows, err := db.QueryContext(ctx, query, chainId)
 	if err != nil {
-		return fmt.Errorf("failed to query database: %w", err)
+		return nil, fmt.Errorf("failed to query database: %w", err)
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
@@ -235,54 +983,46 @@ func (s *Subscription) InitialCheck(worker *worker.Worker) error {
 		}
 	}()
 
-	// Process each record using the same handlers as real-time updates
+	records := []handlers.Record{}
 	for rows.Next() {
-		var record handlers.Record
-		err := rows.Scan(
-			&record.ID,
-			&record.Name,
-			&record.Coin,
-			&record.ChainID,
-			&record.LightLogoURL,
-			&record.DarkLogoURL,
-			&record.FaviconURL,
-			&record.ExplorerURL,
-			&record.CreatedAt,
-			&record.UpdatedAt,
-		)
+		record, err := scanRecord(rows)
 		if err != nil {
-			return fmt.Errorf("failed to scan row: %w", err)
+			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
+		records = append(records, record)
+	}
 
-		// Create a PostgresChanges instance to reuse existing handler logic
-		changes := &PostgresChanges{
-			Event:  "postgres_changes",
-			Worker: worker,
-		}
-		changes.Payload.Data.Record = record
-		changes.Payload.Data.Table = table
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return records, nil
+}
 
-		// Handle the record
-		if err := changes.HandleMessage(); err != nil {
-			log.Printf("Failed to handle initial record %d: %v", record.ID, err)
-			continue
-		}
+// LatestRecord returns the newest record of a table for the chain, or nil when there is none
+func LatestRecord(table string, chainId int) (*handlers.Record, error) {
+	if err := config.SafeIdentifier(table); err != nil {
+		return nil, fmt.Errorf("table validation failed: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return fmt.Errorf("error iterating rows: %w", err)
+	db, err := sql.Open("postgres", viper.GetString("supabaseUrl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Warning: failed to close database connection: %v", closeErr)
+		}
+	}()
 
-	return nil
-}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-// safeIdentifier validates that a table name is safe for SQL queries
-// Only allows alphanumeric characters and underscores, starting with a letter or underscore
-func safeIdentifier(identifier string) error {
-	// Regex pattern for safe SQL identifiers: ^[a-zA-Z_][a-zA-Z0-9_]*$
-	safePattern := regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
-	if !safePattern.MatchString(identifier) {
-		return fmt.Errorf("unsafe table identifier: %s - only alphanumeric characters and underscores allowed, must start with letter or underscore", identifier)
+	records, err := queryRecords(ctx, db, table, chainId)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
 }
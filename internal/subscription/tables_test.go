@@ -0,0 +1,87 @@
+package subscription
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/handlers"
+
+	"github.com/spf13/viper"
+)
+
+// useTwoTables configures a settings table driving the coin handler and a branding
+// table driving the name handler
+func useTwoTables(t *testing.T) string {
+	t.Helper()
+	envFile := useEnvFile(t, "")
+	viper.Set("manageContainers", false)
+	viper.Set("chainId", 1)
+	viper.Set("tables", []map[string]any{
+		{"name": "settings", "handlers": []string{"coin"}},
+		{"name": "branding", "handlers": []string{"name"}},
+	})
+	return envFile
+}
+
+func TestRouteTwoTablesProduceDistinctEnvUpdates(t *testing.T) {
+	envFile := useTwoTables(t)
+	monitored := tablesByName(config.GetTables())
+	s := New(nil)
+
+	s.route(context.Background(), change("settings", handlers.Record{ID: 1, ChainID: 1, Coin: "ETH", Name: "Ignored"}), monitored)
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_CURRENCY_SYMBOL=ETH") {
+		t.Errorf("settings change did not write the coin: %q", content)
+	}
+	if strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_NAME") {
+		t.Errorf("settings change ran the name handler: %q", content)
+	}
+
+	s.route(context.Background(), change("branding", handlers.Record{ID: 2, ChainID: 1, Name: "Aurora", Coin: "IGNORED"}), monitored)
+	content, err = os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "NEXT_PUBLIC_NETWORK_NAME=Aurora") {
+		t.Errorf("branding change did not write the name: %q", content)
+	}
+	if strings.Contains(string(content), "IGNORED") {
+		t.Errorf("branding change ran the coin handler: %q", content)
+	}
+}
+
+func TestRouteIgnoresUnmonitoredTable(t *testing.T) {
+	envFile := useTwoTables(t)
+	s := New(nil)
+
+	s.route(context.Background(), change("other", handlers.Record{ID: 1, ChainID: 1, Coin: "ETH"}), tablesByName(config.GetTables()))
+	if content, _ := os.ReadFile(envFile); len(content) != 0 {
+		t.Errorf("change from an unmonitored table wrote %q", content)
+	}
+}
+
+func TestNewJoinPayloadSubscribesEveryTable(t *testing.T) {
+	useTwoTables(t)
+
+	var names []string
+	for _, table := range config.GetTables() {
+		names = append(names, table.Name)
+	}
+	payload := NewJoinPayload(names)
+
+	changes := payload.Payload.Config.PostgresChanges
+	if len(changes) != 2 || changes[0].Table != "settings" || changes[1].Table != "branding" {
+		t.Fatalf("postgres_changes = %+v, want one entry per table", changes)
+	}
+	for _, change := range changes {
+		if change.Filter != "chain_id=eq.1" {
+			t.Errorf("filter = %q, want chain_id=eq.1", change.Filter)
+		}
+	}
+}
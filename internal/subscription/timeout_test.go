@@ -0,0 +1,137 @@
+package subscription
+
+import (
+	"blockscout-vc/internal/config"
+	"blockscout-vc/internal/handlers"
+	"blockscout-vc/internal/worker"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// slowHandler blocks until its context is done, like an image check against a hanging host
+type slowHandler struct{}
+
+func (slowHandler) Handle(ctx context.Context, record *handlers.Record) handlers.HandlerResult {
+	select {
+	case <-ctx.Done():
+		return handlers.HandlerResult{Error: ctx.Err()}
+	case <-time.After(5 * time.Second):
+		return handlers.HandlerResult{}
+	}
+}
+
+// fastHandler completes immediately
+type fastHandler struct{}
+
+func (fastHandler) Handle(ctx context.Context, record *handlers.Record) handlers.HandlerResult {
+	return handlers.HandlerResult{EnvUpdated: true}
+}
+
+func TestRunHandlerTimesOutSlowHandler(t *testing.T) {
+	viper.Set("handlerTimeout", "50ms")
+	t.Cleanup(viper.Reset)
+
+	start := time.Now()
+	result := runHandler(context.Background(), slowHandler{}, &handlers.Record{})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("slow handler ran for %s, want it cut off near 50ms", elapsed)
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "timed out after 50ms") {
+		t.Fatalf("got error %v, want a timeout error", result.Error)
+	}
+
+	result = runHandler(context.Background(), fastHandler{}, &handlers.Record{})
+	if result.Error != nil || !result.EnvUpdated {
+		t.Fatalf("fast handler result = %+v, want it to complete", result)
+	}
+}
+
+func TestRunHandlerWithoutTimeout(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result := runHandler(ctx, slowHandler{}, &handlers.Record{})
+	if result.Error == nil {
+		t.Fatal("expected the parent context to stop the handler")
+	}
+	if strings.Contains(result.Error.Error(), "timed out") {
+		t.Fatalf("got %v, a cancelled parent is not a handlerTimeout", result.Error)
+	}
+}
+
+func TestInitialCheckReturnsByTimeoutOnSlowImageHost(t *testing.T) {
+	envFile := useEnvFile(t, "")
+	viper.Set("imageValidation.allowPrivate", true)
+	viper.Set("frontendServiceName", "frontend")
+	viper.Set("frontendContainerName", "frontend-1")
+
+	// The image host accepts the HEAD request and never answers it
+	release := make(chan struct{})
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(images.Close)
+	t.Cleanup(func() { close(release) })
+
+	db := sql.OpenDB(versionedTable{rows: []map[string]driver.Value{
+		{"id": int64(1), "chain_id": int64(1), "network_logo": images.URL + "/logo.png", "updated_at": time.Now()},
+	}})
+	t.Cleanup(func() { db.Close() })
+	tables := []config.TableConfig{{Name: "silos", Handlers: []string{"image"}}}
+
+	s := New(nil)
+	checked := make(chan struct{})
+	start := time.Now()
+	err := runWithin(context.Background(), 100*time.Millisecond, func(ctx context.Context) error {
+		defer close(checked)
+		_, err := s.applyTables(ctx, db, worker.New(), tables, []int{1}, nil)
+		return err
+	})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("initial check returned after %s, want it cut off near 100ms", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The image handler sees the cancelled context and stops without writing
+	select {
+	case <-checked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the aborted check did not stop")
+	}
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 0 {
+		t.Errorf("env file = %q, want no writes from the aborted check", content)
+	}
+}
+
+func TestInitialCheckCancelledWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runWithin(ctx, time.Minute, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
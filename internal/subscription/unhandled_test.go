@@ -0,0 +1,54 @@
+package subscription
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLogUnhandledTableSuppressedAfterLimit(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("unhandledTableLogLimit", 3)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s := New(nil)
+	before := unhandledTableEvents.Value()
+	for i := 0; i < 5; i++ {
+		s.logUnhandledTable("other")
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("logged %d lines, want 3:\n%s", len(lines), logs.String())
+	}
+	if !strings.Contains(lines[2], "suppressing further messages") {
+		t.Errorf("last line = %q, want the suppression notice", lines[2])
+	}
+	// Every event is counted, logged or not
+	if got := unhandledTableEvents.Value() - before; got != 5 {
+		t.Errorf("counted %d unhandled events, want 5", got)
+	}
+}
+
+func TestLogUnhandledTableWithoutLimit(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s := New(nil)
+	for i := 0; i < 5; i++ {
+		s.logUnhandledTable("other")
+	}
+	if got := strings.Count(logs.String(), "Unhandled table: other"); got != 5 {
+		t.Errorf("logged %d times, want every event", got)
+	}
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"blockscout-vc/internal/config"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrCircuitOpen is reported for a job that failed recreation.maxFailures times in a row
+// and is no longer retried automatically
+var ErrCircuitOpen = errors.New("recreation stopped after repeated failures")
+
+// jobFailures tracks the consecutive failures of a job
+type jobFailures struct {
+	count       int
+	lastError   string
+	lastFailure time.Time
+	retryAt     time.Time // Earliest time the job is re-attempted
+}
+
+// FailedJob describes a job whose last recreation failed, as reported by the status endpoint
+type FailedJob struct {
+	JobKey      string    `json:"jobKey"`
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"lastError"`
+	LastFailure time.Time `json:"lastFailure"`
+	RetryAt     time.Time `json:"retryAt"`
+	CircuitOpen bool      `json:"circuitOpen"` // No longer retried automatically
+}
+
+// failureBackoff returns the wait before re-attempting a job that failed count times in a row:
+// recreation.failureBackoff doubled for every failure after the first, capped at recreation.failureBackoffMax
+func failureBackoff(count int) time.Duration {
+	backoff := config.GetRecreationFailureBackoff()
+	max := config.GetRecreationFailureBackoffMax()
+	if backoff <= 0 {
+		return 0
+	}
+	for i := 1; i < count && backoff < max; i++ {
+		backoff *= 2
+	}
+	return min(backoff, max)
+}
+
+// circuitOpen reports whether count consecutive failures stop automatic retries
+func circuitOpen(count int) bool {
+	maxFailures := config.GetRecreationMaxFailures()
+	return maxFailures > 0 && count >= maxFailures
+}
+
+// recordResult updates the failure count of the job: a success clears it, a failure
+// increments it and schedules the next attempt after the failure backoff
+func (w *Worker) recordResult(jobKey string, err error) {
+	w.failuresMux.Lock()
+	defer w.failuresMux.Unlock()
+
+	if err == nil {
+		delete(w.failures, jobKey)
+		return
+	}
+	failures, exists := w.failures[jobKey]
+	if !exists {
+		failures = &jobFailures{}
+		w.failures[jobKey] = failures
+	}
+	failures.count++
+	failures.lastError = err.Error()
+	failures.lastFailure = time.Now()
+	failures.retryAt = failures.lastFailure.Add(failureBackoff(failures.count))
+}
+
+// failureState returns how long the job must wait before it is re-attempted and whether
+// it is no longer retried automatically
+func (w *Worker) failureState(jobKey string) (wait time.Duration, open bool) {
+	w.failuresMux.Lock()
+	defer w.failuresMux.Unlock()
+
+	failures, exists := w.failures[jobKey]
+	if !exists {
+		return 0, false
+	}
+	return max(time.Until(failures.retryAt), 0), circuitOpen(failures.count)
+}
+
+// ResetFailures forgets the failures of every job, so an operator can retry jobs whose
+// automatic retries were stopped
+func (w *Worker) ResetFailures() {
+	w.failuresMux.Lock()
+	defer w.failuresMux.Unlock()
+	w.failures = make(map[string]*jobFailures)
+}
+
+// FailedJobs returns the jobs whose last recreation failed, sorted by job key
+func (w *Worker) FailedJobs() []FailedJob {
+	w.failuresMux.Lock()
+	defer w.failuresMux.Unlock()
+
+	jobs := make([]FailedJob, 0, len(w.failures))
+	for jobKey, failures := range w.failures {
+		jobs = append(jobs, FailedJob{
+			JobKey:      jobKey,
+			Failures:    failures.count,
+			LastError:   failures.lastError,
+			LastFailure: failures.lastFailure,
+			RetryAt:     failures.retryAt,
+			CircuitOpen: circuitOpen(failures.count),
+		})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].JobKey < jobs[j].JobKey })
+	return jobs
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/docker"
+
+	"github.com/spf13/viper"
+)
+
+func TestFailingJobBacksOffUntilCircuitOpens(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	useDockerScript(t, "echo 'Error response from daemon: no such image' >&2; exit 1")
+	backoff := 100 * time.Millisecond
+	viper.Set("recreation.failureBackoff", backoff)
+	viper.Set("recreation.failureBackoffMax", time.Minute)
+	viper.Set("recreation.maxFailures", 3)
+	logs := captureLog(t)
+
+	w := New()
+	w.docker.Output = io.Discard
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	w.Start(ctx)
+
+	containers := []docker.Container{{Name: "frontend-1", ServiceName: "frontend"}}
+	for attempt := 1; attempt <= 3; attempt++ {
+		// The previous job leaves the queue's job set just after reporting its result
+		var result <-chan error
+		var ok bool
+		for deadline := time.Now().Add(time.Second); result == nil; time.Sleep(time.Millisecond) {
+			if result, ok = w.AddJobWithResult(containers); !ok && time.Now().After(deadline) {
+				t.Fatalf("attempt %d was not queued", attempt)
+			}
+		}
+		select {
+		case err := <-result:
+			if err == nil || errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("attempt %d = %v, want the docker failure", attempt, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("attempt %d delivered no result", attempt)
+		}
+
+		failed := w.FailedJobs()
+		if len(failed) != 1 || failed[0].Failures != attempt {
+			t.Fatalf("after attempt %d FailedJobs() = %+v, want %d failures", attempt, failed, attempt)
+		}
+		wantBackoff := backoff << (attempt - 1)
+		if got := failed[0].RetryAt.Sub(failed[0].LastFailure); got != wantBackoff {
+			t.Errorf("backoff after attempt %d = %s, want %s", attempt, got, wantBackoff)
+		}
+		if failed[0].CircuitOpen != (attempt == 3) {
+			t.Errorf("circuit open after attempt %d = %t", attempt, failed[0].CircuitOpen)
+		}
+	}
+	if n := strings.Count(logs.String(), "failed recently, backing off"); n != 2 {
+		t.Errorf("the worker backed off %d times, want 2:\n%s", n, logs.String())
+	}
+
+	// Once the last job left the queue, further jobs are refused instead of retried
+	for deadline := time.Now().Add(time.Second); !strings.Contains(logs.String(), ErrCircuitOpen.Error()); time.Sleep(time.Millisecond) {
+		if _, ok := w.AddJobWithResult(containers); ok {
+			t.Fatal("a job was queued after the circuit opened")
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the job was never refused:\n%s", logs.String())
+		}
+	}
+
+	// Resetting the failures lets an operator retry the job
+	w.ResetFailures()
+	if len(w.FailedJobs()) != 0 {
+		t.Error("ResetFailures kept failed jobs")
+	}
+}
@@ -2,11 +2,13 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"log"
 	"strings"
 	"sync"
 	"time"
 
+	"blockscout-vc/internal/config"
 	"blockscout-vc/internal/docker"
 
 	"github.com/spf13/viper"
@@ -15,33 +17,51 @@ import (
 // Job represents a container recreation task with one or more containers
 type Job struct {
 	Containers []docker.Container
+	Result     chan error // Receives the recreation result when set (see AddJobWithResult)
 }
 
 // Worker manages a queue of container recreation jobs,
-// ensuring sequential processing and preventing duplicate jobs
+// preventing duplicate jobs and serializing jobs that share containers
 type Worker struct {
-	docker    *docker.Docker
-	jobs      chan Job            // Buffered channel for job queue
-	jobSet    map[string]struct{} // Set of unique jobs currently in queue
-	jobSetMux sync.Mutex          // Mutex to protect the job set
+	docker            *docker.Docker
+	jobs              chan Job                // Buffered channel for job queue
+	jobSet            map[string]struct{}     // Set of unique jobs currently in queue
+	jobSetMux         sync.Mutex              // Mutex to protect the job set
+	concurrency       int                     // Number of jobs processed in parallel
+	containerLocks    map[string]*sync.Mutex  // Per-container locks keyed by docker.ContainerKey, so overlapping jobs serialize
+	containerLocksMux sync.Mutex              // Mutex to protect the container locks map
+	lastRecreated     map[string]time.Time    // Last recreation attempt per container, for containerCooldown
+	lastRecreatedMux  sync.Mutex              // Mutex to protect lastRecreated
+	failures          map[string]*jobFailures // Consecutive failures per job key, for the failure backoff
+	failuresMux       sync.Mutex              // Mutex to protect failures
 }
 
 // New creates a new Worker instance with a job buffer of 100
+// Concurrency is read from workerConcurrency and defaults to 1 (serial processing)
 func New() *Worker {
+	concurrency := viper.GetInt("workerConcurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
 	return &Worker{
-		docker:    docker.NewDocker(),
-		jobs:      make(chan Job, 100),
-		jobSet:    make(map[string]struct{}),
-		jobSetMux: sync.Mutex{},
+		docker:         docker.NewDocker(),
+		jobs:           make(chan Job, 100),
+		jobSet:         make(map[string]struct{}),
+		jobSetMux:      sync.Mutex{},
+		concurrency:    concurrency,
+		containerLocks: make(map[string]*sync.Mutex),
+		lastRecreated:  make(map[string]time.Time),
+		failures:       make(map[string]*jobFailures),
 	}
 }
 
-// Start begins processing jobs in a separate goroutine
+// Start begins processing jobs in workerConcurrency separate goroutines
 // The worker will continue until the context is cancelled
 func (w *Worker) Start(ctx context.Context) {
 	go func() {
 		// Initial delay before starting to process jobs
-		delay := viper.GetDuration("recreationDelay")
+		delay := config.GetRecreationDelay()
 		if delay > 0 {
 			log.Printf("Worker starting in %s...", delay)
 			select {
@@ -52,33 +72,66 @@ func (w *Worker) Start(ctx context.Context) {
 			}
 		}
 
-		w.process(ctx)
+		for i := 0; i < w.concurrency; i++ {
+			go w.process(ctx)
+		}
 	}()
 }
 
 // AddJob adds a new container recreation job to the queue
-// Returns false if the job is already in queue or if containers is empty
+// Returns false if the job is already in queue, if containers is empty or if manageContainers or enable.worker is disabled
 // Returns true if the job was successfully added
 func (w *Worker) AddJob(containers []docker.Container) bool {
+	return w.addJob(Job{Containers: containers})
+}
+
+// AddJobWithResult adds a job like AddJob and returns a channel receiving the recreation
+// result once the job has run: nil on success, the recreation error otherwise, or the
+// context error if the worker stops first. The channel is nil when the job was not added
+func (w *Worker) AddJobWithResult(containers []docker.Container) (<-chan error, bool) {
+	result := make(chan error, 1)
+	if !w.addJob(Job{Containers: containers, Result: result}) {
+		return nil, false
+	}
+	return result, true
+}
+
+// addJob queues job unless an identical job is already queued, logging why a job is not queued
+func (w *Worker) addJob(job Job) bool {
+	containers := job.Containers
 	if len(containers) == 0 {
 		return false
 	}
+	if !config.GetManageContainers() {
+		log.Printf("manageContainers is disabled, not recreating %v", w.docker.GetContainerNames(containers))
+		return false
+	}
+	if !config.GetEnableWorker() {
+		log.Printf("enable.worker is disabled, not recreating %v", w.docker.GetContainerNames(containers))
+		return false
+	}
 
 	w.jobSetMux.Lock()
 	defer w.jobSetMux.Unlock()
 
 	key := w.makeKey(containers)
 	if _, exists := w.jobSet[key]; exists {
+		log.Printf("Job for containers %s already in queue", strings.Join(w.docker.GetContainerNames(containers), ","))
+		return false
+	}
+	if _, open := w.failureState(key); open {
+		log.Printf("Not recreating %s: %v (use recreate-all to retry)", key, ErrCircuitOpen)
 		return false
 	}
 
 	w.jobSet[key] = struct{}{}
-	w.jobs <- Job{Containers: containers}
+	w.jobs <- job
 	return true
 }
 
 // process is the main job processing loop
-// It handles one job at a time and removes completed jobs from the set
+// Each loop handles one job at a time, holding the locks of the job's containers
+// so that jobs sharing containers never run concurrently, and removes completed jobs from the set
 func (w *Worker) process(ctx context.Context) {
 	for {
 		select {
@@ -89,7 +142,46 @@ func (w *Worker) process(ctx context.Context) {
 			func() {
 				defer w.cleanupJob(jobKey)
 
+				containerNames := w.docker.GetContainerNames(w.docker.UniqueContainers(job.Containers))
+				unlock := w.lockContainers(w.docker.GetContainerKeys(job.Containers))
+				defer unlock()
+
+				// Back off from a job that failed recently, and stop once it failed too often
+				wait, open := w.failureState(jobKey)
+				if open {
+					log.Printf("Not recreating %v: %v", containerNames, ErrCircuitOpen)
+					job.report(ErrCircuitOpen)
+					return
+				}
+				if wait > 0 {
+					log.Printf("Recreation of %v failed recently, backing off for %s...", containerNames, wait)
+					select {
+					case <-ctx.Done():
+						job.report(ctx.Err())
+						return
+					case <-time.After(wait):
+					}
+				}
+
+				// Defer the job until every container is out of its cooldown
+				if wait := w.cooldownRemaining(containerNames); wait > 0 {
+					log.Printf("Containers %v recreated recently, waiting %s for cooldown...", containerNames, wait)
+					select {
+					case <-ctx.Done():
+						job.report(ctx.Err())
+						return
+					case <-time.After(wait):
+					}
+				}
+
 				err := w.docker.RecreateContainers(job.Containers)
+				w.markRecreated(containerNames)
+				w.recordResult(jobKey, err)
+				job.report(err)
+				if errors.Is(err, docker.ErrCommandTimeout) {
+					log.Printf("failed to recreate containers %v, docker command killed: %v", containerNames, err)
+					return
+				}
 				if err != nil {
 					log.Printf("failed to recreate containers: %v", err)
 					return
@@ -98,11 +190,7 @@ func (w *Worker) process(ctx context.Context) {
 				// Clean up the job immediately after recreation
 				w.cleanupJob(jobKey)
 
-				// Get delay from config with default value
-				delay := viper.GetDuration("recreationDelay")
-				if delay == 0 {
-					delay = 0 * time.Second // Default if not set
-				}
+				delay := config.GetRecreationDelay()
 
 				log.Printf("Container recreation completed, waiting %s before next job...", delay)
 				select {
@@ -116,11 +204,92 @@ func (w *Worker) process(ctx context.Context) {
 	}
 }
 
-// makeKey creates a unique string key for a set of container names
-// Uses docker.UniqueContainerNames to handle container name normalization
+// JobKey returns the key identifying the job for the given containers in the queue
+func (w *Worker) JobKey(containers []docker.Container) string {
+	return w.makeKey(containers)
+}
+
+// LastRecreationLog returns the buffered output of the most recent container recreation
+func (w *Worker) LastRecreationLog() []string {
+	return w.docker.LastLog()
+}
+
+// makeKey creates a unique string key for a set of containers from their docker.ContainerKey,
+// the (name, service, chain) tuple docker.UniqueContainers deduplicates on, so jobs differing
+// only by service are distinct
 func (w *Worker) makeKey(containers []docker.Container) string {
-	unique := w.docker.UniqueContainers(containers)
-	return strings.Join(w.docker.GetContainerNames(unique), ",")
+	return strings.Join(w.docker.GetContainerKeys(containers), ",")
+}
+
+// lockContainers acquires the lock of every container key (see docker.ContainerKey) and returns
+// a function releasing them. Keys must be sorted so that overlapping jobs acquire locks in the
+// same order and cannot deadlock
+func (w *Worker) lockContainers(keys []string) func() {
+	locks := make([]*sync.Mutex, 0, len(keys))
+
+	w.containerLocksMux.Lock()
+	for _, key := range keys {
+		lock, exists := w.containerLocks[key]
+		if !exists {
+			lock = &sync.Mutex{}
+			w.containerLocks[key] = lock
+		}
+		locks = append(locks, lock)
+	}
+	w.containerLocksMux.Unlock()
+
+	for _, lock := range locks {
+		lock.Lock()
+	}
+
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+// cooldownRemaining returns how long to wait until none of the named containers
+// is within containerCooldown of its last recreation
+func (w *Worker) cooldownRemaining(names []string) time.Duration {
+	cooldown := viper.GetDuration("containerCooldown")
+	if cooldown <= 0 {
+		return 0
+	}
+
+	w.lastRecreatedMux.Lock()
+	defer w.lastRecreatedMux.Unlock()
+
+	var remaining time.Duration
+	for _, name := range names {
+		last, exists := w.lastRecreated[name]
+		if !exists {
+			continue
+		}
+		if wait := cooldown - time.Since(last); wait > remaining {
+			remaining = wait
+		}
+	}
+	return remaining
+}
+
+// markRecreated records the recreation time of the named containers
+func (w *Worker) markRecreated(names []string) {
+	w.lastRecreatedMux.Lock()
+	defer w.lastRecreatedMux.Unlock()
+
+	now := time.Now()
+	for _, name := range names {
+		w.lastRecreated[name] = now
+	}
+}
+
+// report delivers the job's result to its Result channel, if any
+// Result is buffered so reporting never blocks when nobody is waiting
+func (j Job) report(err error) {
+	if j.Result != nil {
+		j.Result <- err
+	}
 }
 
 func (w *Worker) cleanupJob(jobKey string) {
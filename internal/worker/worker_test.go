@@ -0,0 +1,309 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blockscout-vc/internal/docker"
+
+	"github.com/spf13/viper"
+)
+
+// logBuffer is a bytes.Buffer safe for the concurrent writes of worker goroutines
+type logBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.String()
+}
+
+// captureLog collects the standard logger's output for the duration of the test
+func captureLog(t *testing.T) *logBuffer {
+	t.Helper()
+	buf := &logBuffer{}
+	log.SetOutput(buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return buf
+}
+
+func TestAddJobLogsWhyAJobIsNotQueued(t *testing.T) {
+	containers := []docker.Container{{Name: "frontend-1", ServiceName: "frontend"}}
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantLog string
+	}{
+		{"containers not managed", map[string]interface{}{"manageContainers": false}, "manageContainers is disabled"},
+		{"worker disabled", map[string]interface{}{"enable.worker": false}, "enable.worker is disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			for key, value := range tt.config {
+				viper.Set(key, value)
+			}
+			logs := captureLog(t)
+
+			if New().AddJob(containers) {
+				t.Fatal("AddJob queued a job")
+			}
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("log %q does not contain %q", logs.String(), tt.wantLog)
+			}
+			if strings.Contains(logs.String(), "already in queue") {
+				t.Errorf("log %q claims the job is already queued", logs.String())
+			}
+		})
+	}
+}
+
+func TestAddJobLogsDuplicate(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	containers := []docker.Container{{Name: "frontend-1", ServiceName: "frontend"}}
+	w := New()
+
+	if !w.AddJob(containers) {
+		t.Fatal("first AddJob was not queued")
+	}
+	logs := captureLog(t)
+	if w.AddJob(containers) {
+		t.Fatal("duplicate AddJob was queued")
+	}
+	if !strings.Contains(logs.String(), "Job for containers frontend-1 already in queue") {
+		t.Errorf("log %q does not report the duplicate", logs.String())
+	}
+}
+
+// tryLock reports whether lockContainers(names) is acquired within wait, releasing it if so
+func tryLock(w *Worker, names []string, wait time.Duration) bool {
+	acquired := make(chan func(), 1)
+	go func() { acquired <- w.lockContainers(names) }()
+	select {
+	case unlock := <-acquired:
+		unlock()
+		return true
+	case <-time.After(wait):
+		// Release the lock once the pending acquisition completes
+		go func() { (<-acquired)() }()
+		return false
+	}
+}
+
+func TestLockContainersDisjointJobsRunConcurrently(t *testing.T) {
+	w := New()
+	unlock := w.lockContainers([]string{"backend-1", "frontend-1"})
+	defer unlock()
+
+	if !tryLock(w, []string{"stats-1"}, time.Second) {
+		t.Fatal("a job with disjoint containers was blocked")
+	}
+}
+
+func TestLockContainersOverlappingJobsSerialize(t *testing.T) {
+	w := New()
+	unlock := w.lockContainers([]string{"backend-1", "frontend-1"})
+
+	if tryLock(w, []string{"frontend-1", "stats-1"}, 50*time.Millisecond) {
+		t.Fatal("a job sharing a container ran while the first job held it")
+	}
+	unlock()
+	if !tryLock(w, []string{"frontend-1", "stats-1"}, time.Second) {
+		t.Fatal("the overlapping job did not run after the first job finished")
+	}
+}
+
+// waitForCompletedJobs waits until the worker logged n successful recreations. The worker
+// reads its config once more after reporting a job's result, so tests wait for that log
+// before resetting the config
+func waitForCompletedJobs(t *testing.T, logs *logBuffer, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); strings.Count(logs.String(), "Container recreation completed") < n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the worker did not complete %d jobs:\n%s", n, logs.String())
+		}
+	}
+}
+
+// useDockerStub puts a docker binary that always succeeds first on PATH and configures
+// a compose file, so jobs run without a docker daemon
+func useDockerStub(t *testing.T) {
+	t.Helper()
+	useDockerScript(t, "exit 0")
+}
+
+// useDockerScript is useDockerStub with a docker binary running the shell script body
+func useDockerScript(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the docker stub is a shell script")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	composeFile := filepath.Join(dir, "docker-compose.yaml")
+	if err := os.WriteFile(composeFile, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	viper.Set("pathToDockerCompose", composeFile)
+	viper.Set("recreationDelay", 0)
+}
+
+func TestContainerCooldownDefersSecondJob(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	useDockerStub(t)
+	cooldown := 300 * time.Millisecond
+	viper.Set("containerCooldown", cooldown)
+	logs := captureLog(t)
+
+	w := New()
+	w.docker.Output = io.Discard
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	w.Start(ctx)
+
+	containers := []docker.Container{{Name: "frontend-1", ServiceName: "frontend"}}
+	first, ok := w.AddJobWithResult(containers)
+	if !ok {
+		t.Fatal("first job was not queued")
+	}
+	if err := <-first; err != nil {
+		t.Fatalf("first job: %v", err)
+	}
+	firstDone := time.Now()
+
+	// The first job leaves the queue's job set just after reporting its result
+	var second <-chan error
+	for deadline := time.Now().Add(time.Second); second == nil; time.Sleep(time.Millisecond) {
+		if second, ok = w.AddJobWithResult(containers); !ok && time.Now().After(deadline) {
+			t.Fatal("second job was not queued")
+		}
+	}
+	if err := <-second; err != nil {
+		t.Fatalf("second job: %v", err)
+	}
+	if waited := time.Since(firstDone); waited < cooldown-50*time.Millisecond {
+		t.Errorf("second job finished %s after the first, want it to wait out the %s cooldown", waited, cooldown)
+	}
+
+	waitForCompletedJobs(t, logs, 2)
+}
+
+func TestAddJobWithResultDeliversRecreationResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{"success", "exit 0", false},
+		{"failure", "echo 'Error response from daemon: conflict' >&2; exit 1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			useDockerScript(t, tt.script)
+			logs := captureLog(t)
+
+			w := New()
+			w.docker.Output = io.Discard
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			w.Start(ctx)
+
+			result, ok := w.AddJobWithResult([]docker.Container{{Name: "frontend-1", ServiceName: "frontend"}})
+			if !ok || result == nil {
+				t.Fatal("job was not queued")
+			}
+			select {
+			case err := <-result:
+				if (err != nil) != tt.wantErr {
+					t.Errorf("result = %v, want error: %t", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no result delivered")
+			}
+			if !tt.wantErr {
+				waitForCompletedJobs(t, logs, 1)
+			}
+		})
+	}
+}
+
+func TestAddJobWithResultNotQueued(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("manageContainers", false)
+
+	if result, ok := New().AddJobWithResult([]docker.Container{{Name: "frontend-1"}}); ok || result != nil {
+		t.Errorf("AddJobWithResult() = %v, %t, want no channel for a job that is not queued", result, ok)
+	}
+}
+
+func TestJobsDifferingOnlyByServiceAreBothRecreated(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	calls := filepath.Join(t.TempDir(), "calls")
+	useDockerScript(t, `echo "$@" >> `+calls)
+	logs := captureLog(t)
+
+	w := New()
+	w.docker.Output = io.Discard
+	frontend := []docker.Container{{Name: "explorer-1", ServiceName: "frontend"}}
+	proxy := []docker.Container{{Name: "explorer-1", ServiceName: "proxy"}}
+	if w.JobKey(frontend) == w.JobKey(proxy) {
+		t.Fatalf("jobs for services frontend and proxy share the key %s", w.JobKey(frontend))
+	}
+
+	// Both are queued before the worker starts, so the first is still pending when the second arrives
+	first, ok := w.AddJobWithResult(frontend)
+	if !ok {
+		t.Fatal("frontend job was not queued")
+	}
+	second, ok := w.AddJobWithResult(proxy)
+	if !ok {
+		t.Fatalf("proxy job was dropped while the frontend job was pending:\n%s", logs.String())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	w.Start(ctx)
+
+	for _, result := range []<-chan error{first, second} {
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Fatalf("job failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no result delivered")
+		}
+	}
+	waitForCompletedJobs(t, logs, 2)
+
+	recorded, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, service := range []string{"frontend", "proxy"} {
+		if !strings.Contains(string(recorded), " "+service) {
+			t.Errorf("service %s was not recreated; docker calls:\n%s", service, recorded)
+		}
+	}
+}
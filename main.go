@@ -12,6 +12,10 @@ func main() {
 	c := cmd.RootCmd()
 	// Add the sidecar subcommand
 	c.AddCommand(cmd.StartSidecarCmd())
+	// Add the selftest subcommand
+	c.AddCommand(cmd.SelfTestCmd())
+	// Add the realtime-tail subcommand
+	c.AddCommand(cmd.RealtimeTailCmd())
 
 	// Execute the command and handle any errors
 	if err := c.Execute(); err != nil {